@@ -18,6 +18,7 @@ package v1aplha1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -25,14 +26,25 @@ import (
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
 // OCISecretSpec defines the desired state of OCISecret
+// +kubebuilder:validation:XValidation:rule="has(self.artifactRef) || (has(self.ArtefactRegistry) && has(self.orasArtefact))",message="artifactRef or ArtefactRegistry and orasArtefact must be set"
 type OCISecretSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
-	// +kubebuilder:validation:Required
+	// ArtifactRef is the full reference of the artifact: the registry, the repository and the tag, digest or
+	// tag pinned to a digest, e.g. ghcr.io/org/configs:v1 or ghcr.io/org/configs:v1@sha256:... It takes
+	// precedence over ArtefactRegistry and OrasArtefact.
+	// +kubebuilder:validation:Optional
+	ArtifactRef string `json:"artifactRef,omitempty"`
+
+	// OrasArtefact is the tag or digest of the artifact in ArtefactRegistry.
+	// Deprecated: use ArtifactRef instead, it is ignored if ArtifactRef is set.
+	// +kubebuilder:validation:Optional
 	OrasArtefact string `json:"orasArtefact,omitempty"`
 
-	// +kubebuilder:validation:Required
+	// ArtefactRegistry is the repository of the artifact, e.g. ghcr.io/org/configs.
+	// Deprecated: use ArtifactRef instead, it is ignored if ArtifactRef is set.
+	// +kubebuilder:validation:Optional
 	ArtefactRegistry string `json:"ArtefactRegistry,omitempty"`
 
 	// +kubebuilder:validation:Optional
@@ -42,31 +54,660 @@ type OCISecretSpec struct {
 	// +kubebuilder:default:={}
 	ArtefactPullSecret corev1.SecretReference `json:"ArtefactPullSecret,omitempty"`
 
+	// BasicAuthSecretRef references a Secret with the username and password keys (e.g. of type
+	// kubernetes.io/basic-auth) used to authenticate against the registry instead of the Docker
+	// config of ArtefactPullSecret.
+	// +kubebuilder:validation:Optional
+	BasicAuthSecretRef *corev1.SecretReference `json:"basicAuthSecretRef,omitempty"`
+
+	// CredentialProvider obtains short-lived credentials for the registry from the cloud the operator
+	// runs in, instead of a pull secret: aws (Amazon ECR with the default AWS credential chain, e.g.
+	// IAM roles for service accounts), gcp (Artifact Registry and GCR with the metadata server, e.g.
+	// GKE workload identity) or azure (ACR with the workload identity or managed identity). auto picks
+	// the cloud by the registry host and accesses registries of other hosts anonymously. The
+	// credentials are refreshed on every reconcile. Only one of ArtefactPullSecret, BasicAuthSecretRef
+	// and CredentialProvider may be set.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=aws;gcp;azure;auto
+	CredentialProvider string `json:"credentialProvider,omitempty"`
+
+	// AnonymousFallback retries the registry anonymously when it rejects the credentials of the
+	// ArtefactPullSecret, BasicAuthSecretRef or CredentialProvider, e.g. for public registries with
+	// stale credentials attached.
+	// Status.AuthMode reports which mode succeeded.
+	// +kubebuilder:validation:Optional
+	AnonymousFallback bool `json:"anonymousFallback,omitempty"`
+
+	// AuthScopes are requested with the bearer tokens of the registry in addition to the pull scope of
+	// the repository, e.g. repository:org/base:pull for registries that check the scopes of a base
+	// repository. They are added to the scopes of the --registry-auth-scopes flag.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:items:Pattern=`^[^:\s]+:[^\s]+:[^:\s]+$`
+	AuthScopes []string `json:"authScopes,omitempty"`
+
+	// Mirrors are registry hosts (e.g. mirror.example.com or mirror.example.com:5000) serving the same
+	// repository as the registry of the artifact. They are tried in order when the registry fails with a
+	// transient error, e.g. during an outage, with the same credentials. Status.Mirror reports the mirror
+	// the last sync used.
+	// +kubebuilder:validation:Optional
+	Mirrors []string `json:"mirrors,omitempty"`
+
 	// +kubebuilder:validation:Required
 	TargetSecret corev1.SecretReference `json:"targetSecret,omitempty"`
+
+	// IncludeReferrers lists the artifact types (e.g. signatures or SBOMs) of referrers
+	// attached to the artifact that are additionally written into the target Secret. Together they may
+	// have MaxArtifactSize bytes, or the 1MiB of a Secret if it is unset; larger referrers fail the sync
+	// with the ArtifactTooLarge reason before they are downloaded.
+	// +kubebuilder:validation:Optional
+	IncludeReferrers []string `json:"includeReferrers,omitempty"`
+
+	// Verification requires the artifact to be signed before its content is written into the target Secret.
+	// Only signatures of a public key are verified, keyless signatures with a Fulcio certificate for an
+	// issuer and subject and Rekor transparency log entries are not supported.
+	// +kubebuilder:validation:Optional
+	Verification *Verification `json:"verification,omitempty"`
+
+	// ExpectedDigest pins the digest (e.g. sha256:...) the artifact must have. Content with another
+	// digest, e.g. because the tag was moved unexpectedly, is refused with the DigestMismatch reason
+	// and the target Secret keeps its current content.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]+([+._-][a-z0-9]+)*:[a-zA-Z0-9=_-]+$`
+	ExpectedDigest string `json:"expectedDigest,omitempty"`
+
+	// MinVersion is the lowest semantic version (e.g. 1.4.0 or v1.4.0) in the org.opencontainers.image.version
+	// annotation of the artifact that is synced. Artifacts with a lower version, without the annotation or
+	// with a version that isn't a semantic version are skipped before their layers are downloaded; the target
+	// Secret keeps its current content and the SyncSkipped condition reports why.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^v?[0-9]+(\.[0-9]+){0,2}(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`
+	MinVersion string `json:"minVersion,omitempty"`
+
+	// NotBefore is the earliest creation time in the org.opencontainers.image.created annotation of the
+	// artifact that is synced. Older artifacts and artifacts without the annotation are skipped like
+	// artifacts below MinVersion.
+	// +kubebuilder:validation:Optional
+	NotBefore *metav1.Time `json:"notBefore,omitempty"`
+
+	// MaxArtifactSize is the maximum total size of the artifact. Larger artifacts are
+	// rejected before they are downloaded completely.
+	// +kubebuilder:validation:Optional
+	MaxArtifactSize *resource.Quantity `json:"maxArtifactSize,omitempty"`
+
+	// MaxFileCount is the maximum number of files of the artifact, counted after extracting archives.
+	// Artifacts with more files are rejected while they are read, before they are written anywhere.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	MaxFileCount int32 `json:"maxFileCount,omitempty"`
+
+	// AutoShard splits files that don't fit into the target Secret across numbered shard Secrets
+	// (<name>-0, <name>-1, ...) in its namespace instead of failing with SecretTooLarge. The target
+	// Secret then only holds the key .oci-sync-shards.json, which maps every key to its shard Secret.
+	// The shard Secrets are owned by the OCISecret and deleted once the files fit again.
+	// +kubebuilder:validation:Optional
+	AutoShard bool `json:"autoShard,omitempty"`
+
+	// Proxy is the URL of the proxy used to reach the registry (e.g. http://proxy.example.com:3128),
+	// unless the registry is in the NO_PROXY environment variable of the operator.
+	// If empty, the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables of the operator apply.
+	// +kubebuilder:validation:Optional
+	Proxy string `json:"proxy,omitempty"`
+
+	// UserAgent is sent to the registry instead of the operator's default User-Agent
+	// (oci-resource-sync-operator/<version> or the --registry-user-agent flag), e.g. for registries
+	// behind a WAF that filters on it.
+	// +kubebuilder:validation:Optional
+	UserAgent string `json:"userAgent,omitempty"`
+
+	// ClientCertSecretRef references a Secret with the client certificate (tls.crt), its key (tls.key)
+	// and optionally a CA bundle (ca.crt) used for mutual TLS with the registry.
+	// +kubebuilder:validation:Optional
+	ClientCertSecretRef *corev1.SecretReference `json:"clientCertSecretRef,omitempty"`
+
+	// DryRun computes the changes a sync would apply to the target Secret and reports them in
+	// the status and as events without writing the target Secret.
+	// +kubebuilder:validation:Optional
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// AdoptExisting allows taking over target Secrets that already exist without being managed by
+	// an OCISecret, e.g. hand-created ones. Their content is replaced and the OCISecret becomes their
+	// owner. By default such Secrets are left alone and the sync fails with TargetConflict.
+	// +kubebuilder:validation:Optional
+	AdoptExisting bool `json:"adoptExisting,omitempty"`
+
+	// SetOwnerReference sets an owner reference to the OCISecret on the target Secrets, so the garbage
+	// collector deletes them together with the OCISecret. Set it to false for target Secrets that must not
+	// carry the owner reference, e.g. Secrets in other namespaces than a cluster-scoped OCISecret that are
+	// watched by tools rejecting foreign owners. The OCISecret then gets a finalizer that deletes its target
+	// Secrets before it is removed. Defaults to true.
+	// +kubebuilder:validation:Optional
+	SetOwnerReference *bool `json:"setOwnerReference,omitempty"`
+
+	// Suspend stops syncing: the target Secrets keep their current content and the registry isn't
+	// polled until Suspend is unset again.
+	// +kubebuilder:validation:Optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// ForceResyncInterval rewrites the target Secrets at this interval (e.g. 24h) even if the digest of the
+	// artifact didn't change, so edits of the synced keys are reverted and consumers watching the Secrets
+	// see a new resourceVersion. It is independent of the polling of the registry. Disabled if not set.
+	// +kubebuilder:validation:Optional
+	ForceResyncInterval *metav1.Duration `json:"forceResyncInterval,omitempty"`
+
+	// KeyLayout stores the files in subdirectories of the artifact under keys that encode their path.
+	// If not set, only the files at the top level of the artifact are synced.
+	// +kubebuilder:validation:Optional
+	KeyLayout *KeyLayout `json:"keyLayout,omitempty"`
+
+	// ExtractArchives replaces tar and tar+gzip files of the artifact by the files they contain.
+	// Entries that would be extracted outside of the archive's directory are rejected. Without
+	// MaxArtifactSize the extracted files may have 1 MiB in total, the maximum size of a Secret.
+	// +kubebuilder:validation:Optional
+	ExtractArchives bool `json:"extractArchives,omitempty"`
+
+	// Decompress decompresses gzip and zstd compressed files of the artifact before they are stored.
+	// Files that aren't compressed are stored unchanged. Without MaxArtifactSize the decompressed files
+	// may have 1 MiB in total, the maximum size of a Secret.
+	// +kubebuilder:validation:Optional
+	Decompress *Decompress `json:"decompress,omitempty"`
+
+	// Transform maps keys of the target Secret to a transformation of the file content that is applied
+	// after filtering: base64encode stores the content base64 encoded, base64decode stores the decoded
+	// content of a base64 encoded file.
+	// +kubebuilder:validation:Optional
+	Transform map[string]FileTransform `json:"transform,omitempty"`
+
+	// AdditionalTargets are further Secrets the files of the artifact are written to, so one download
+	// fans out to several Secrets. Each target tracks the synced digest on its own.
+	// +kubebuilder:validation:Optional
+	AdditionalTargets []SecretTarget `json:"additionalTargets,omitempty"`
+
+	// MergeMode controls how the files are written into the target Secrets: replace (the default)
+	// replaces all keys, merge only sets the keys of the files and keeps the other keys, e.g. keys
+	// managed by hand. Keys written by a previous sync whose files were removed are deleted in both modes.
+	// +kubebuilder:validation:Optional
+	MergeMode MergeMode `json:"mergeMode,omitempty"`
+
+	// IncludeManifest adds the key .oci-sync-manifest.json to the target Secret, listing the synced
+	// files with their sizes and file modes, the digest of the artifact and the time of the sync, so
+	// consumers can tell at runtime which keys were populated and restore the permissions of the files.
+	// +kubebuilder:validation:Optional
+	IncludeManifest bool `json:"includeManifest,omitempty"`
+
+	// IncludeConfig adds the config blob of the artifact to the files under the key .oci-config, for
+	// artifact formats that keep data in the config rather than in layers. The empty config of
+	// artifacts pushed without one is skipped.
+	// +kubebuilder:validation:Optional
+	IncludeConfig bool `json:"includeConfig,omitempty"`
+
+	// FailOnEmpty refuses to update the target Secret when no file is left to sync, because the
+	// artifact has no files or the file selection matches none of them. By default the target
+	// Secret is written without keys and only the EmptyArtifact condition warns about it.
+	// +kubebuilder:validation:Optional
+	FailOnEmpty bool `json:"failOnEmpty,omitempty"`
+
+	// RequireAllFiles fails the sync and leaves the target Secret unchanged if an entry of Sync.Files
+	// matches no file of the artifact. By default the missing files are only reported with the
+	// MissingFiles condition.
+	// +kubebuilder:validation:Optional
+	RequireAllFiles bool `json:"requireAllFiles,omitempty"`
+
+	// Platform (os/architecture[/variant], e.g. linux/amd64) selects the manifest of an artifact that is
+	// published as an image index. If not set, the files of all manifests of the index are merged; files
+	// with the same name must have the same content then. It's ignored for single-manifest artifacts.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^[^/]+/[^/]+(/[^/]+)?$`
+	Platform string `json:"platform,omitempty"`
+
+	// PropagateAnnotations lists patterns (e.g. org.opencontainers.image.*) of the annotations of the
+	// artifact's manifest that are copied onto the target Secrets, e.g. its version or source revision.
+	// Annotations that disappear from the manifest are removed from the Secrets again.
+	// +kubebuilder:validation:Optional
+	PropagateAnnotations []string `json:"propagateAnnotations,omitempty"`
+
+	// TargetSecretMetadata are labels and annotations set on the target Secrets, e.g. for network
+	// policies or backup selectors. The annotations the operator maintains itself take precedence.
+	// +kubebuilder:validation:Optional
+	TargetSecretMetadata *SecretMetadata `json:"targetSecretMetadata,omitempty"`
+}
+
+// SecretMetadata are labels and annotations of a Secret.
+type SecretMetadata struct {
+	// Labels are set on the Secret.
+	// +kubebuilder:validation:Optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations are set on the Secret.
+	// +kubebuilder:validation:Optional
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
+// MergeMode controls how the files are written into the target Secrets.
+// +kubebuilder:validation:Enum=replace;merge
+type MergeMode string
+
+const (
+	// MergeModeReplace replaces all keys of the target Secret with the files.
+	MergeModeReplace MergeMode = "replace"
+	// MergeModeMerge sets the keys of the files and keeps the other keys of the target Secret.
+	MergeModeMerge MergeMode = "merge"
+)
+
+// FileTransform is a transformation of the content of a file.
+// +kubebuilder:validation:Enum=none;base64encode;base64decode
+type FileTransform string
+
+const (
+	// FileTransformNone stores the content unchanged.
+	FileTransformNone FileTransform = "none"
+	// FileTransformBase64Encode stores the content base64 encoded.
+	FileTransformBase64Encode FileTransform = "base64encode"
+	// FileTransformBase64Decode stores the decoded content of base64 encoded files.
+	FileTransformBase64Decode FileTransform = "base64decode"
+)
+
+// Decompress configures the decompression of the files of the artifact.
+type Decompress struct {
+	// Files limits the decompression to the files with these keys; empty decompresses all files.
+	// +kubebuilder:validation:Optional
+	Files []string `json:"files,omitempty"`
+}
+
+// SecretTarget is a Secret that receives a selection of the files of the artifact.
+type SecretTarget struct {
+	// Name is the name of the Secret.
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Namespace is the namespace of the Secret; it defaults to the namespace of a namespaced OCISecret.
+	// +kubebuilder:validation:Optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Files lists the keys written into this Secret; empty means all files.
+	// +kubebuilder:validation:Optional
+	Files []string `json:"files,omitempty"`
+
+	// Rename maps keys of the artifact to the keys they are stored under in this Secret.
+	// +kubebuilder:validation:Optional
+	Rename map[string]string `json:"rename,omitempty"`
+}
+
+// KeyLayout configures how the paths of files in subdirectories are mapped to Secret keys.
+type KeyLayout struct {
+	// Separator replaces the path separator in the keys, e.g. conf/app.yaml is stored as conf__app.yaml.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^[-._a-zA-Z0-9]+$`
+	// +kubebuilder:default:="__"
+	Separator string `json:"separator,omitempty"`
+
+	// ManifestKey is the key of a JSON document that maps every key to the original path of its file,
+	// so the directory tree can be rebuilt even if file names contain the separator. Empty disables it.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^[-._a-zA-Z0-9]+$`
+	ManifestKey string `json:"manifestKey,omitempty"`
+}
+
+// Verification configures the signature verification of the artifact. It checks a cosign signature
+// against a public key, keyless verification of the certificate issuer and subject is out of scope.
+type Verification struct {
+	// PublicKey is the PEM encoded public key (ECDSA, RSA or Ed25519) of the cosign
+	// signature that must be attached to the artifact through the OCI referrers API.
+	// +kubebuilder:validation:Required
+	PublicKey string `json:"publicKey"`
+}
+
+// +kubebuilder:pruning:PreserveUnknownFields
 type Sync struct {
 
+	// Files are the files of the artifact that are synced; empty means all files. Entries may be
+	// shell-style glob patterns such as *.yaml or config-?.json.
 	// +kubebuilder:validation:Optional
 	Files []string `json:"Files,omitempty"`
+
+	// ExcludeFiles are files (or glob patterns) of the artifact that are not synced. When Files is
+	// set as well, Files is applied first and ExcludeFiles removes files from that selection.
+	// +kubebuilder:validation:Optional
+	ExcludeFiles []string `json:"ExcludeFiles,omitempty"`
+
+	// LayerTitles limits the download to the layers with these org.opencontainers.image.title
+	// annotations, so the other layers of large artifacts are never downloaded. Unlike Files it
+	// also applies to the AdditionalTargets.
+	// +kubebuilder:validation:Optional
+	LayerTitles []string `json:"LayerTitles,omitempty"`
+
+	// MediaTypes limits the download to the layers whose media type matches one of these patterns (e.g.
+	// application/vnd.example.config+yaml or application/vnd.example.*), so blobs of other media types are
+	// never downloaded. It applies after LayerTitles and to the AdditionalTargets as well. The sync fails
+	// with the NoMatchingMediaType reason if no layer matches.
+	// +kubebuilder:validation:Optional
+	MediaTypes []string `json:"mediaTypes,omitempty"`
+
+	// StripPrefix is a directory of the artifact (e.g. dist) that is removed from the paths of the
+	// files below it before they are stored, so dist/app.yaml is stored as app.yaml. It applies before
+	// KeyLayout and to the files extracted from archives. Files that end up with the same key fail the sync with
+	// the ConflictingFiles reason.
+	// +kubebuilder:validation:Optional
+	StripPrefix string `json:"stripPrefix,omitempty"`
+
+	// KeyTemplate is a Go text/template the keys of the synced files are rendered with, e.g.
+	// config-{{ .Tag }}.yaml or {{ index .Annotations "org.opencontainers.image.version" }}-{{ .Filename }}.
+	// It can use the key of the file (.Filename), the tag (.Tag) and digest (.Digest) of the artifact and
+	// the annotations of its manifest (.Annotations). It applies to the target Secret after Files and
+	// ExcludeFiles; keys that aren't valid Secret keys and files rendered to the same key fail the sync.
+	// +kubebuilder:validation:Optional
+	KeyTemplate string `json:"keyTemplate,omitempty"`
+
+	// KeyNamePolicy controls what happens to files whose keys aren't valid Secret keys (only letters,
+	// digits, "-", "_" and "."), e.g. files with spaces or colons in their names: reject (the default)
+	// fails the sync, sanitize replaces the invalid characters with "_". It applies after KeyTemplate and
+	// Transforms; files sanitized to the same key fail the sync.
+	// +kubebuilder:validation:Optional
+	KeyNamePolicy KeyNamePolicy `json:"keyNamePolicy,omitempty"`
+
+	// Transforms is a pipeline of steps applied in order to the synced files of the target Secret, after
+	// Files, ExcludeFiles and Transform and before KeyTemplate, e.g. to decompress files, decode them and
+	// rename them afterwards. A step that fails, e.g. on content that isn't valid base64, fails the sync
+	// with the InvalidTransform reason.
+	// +kubebuilder:validation:Optional
+	Transforms []TransformStep `json:"transforms,omitempty"`
+}
+
+// KeyNamePolicy controls how the keys of files that aren't valid Secret keys are handled.
+// +kubebuilder:validation:Enum=reject;sanitize
+type KeyNamePolicy string
+
+const (
+	// KeyNamePolicyReject fails the sync on keys that aren't valid Secret keys.
+	KeyNamePolicyReject KeyNamePolicy = "reject"
+	// KeyNamePolicySanitize replaces the characters of the keys that aren't allowed in Secret keys with "_".
+	KeyNamePolicySanitize KeyNamePolicy = "sanitize"
+)
+
+// TransformStepType is the type of a step of the Sync.Transforms pipeline.
+// +kubebuilder:validation:Enum=decode;decompress;rename;filter
+type TransformStepType string
+
+const (
+	// TransformStepDecode decodes base64 encoded files.
+	TransformStepDecode TransformStepType = "decode"
+	// TransformStepDecompress decompresses gzip and zstd compressed files; other files are kept unchanged.
+	TransformStepDecompress TransformStepType = "decompress"
+	// TransformStepRename replaces the matches of the regular expression From in the keys with To.
+	TransformStepRename TransformStepType = "rename"
+	// TransformStepFilter keeps the files matching Files, or drops them with Exclude.
+	TransformStepFilter TransformStepType = "filter"
+)
+
+// TransformStep is a step of the Sync.Transforms pipeline.
+type TransformStep struct {
+	// Type is the transformation of the step.
+	// +kubebuilder:validation:Required
+	Type TransformStepType `json:"type"`
+
+	// Files limits the step to the files whose keys match one of these names or glob patterns; empty
+	// applies the step to all files. For filter steps they select the files that are kept.
+	// +kubebuilder:validation:Optional
+	Files []string `json:"files,omitempty"`
+
+	// Exclude makes a filter step drop the files matching Files instead of keeping them.
+	// +kubebuilder:validation:Optional
+	Exclude bool `json:"exclude,omitempty"`
+
+	// From is the regular expression a rename step replaces in the keys, e.g. ^dist/ or \.tmpl$.
+	// +kubebuilder:validation:Optional
+	From string `json:"from,omitempty"`
+
+	// To is the replacement of the matches of From; it may refer to submatches with ${1}. Keys that
+	// aren't valid Secret keys and files renamed to the same key fail the sync.
+	// +kubebuilder:validation:Optional
+	To string `json:"to,omitempty"`
 }
 
 // OCISecretStatus defines the observed state of OCISecret
 type OCISecretStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+
+	// Conditions represent the latest available observations of the OCISecret's state.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the generation of the OCISecret that was last synced successfully.
+	// A lower value than metadata.generation means the current spec hasn't been applied yet.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// AuthMode is the authentication mode of the last successful sync: PullSecret, CredentialProvider,
+	// Anonymous or AnonymousFallback (the configured credentials were rejected).
+	// +optional
+	AuthMode string `json:"authMode,omitempty"`
+
+	// Mirror is the host of the mirror (see Spec.Mirrors) the artifact of the last successful sync was
+	// pulled from; empty if it was pulled from the registry itself.
+	// +optional
+	Mirror string `json:"mirror,omitempty"`
+
+	// FileDigests maps the keys of the target Secret to the sha256 digest of their content as of the
+	// last sync that wrote the target Secret.
+	// +optional
+	FileDigests map[string]string `json:"fileDigests,omitempty"`
+
+	// LastForceSync is the value of the force-sync annotation that was handled by the last
+	// successful sync; a different annotation value forces the next sync.
+	// +optional
+	LastForceSync string `json:"lastForceSync,omitempty"`
+
+	// Plan lists the changes the last sync would apply to the target Secret while DryRun is set.
+	// +optional
+	Plan *SyncPlan `json:"plan,omitempty"`
+
+	// SyncHistory lists the digests the target Secret was synced to, newest first, so it can be told
+	// which content a mutable tag pointed to at which time. It keeps the last MaxSyncHistory entries.
+	// +optional
+	SyncHistory []SyncHistoryEntry `json:"syncHistory,omitempty"`
+
+	// PendingDigest is a new digest of the artifact that wasn't synced yet.
+	// +optional
+	PendingDigest string `json:"pendingDigest,omitempty"`
+
+	// PendingDigestTime is when the operator first observed the PendingDigest.
+	// +optional
+	PendingDigestTime *metav1.Time `json:"pendingDigestTime,omitempty"`
+
+	// LastSyncLatency is the time from first observing a new digest of the artifact until the target
+	// Secret was synced to it, as of the last sync that followed a change of the artifact.
+	// +optional
+	LastSyncLatency *metav1.Duration `json:"lastSyncLatency,omitempty"`
+
+	// LastWriteTime is when the target Secret was last written, by a sync to a new digest, a spec change,
+	// a forced sync or a resync of ForceResyncInterval.
+	// +optional
+	LastWriteTime *metav1.Time `json:"lastWriteTime,omitempty"`
+}
+
+// MaxSyncHistory is the number of entries kept in OCISecretStatus.SyncHistory.
+const MaxSyncHistory = 10
+
+// SyncHistoryEntry records a sync of the target Secret to a new digest.
+type SyncHistoryEntry struct {
+	// Digest is the digest of the synced artifact.
+	Digest string `json:"digest"`
+	// Time is when the target Secret was synced to the digest.
+	Time metav1.Time `json:"time"`
 }
 
+// SyncPlan describes the changes a sync would apply to the target Secret.
+type SyncPlan struct {
+	// Digest is the digest of the artifact the plan was computed for.
+	Digest string `json:"digest,omitempty"`
+	// AddedKeys are the keys that would be added to the target Secret.
+	AddedKeys []string `json:"addedKeys,omitempty"`
+	// ChangedKeys are the keys whose content would change.
+	ChangedKeys []string `json:"changedKeys,omitempty"`
+	// RemovedKeys are the keys that would be removed from the target Secret.
+	RemovedKeys []string `json:"removedKeys,omitempty"`
+}
+
+// ForceSyncAnnotation forces the operator to download the artifact and sync the target Secret again,
+// even if its digest didn't change, whenever the value of the annotation changes (e.g. to the current
+// time). The handled value is recorded in OCISecretStatus.LastForceSync.
+const ForceSyncAnnotation = "oci-sync.brtrm.de/force-sync"
+
+// SyncManifestKey is the key of the manifest added to the target Secret with OCISecretSpec.IncludeManifest.
+const SyncManifestKey = ".oci-sync-manifest.json"
+
+// ShardManifestKey is the key of the manifest in a target Secret whose files are split across shard
+// Secrets with OCISecretSpec.AutoShard. It maps every key to the name of the shard Secret holding it.
+const ShardManifestKey = ".oci-sync-shards.json"
+
+// Authentication modes reported in OCISecretStatus.AuthMode.
+const (
+	// AuthModePullSecret means the credentials of the ArtefactPullSecret or BasicAuthSecretRef were used.
+	AuthModePullSecret = "PullSecret"
+	// AuthModeCredentialProvider means the credentials of the CredentialProvider were used.
+	AuthModeCredentialProvider = "CredentialProvider"
+	// AuthModeAnonymous means no pull secret is configured and the registry was accessed anonymously.
+	AuthModeAnonymous = "Anonymous"
+	// AuthModeAnonymousFallback means the credentials were rejected and the anonymous retry succeeded.
+	AuthModeAnonymousFallback = "AnonymousFallback"
+)
+
+const (
+	// ConditionTypeReady indicates whether the target Secret is in sync with the artifact.
+	ConditionTypeReady = "Ready"
+	// ConditionTypeTooManyKeys is an advisory condition that is true when the target Secret has more
+	// keys than the kubelet can mount efficiently.
+	ConditionTypeTooManyKeys = "TooManyKeys"
+	// ConditionTypeConflict is true while other OCISecrets write the same target Secrets.
+	ConditionTypeConflict = "Conflict"
+	// ConditionTypeSuspended is true while syncing is suspended with OCISecretSpec.Suspend.
+	ConditionTypeSuspended = "Suspended"
+	// ConditionTypeEmptyArtifact is a warning condition that is true while the artifact contains no files.
+	ConditionTypeEmptyArtifact = "EmptyArtifact"
+	// ConditionTypeMissingFiles is a warning condition that is true while entries of Sync.Files match no
+	// file of the artifact.
+	ConditionTypeMissingFiles = "MissingFiles"
+	// ConditionTypeTooLarge is a warning condition that is true while the synced data exceeds the size
+	// limit of a Secret.
+	ConditionTypeTooLarge = "TooLarge"
+	// ConditionTypeSyncSkipped is true while the artifact doesn't meet MinVersion or NotBefore and the
+	// target Secret keeps the content of the last artifact that did.
+	ConditionTypeSyncSkipped = "SyncSkipped"
+	// ConditionTypeDrift is true while the target Secrets differ from the artifact. It is only reported
+	// while the operator runs with --audit-only.
+	ConditionTypeDrift = "Drift"
+
+	// ReasonSynced is used when the target Secret has been synced successfully.
+	ReasonSynced = "Synced"
+	// ReasonSignatureInvalid is used when the artifact has no valid signature for the configured key.
+	ReasonSignatureInvalid = "SignatureInvalid"
+	// ReasonDigestMismatch is used when the digest of the artifact isn't the expected digest, or the tag of
+	// a tag pinned to a digest points to another digest.
+	ReasonDigestMismatch = "DigestMismatch"
+	// ReasonArtifactTooLarge is used when the artifact exceeds the configured maximum size.
+	ReasonArtifactTooLarge = "ArtifactTooLarge"
+	// ReasonTooManyFiles is used when the artifact has more files than the configured maximum.
+	ReasonTooManyFiles = "TooManyFiles"
+	// ReasonSecretTooLarge is used when the synced data would exceed the size limit of a Secret.
+	ReasonSecretTooLarge = "SecretTooLarge"
+	// ReasonDisallowedMediaType is used when the artifact contains a media type that is not allowed.
+	ReasonDisallowedMediaType = "DisallowedMediaType"
+	// ReasonUnsupportedManifest is used when the reference points to a container image or an image index.
+	ReasonUnsupportedManifest = "UnsupportedManifest"
+	// ReasonLayerNotFound is used when the artifact has no layer with one of the selected titles.
+	ReasonLayerNotFound = "LayerNotFound"
+	// ReasonNoMatchingMediaType is used when the artifact has no layer with one of the selected media types.
+	ReasonNoMatchingMediaType = "NoMatchingMediaType"
+	// ReasonPlatformNotFound is used when the image index of the artifact has no manifest for the platform.
+	ReasonPlatformNotFound = "PlatformNotFound"
+	// ReasonConflictingFiles is used when different files of the artifact would be stored under the same key, e.g.
+	// files with the same name in the manifests of an image index.
+	ReasonConflictingFiles = "ConflictingFiles"
+	// ReasonUnsafeArchive is used when an archive of the artifact contains an entry with an unsafe path.
+	ReasonUnsafeArchive = "UnsafeArchive"
+	// ReasonInvalidTransform is used when a file can't be transformed, e.g. because it isn't valid base64.
+	ReasonInvalidTransform = "InvalidTransform"
+	// ReasonInvalidKeyTemplate is used when the key template can't be rendered into valid, distinct keys.
+	ReasonInvalidKeyTemplate = "InvalidKeyTemplate"
+	// ReasonInvalidKeyName is used when files of the artifact have keys that aren't valid Secret keys and
+	// can't be sanitized according to the Sync.KeyNamePolicy.
+	ReasonInvalidKeyName = "InvalidKeyName"
+	// ReasonBelowGate is used when the artifact doesn't meet MinVersion or NotBefore and isn't synced.
+	ReasonBelowGate = "BelowGate"
+	// ReasonInvalidGate is used when MinVersion isn't a semantic version.
+	ReasonInvalidGate = "InvalidGate"
+	// ReasonKeysSanitized is used when keys of files were sanitized according to the Sync.KeyNamePolicy.
+	ReasonKeysSanitized = "KeysSanitized"
+	// ReasonInvalidClientCertificate is used when the client certificate for mutual TLS can't be loaded.
+	ReasonInvalidClientCertificate = "InvalidClientCertificate"
+	// ReasonCrossNamespaceReference is used when a namespaced OCISecret references a Secret in another namespace.
+	ReasonCrossNamespaceReference = "CrossNamespaceReference"
+	// ReasonMissingNamespace is used when a Secret reference has no namespace and none can be defaulted.
+	ReasonMissingNamespace = "MissingNamespace"
+	// ReasonTargetConflict is used when a target Secret is a credential Secret of the OCISecret, exists
+	// without being managed by it or is also written by another OCISecret.
+	ReasonTargetConflict = "TargetConflict"
+	// ReasonRegistryNotAllowed is used when the registry of the artifact isn't on the operator's allowlist.
+	ReasonRegistryNotAllowed = "RegistryNotAllowed"
+	// ReasonInvalidReference is used when the registry or the tag of the artifact can't be parsed.
+	ReasonInvalidReference = "InvalidReference"
+	// ReasonInvalidPullSecret is used when the pull secret has no Docker config JSON, the basic-auth
+	// Secret has no username or more than one source of credentials is configured.
+	ReasonInvalidPullSecret = "InvalidPullSecret"
+	// ReasonUnsupportedPullSecretType is used when the pull secret isn't a kubernetes.io/dockerconfigjson,
+	// kubernetes.io/dockercfg or Opaque Secret, e.g. a basic-auth or TLS Secret referenced by mistake.
+	ReasonUnsupportedPullSecretType = "UnsupportedPullSecretType"
+	// ReasonSuspended is used when the OCISecret is suspended and the target Secret is not synced.
+	ReasonSuspended = "Suspended"
+	// ReasonDryRun is used when the OCISecret is in dry-run mode and the target Secret is not synced.
+	ReasonDryRun = "DryRun"
+	// ReasonAuditOnly is used when the operator runs with --audit-only and the target Secret is not synced.
+	ReasonAuditOnly = "AuditOnly"
+	// ReasonDrifted is used when the target Secrets differ from the artifact in audit-only mode.
+	ReasonDrifted = "Drifted"
+	// ReasonInSync is used when the target Secrets match the artifact in audit-only mode.
+	ReasonInSync = "InSync"
+	// ReasonTimedOut is used when a reconcile was abandoned after the --max-reconcile-duration of the operator.
+	ReasonTimedOut = "TimedOut"
+	// ReasonAuthenticationFailed is used when the registry rejects the credentials.
+	ReasonAuthenticationFailed = "AuthenticationFailed"
+	// ReasonArtifactNotFound is used when the repository or the tag of the artifact doesn't exist.
+	ReasonArtifactNotFound = "ArtifactNotFound"
+	// ReasonAnonymousFallback is used when the credentials were rejected and the registry is retried anonymously.
+	ReasonAnonymousFallback = "AnonymousFallback"
+	// ReasonNoFilesMatched is used when the file selection of the OCISecret matches no file of the artifact.
+	ReasonNoFilesMatched = "NoFilesMatched"
+	// ReasonFilesFiltered is used when Sync.Files or Sync.ExcludeFiles removed files of the artifact.
+	ReasonFilesFiltered = "FilesFiltered"
+	// ReasonEmptyArtifact is used when the artifact contains no files.
+	ReasonEmptyArtifact = "EmptyArtifact"
+	// ReasonFilesMissing is used when entries of Sync.Files match no file of the artifact.
+	ReasonFilesMissing = "FilesMissing"
+	// ReasonKeyCountExceeded is used when the target Secret has more keys than the advisory threshold.
+	ReasonKeyCountExceeded = "KeyCountExceeded"
+	// ReasonKeyCountWithinThreshold is used when the target Secret's key count is within the advisory threshold.
+	ReasonKeyCountWithinThreshold = "KeyCountWithinThreshold"
+)
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
-// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:resource:scope=Namespaced
+// +kubebuilder:printcolumn:name="Artifact",type="string",JSONPath=".spec.artifactRef"
+// +kubebuilder:printcolumn:name="Registry",type="string",priority=1,JSONPath=".spec.ArtefactRegistry"
+// +kubebuilder:printcolumn:name="Tag",type="string",priority=1,JSONPath=".spec.orasArtefact"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].reason"
+// +kubebuilder:printcolumn:name="Digest",type="string",priority=1,JSONPath=".status.syncHistory[0].digest"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // OCISecret is the Schema for the ocisecrets API
 type OCISecret struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
+	// +kubebuilder:pruning:PreserveUnknownFields
 	Spec   OCISecretSpec   `json:"spec,omitempty"`
 	Status OCISecretStatus `json:"status,omitempty"`
 }
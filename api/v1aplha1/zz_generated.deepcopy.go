@@ -21,16 +21,53 @@ limitations under the License.
 package v1aplha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Decompress) DeepCopyInto(out *Decompress) {
+	*out = *in
+	if in.Files != nil {
+		in, out := &in.Files, &out.Files
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Decompress.
+func (in *Decompress) DeepCopy() *Decompress {
+	if in == nil {
+		return nil
+	}
+	out := new(Decompress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyLayout) DeepCopyInto(out *KeyLayout) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyLayout.
+func (in *KeyLayout) DeepCopy() *KeyLayout {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyLayout)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OCISecret) DeepCopyInto(out *OCISecret) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCISecret.
@@ -88,7 +125,90 @@ func (in *OCISecretSpec) DeepCopyInto(out *OCISecretSpec) {
 	*out = *in
 	in.Sync.DeepCopyInto(&out.Sync)
 	out.ArtefactPullSecret = in.ArtefactPullSecret
+	if in.BasicAuthSecretRef != nil {
+		in, out := &in.BasicAuthSecretRef, &out.BasicAuthSecretRef
+		*out = new(corev1.SecretReference)
+		**out = **in
+	}
+	if in.AuthScopes != nil {
+		in, out := &in.AuthScopes, &out.AuthScopes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Mirrors != nil {
+		in, out := &in.Mirrors, &out.Mirrors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	out.TargetSecret = in.TargetSecret
+	if in.IncludeReferrers != nil {
+		in, out := &in.IncludeReferrers, &out.IncludeReferrers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(Verification)
+		**out = **in
+	}
+	if in.NotBefore != nil {
+		in, out := &in.NotBefore, &out.NotBefore
+		*out = (*in).DeepCopy()
+	}
+	if in.MaxArtifactSize != nil {
+		in, out := &in.MaxArtifactSize, &out.MaxArtifactSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.ClientCertSecretRef != nil {
+		in, out := &in.ClientCertSecretRef, &out.ClientCertSecretRef
+		*out = new(corev1.SecretReference)
+		**out = **in
+	}
+	if in.SetOwnerReference != nil {
+		in, out := &in.SetOwnerReference, &out.SetOwnerReference
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ForceResyncInterval != nil {
+		in, out := &in.ForceResyncInterval, &out.ForceResyncInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.KeyLayout != nil {
+		in, out := &in.KeyLayout, &out.KeyLayout
+		*out = new(KeyLayout)
+		**out = **in
+	}
+	if in.Decompress != nil {
+		in, out := &in.Decompress, &out.Decompress
+		*out = new(Decompress)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Transform != nil {
+		in, out := &in.Transform, &out.Transform
+		*out = make(map[string]FileTransform, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.AdditionalTargets != nil {
+		in, out := &in.AdditionalTargets, &out.AdditionalTargets
+		*out = make([]SecretTarget, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PropagateAnnotations != nil {
+		in, out := &in.PropagateAnnotations, &out.PropagateAnnotations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TargetSecretMetadata != nil {
+		in, out := &in.TargetSecretMetadata, &out.TargetSecretMetadata
+		*out = new(SecretMetadata)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCISecretSpec.
@@ -104,6 +224,45 @@ func (in *OCISecretSpec) DeepCopy() *OCISecretSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OCISecretStatus) DeepCopyInto(out *OCISecretStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FileDigests != nil {
+		in, out := &in.FileDigests, &out.FileDigests
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Plan != nil {
+		in, out := &in.Plan, &out.Plan
+		*out = new(SyncPlan)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SyncHistory != nil {
+		in, out := &in.SyncHistory, &out.SyncHistory
+		*out = make([]SyncHistoryEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PendingDigestTime != nil {
+		in, out := &in.PendingDigestTime, &out.PendingDigestTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSyncLatency != nil {
+		in, out := &in.LastSyncLatency, &out.LastSyncLatency
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.LastWriteTime != nil {
+		in, out := &in.LastWriteTime, &out.LastWriteTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCISecretStatus.
@@ -116,6 +275,62 @@ func (in *OCISecretStatus) DeepCopy() *OCISecretStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretMetadata) DeepCopyInto(out *SecretMetadata) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretMetadata.
+func (in *SecretMetadata) DeepCopy() *SecretMetadata {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretMetadata)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretTarget) DeepCopyInto(out *SecretTarget) {
+	*out = *in
+	if in.Files != nil {
+		in, out := &in.Files, &out.Files
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Rename != nil {
+		in, out := &in.Rename, &out.Rename
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretTarget.
+func (in *SecretTarget) DeepCopy() *SecretTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Sync) DeepCopyInto(out *Sync) {
 	*out = *in
@@ -124,6 +339,28 @@ func (in *Sync) DeepCopyInto(out *Sync) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ExcludeFiles != nil {
+		in, out := &in.ExcludeFiles, &out.ExcludeFiles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LayerTitles != nil {
+		in, out := &in.LayerTitles, &out.LayerTitles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MediaTypes != nil {
+		in, out := &in.MediaTypes, &out.MediaTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Transforms != nil {
+		in, out := &in.Transforms, &out.Transforms
+		*out = make([]TransformStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Sync.
@@ -135,3 +372,84 @@ func (in *Sync) DeepCopy() *Sync {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncHistoryEntry) DeepCopyInto(out *SyncHistoryEntry) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncHistoryEntry.
+func (in *SyncHistoryEntry) DeepCopy() *SyncHistoryEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncHistoryEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncPlan) DeepCopyInto(out *SyncPlan) {
+	*out = *in
+	if in.AddedKeys != nil {
+		in, out := &in.AddedKeys, &out.AddedKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ChangedKeys != nil {
+		in, out := &in.ChangedKeys, &out.ChangedKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RemovedKeys != nil {
+		in, out := &in.RemovedKeys, &out.RemovedKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncPlan.
+func (in *SyncPlan) DeepCopy() *SyncPlan {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncPlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransformStep) DeepCopyInto(out *TransformStep) {
+	*out = *in
+	if in.Files != nil {
+		in, out := &in.Files, &out.Files
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransformStep.
+func (in *TransformStep) DeepCopy() *TransformStep {
+	if in == nil {
+		return nil
+	}
+	out := new(TransformStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Verification) DeepCopyInto(out *Verification) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Verification.
+func (in *Verification) DeepCopy() *Verification {
+	if in == nil {
+		return nil
+	}
+	out := new(Verification)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -17,26 +17,37 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"flag"
+	"fmt"
+	"hash/fnv"
 	"os"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
 	"github.com/mariusbertram/oci-resource-sync-operator/internal/controller"
+	"github.com/mariusbertram/oci-resource-sync-operator/internal/orasclient"
+	webhookv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/internal/webhook/v1aplha1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -53,11 +64,49 @@ func init() {
 }
 
 func main() {
+	// "manager pull ..." test-pulls an artifact for troubleshooting instead of starting the manager
+	if len(os.Args) > 1 && os.Args[1] == "pull" {
+		if err := runPull(ctrl.SetupSignalHandler(), os.Args[2:], os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var emitSyncRecords bool
+	var keyCountThreshold int
+	var retryPolicy orasclient.RetryPolicy
+	var failureBackoffBase time.Duration
+	var failureBackoffMax time.Duration
+	var allowedMediaTypes string
+	var defaultNamespace string
+	var watchNamespace string
+	var labelSelector string
+	var userAgent string
+	var authScopes string
+	var maxConcurrentReconciles int
+	var maxConcurrentPulls int
+	var digestCacheTTL time.Duration
+	var contentCacheTTL time.Duration
+	var contentCacheMaxEntries int
+	var contentCacheMaxSize int64
+	var memoryThreshold int64
+	var syncHealthWindow time.Duration
+	var gracefulShutdownTimeout time.Duration
+	var syncHealthMaxFailureRatio float64
+	var allowedRegistries string
+	var registryRateLimit float64
+	var registryRateBurst int
+	var circuitBreakerThreshold int
+	var circuitBreakerCooldown time.Duration
+	var auditOnly bool
+	var maxReconcileDuration time.Duration
+	var enableWebhooks bool
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -69,6 +118,92 @@ func main() {
 		"If set, the metrics endpoint is served securely via HTTPS. Use --metrics-secure=false to use HTTP instead.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.BoolVar(&emitSyncRecords, "emit-sync-records", false,
+		"If set, a single JSON line describing the outcome of every reconcile is written to stdout "+
+			"for log-based pipelines.")
+	flag.IntVar(&keyCountThreshold, "key-count-advisory-threshold", 100,
+		"Number of keys in a target Secret above which a TooManyKeys advisory condition and warning event are "+
+			"raised. The sync is not blocked. Use 0 to disable the advisory.")
+	flag.IntVar(&retryPolicy.MaxRetries, "registry-max-retries", 5,
+		"Number of times a failed registry request (5xx, 408, dial timeout) is retried within a reconcile. "+
+			"Throttled requests (429) are requeued after their Retry-After instead.")
+	flag.DurationVar(&retryPolicy.BaseDelay, "registry-retry-base-delay", 250*time.Millisecond,
+		"Delay before the first retry of a failed registry request. It doubles with every further retry.")
+	flag.DurationVar(&retryPolicy.MaxDelay, "registry-retry-max-delay", 3*time.Second,
+		"Maximum delay between two retries of a failed registry request.")
+	flag.DurationVar(&failureBackoffBase, "failure-backoff-base", 5*time.Second,
+		"Requeue delay after the first failed reconcile of an OCISecret. It doubles with every further failure "+
+			"and is jittered to spread the retries. A successful sync resets it.")
+	flag.DurationVar(&failureBackoffMax, "failure-backoff-max", 10*time.Minute,
+		"Maximum requeue delay of an OCISecret whose reconciles keep failing.")
+	flag.StringVar(&allowedMediaTypes, "allowed-media-types", strings.Join(orasclient.DefaultAllowedMediaTypes, ","),
+		"Comma-separated list of layer media types (path.Match patterns such as text/*) an artifact may contain. "+
+			"Artifacts with other layer media types are refused. Use */* to allow every media type.")
+	flag.StringVar(&defaultNamespace, "default-namespace", "",
+		"Namespace used for Secret references of cluster-scoped OCISecrets that don't set one; credential Secrets "+
+			"are looked up in the namespace of the target Secret instead. "+
+			"If empty, such references are reported as an error.")
+	flag.StringVar(&authScopes, "registry-auth-scopes", "",
+		"Comma-separated scopes (e.g. repository:org/base:pull) requested with the bearer tokens of all "+
+			"registries in addition to the pull scope of the repository. OCISecrets can add scopes with spec.authScopes.")
+	flag.StringVar(&watchNamespace, "watch-namespace", "",
+		"Only watch and cache OCISecrets and Secrets in this namespace, e.g. to run one operator per tenant "+
+			"namespace with a Role instead of a ClusterRole. If empty, all namespaces are watched.")
+	flag.StringVar(&labelSelector, "label-selector", "",
+		"Only watch and reconcile the OCISecrets matching this label selector, e.g. shard=a or shard in (a,b), to "+
+			"shard the OCISecrets across several operator instances. If empty, all OCISecrets are reconciled.")
+	flag.StringVar(&userAgent, "registry-user-agent", orasclient.DefaultUserAgent(),
+		"User-Agent sent to the registries. OCISecrets can override it with spec.userAgent.")
+	flag.BoolVar(&auditOnly, "audit-only", false,
+		"Only compare the target Secrets with their artefacts and report the drift in the Drift condition and the "+
+			"ocisecret_drifted_keys metric, without ever writing a Secret, e.g. before the operator takes over "+
+			"Secrets that are still managed by hand.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 4,
+		"The number of OCISecrets reconciled in parallel.")
+	flag.DurationVar(&maxReconcileDuration, "max-reconcile-duration", 10*time.Minute,
+		"The maximum duration of a reconcile, including the download and the Secret writes. Longer reconciles are "+
+			"abandoned, reported with the TimedOut reason and requeued, so a huge artefact doesn't block a worker. "+
+			"0 disables the limit.")
+	flag.IntVar(&maxConcurrentPulls, "max-concurrent-pulls", 4,
+		"The maximum number of concurrent registry requests (digest lookups and downloads). 0 disables the limit.")
+	flag.Float64Var(&registryRateLimit, "registry-rate-limit", 0,
+		"The average number of registry operations per second allowed per registry host, e.g. to stay within "+
+			"the pull quota of Docker Hub. Further operations are postponed. 0 disables the limit.")
+	flag.IntVar(&registryRateBurst, "registry-rate-burst", 10,
+		"The number of registry operations per registry host allowed in a burst above --registry-rate-limit.")
+	flag.IntVar(&circuitBreakerThreshold, "registry-circuit-breaker-threshold", 5,
+		"The number of consecutive transient failures (timeouts, refused connections, 5xx responses) after which "+
+			"a registry host isn't accessed for --registry-circuit-breaker-cooldown. 0 disables the circuit breaker.")
+	flag.DurationVar(&circuitBreakerCooldown, "registry-circuit-breaker-cooldown", 5*time.Minute,
+		"How long a registry host isn't accessed once its circuit breaker opened, before a probe request is let through.")
+	flag.DurationVar(&gracefulShutdownTimeout, "graceful-shutdown-timeout", 30*time.Second,
+		"How long the manager waits on shutdown for running reconciles to finish. Their registry requests are "+
+			"cancelled, so a partially downloaded artefact is dropped without updating any Secret.")
+	flag.DurationVar(&digestCacheTTL, "digest-cache-ttl", 30*time.Second,
+		"How long a resolved artefact digest is reused for OCISecrets with the same registry, tag and "+
+			"credentials. 0 disables the cache.")
+	flag.DurationVar(&contentCacheTTL, "content-cache-ttl", 5*time.Minute,
+		"How long the files of a downloaded artefact are reused for OCISecrets with the same registry, digest, "+
+			"credentials and download options, e.g. when many OCISecrets sync the same artefact into different "+
+			"namespaces. 0 disables the cache.")
+	flag.IntVar(&contentCacheMaxEntries, "content-cache-max-entries", 50,
+		"The maximum number of artefacts kept in the content cache.")
+	flag.Int64Var(&contentCacheMaxSize, "content-cache-max-size", 64<<20,
+		"The maximum total size in bytes of the files kept in the content cache.")
+	flag.Int64Var(&memoryThreshold, "memory-threshold", orasclient.DefaultMemoryThreshold,
+		"Announced size in bytes up to which the layers of an artefact are fetched straight into memory instead of "+
+			"a temporary directory. Use 0 to always download to a temporary directory.")
+	flag.StringVar(&allowedRegistries, "allowed-registries", "",
+		"Comma-separated patterns of the registries OCISecrets may pull from, e.g. ghcr.io/my-org or "+
+			"*.dkr.ecr.*.amazonaws.com. A pattern also allows all repositories below it. If empty, all registries are allowed.")
+	flag.DurationVar(&syncHealthWindow, "sync-health-window", 15*time.Minute,
+		"The readiness check fails if no OCISecret synced successfully for this long. 0 disables the check.")
+	flag.Float64Var(&syncHealthMaxFailureRatio, "sync-health-max-failure-ratio", 0.5,
+		"The readiness check fails if more than this fraction of the OCISecrets fail to sync. 0 disables the check.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", true,
+		"Serve the defaulting webhook of the OCISecrets; it needs the serving certificate and the "+
+			"MutatingWebhookConfiguration of config/webhook. Disable it to run the operator without a certificate, "+
+			"e.g. outside of the cluster.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -120,13 +255,36 @@ func main() {
 		metricsServerOptions.FilterProvider = filters.WithAuthenticationAndAuthorization
 	}
 
+	// A single watched namespace limits the informers, and therefore the memory and the RBAC the
+	// manager needs, to that namespace
+	var cacheOptions cache.Options
+	if watchNamespace != "" {
+		cacheOptions.DefaultNamespaces = map[string]cache.Config{watchNamespace: {}}
+		setupLog.Info("watching a single namespace", "namespace", watchNamespace)
+	}
+	// The label selector limits the OCISecrets in the cache, so the instance doesn't see, reconcile or report
+	// metrics for the OCISecrets of the other shards
+	leaderElectionID := "a1ea7db8.brtrm.de"
+	if labelSelector != "" {
+		selector, err := labels.Parse(labelSelector)
+		if err != nil {
+			setupLog.Error(err, "invalid label selector", "labelSelector", labelSelector)
+			os.Exit(1)
+		}
+		cacheOptions.ByObject = map[client.Object]cache.ByObject{&ocisyncv1aplha1.OCISecret{}: {Label: selector}}
+		leaderElectionID = shardLeaderElectionID(leaderElectionID, selector)
+		setupLog.Info("reconciling a shard of the OCISecrets", "labelSelector", selector.String())
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme:                 scheme,
-		Metrics:                metricsServerOptions,
-		WebhookServer:          webhookServer,
-		HealthProbeBindAddress: probeAddr,
-		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "a1ea7db8.brtrm.de",
+		Scheme:                  scheme,
+		Cache:                   cacheOptions,
+		Metrics:                 metricsServerOptions,
+		WebhookServer:           webhookServer,
+		HealthProbeBindAddress:  probeAddr,
+		LeaderElection:          enableLeaderElection,
+		LeaderElectionID:        leaderElectionID,
+		GracefulShutdownTimeout: &gracefulShutdownTimeout,
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -144,13 +302,57 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err = (&controller.OCISecretReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
+	// Cached digests and files are returned without using the rate limit or waiting for a free pull slot
+	metrics.Registry.MustRegister(orasclient.CircuitBreakerState, orasclient.PulledBytes)
+	artifactClient := orasclient.NewContentCache(
+		orasclient.NewDigestCache(
+			orasclient.NewRateLimitedClient(
+				orasclient.NewCircuitBreakerClient(
+					orasclient.NewLimitedClient(orasclient.OrasClient{}, maxConcurrentPulls),
+					circuitBreakerThreshold, circuitBreakerCooldown),
+				registryRateLimit, registryRateBurst),
+			digestCacheTTL),
+		contentCacheTTL, contentCacheMaxEntries, contentCacheMaxSize)
+	reconciler := &controller.OCISecretReconciler{
+		Client:             mgr.GetClient(),
+		Scheme:             mgr.GetScheme(),
+		ArtifactClient:     artifactClient,
+		Recorder:           mgr.GetEventRecorderFor("ocisecret-controller"),
+		KeyCountThreshold:  keyCountThreshold,
+		RetryPolicy:        retryPolicy,
+		FailureBackoffBase: failureBackoffBase,
+		FailureBackoffMax:  failureBackoffMax,
+		AllowedMediaTypes:  strings.Split(allowedMediaTypes, ","),
+		DefaultNamespace:   defaultNamespace,
+		UserAgent:          userAgent,
+		AuditOnly:          auditOnly,
+		MemoryThreshold:    memoryThreshold,
+		// Covers the whole reconcile, the registry requests are additionally bounded by the retry policy
+		MaxReconcileDuration: maxReconcileDuration,
+		// With leader election only the leader reconciles, so the limits apply to the whole deployment
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+		Health:                  controller.NewSyncHealth(syncHealthWindow, syncHealthMaxFailureRatio),
+	}
+	if allowedRegistries != "" {
+		reconciler.AllowedRegistries = strings.Split(allowedRegistries, ",")
+	}
+	if authScopes != "" {
+		reconciler.AuthScopes = strings.Split(authScopes, ",")
+	}
+	if emitSyncRecords {
+		reconciler.RecordWriter = os.Stdout
+	}
+	if err = reconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "OCISecret")
 		os.Exit(1)
 	}
+	// The webhook server only starts listening once a webhook is registered
+	if enableWebhooks {
+		if err = webhookv1aplha1.SetupOCISecretWebhookWithManager(mgr, defaultNamespace); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "OCISecret")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -161,10 +363,35 @@ func main() {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
+	// Report failing syncs through the readiness probe; a liveness failure would only restart the
+	// operator, which doesn't help against e.g. an unreachable registry
+	if err := mgr.AddReadyzCheck("sync", reconciler.Health.Check); err != nil {
+		setupLog.Error(err, "unable to set up sync health check")
+		os.Exit(1)
+	}
+
+	shutdownTracing, err := setupTracing(context.Background())
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
 
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	err = mgr.Start(ctrl.SetupSignalHandler())
+	// Export the spans of the last reconciles before exiting
+	if shutdownErr := shutdownTracing(context.Background()); shutdownErr != nil {
+		setupLog.Error(shutdownErr, "unable to flush traces")
+	}
+	if err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
 }
+
+// shardLeaderElectionID returns the leader election ID of the operator instances reconciling the OCISecrets
+// matching the selector, so every shard elects its own leader instead of only one shard running.
+func shardLeaderElectionID(id string, selector labels.Selector) string {
+	hash := fnv.New32a()
+	hash.Write([]byte(selector.String()))
+	return fmt.Sprintf("%08x.%s", hash.Sum32(), id)
+}
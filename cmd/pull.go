@@ -0,0 +1,204 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mariusbertram/oci-resource-sync-operator/internal/orasclient"
+	"github.com/mariusbertram/oci-resource-sync-operator/internal/utils"
+)
+
+// pulledFile describes a file of a test-pulled artifact.
+type pulledFile struct {
+	Name string `json:"name"`
+	Size int    `json:"size"`
+}
+
+// pullResult is the output of the pull subcommand.
+type pullResult struct {
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Files       []pulledFile      `json:"files"`
+	// Filtered are the files removed by --files and --exclude-files
+	Filtered []string `json:"filtered,omitempty"`
+}
+
+// runPull downloads an artifact like a reconcile does, without a cluster or an OCISecret, and writes
+// the digest and the files that would be synced to out. It's meant to troubleshoot credentials and
+// file filters. The password of --username is read from in with --password-stdin.
+func runPull(ctx context.Context, args []string, in io.Reader, out io.Writer) error {
+	flags := flag.NewFlagSet("pull", flag.ContinueOnError)
+	registry := flags.String("registry", "", "The repository of the artifact, e.g. ghcr.io/my-org/configs.")
+	tag := flags.String("tag", "", "The tag or digest of the artifact.")
+	credsFile := flags.String("creds", "", "Path to a Docker config JSON file with the registry credentials. "+
+		"If empty, the artifact is pulled anonymously.")
+	username := flags.String("username", "", "Username to authenticate with instead of --creds.")
+	passwordStdin := flags.Bool("password-stdin", false,
+		"Read the password of --username from stdin, so it doesn't show up in the process list or the shell history.")
+	scopes := flags.String("scopes", "",
+		"Comma-separated scopes requested with the bearer tokens in addition to the pull scope, like spec.authScopes.")
+	credentialProvider := flags.String("credential-provider", "",
+		"Obtain the credentials from a cloud provider (aws, gcp, azure or auto) instead of --creds.")
+	clientCert := flags.String("client-cert", "", "Path to a PEM client certificate for registries that require mutual TLS.")
+	clientKey := flags.String("client-key", "", "Path to the PEM private key of --client-cert.")
+	caCert := flags.String("ca-cert", "", "Path to PEM CA certificates the registry certificate is verified with.")
+	files := flags.String("files", "", "Comma-separated patterns of the files to keep, like spec.Sync.Files.")
+	excludeFiles := flags.String("exclude-files", "",
+		"Comma-separated patterns of the files to drop, like spec.Sync.ExcludeFiles.")
+	layerTitles := flags.String("layer-titles", "", "Comma-separated titles of the layers to download.")
+	mediaTypes := flags.String("media-types", "", "Comma-separated media types (patterns) of the layers to download.")
+	allowedMediaTypes := flags.String("allowed-media-types", strings.Join(orasclient.DefaultAllowedMediaTypes, ","),
+		"Comma-separated list of layer media types the artifact may contain.")
+	extractArchives := flags.Bool("extract-archives", false, "Replace tar archives by the files they contain.")
+	decompress := flags.Bool("decompress", false, "Decompress gzip and zstd compressed files.")
+	pathSeparator := flags.String("path-separator", "",
+		"Read files in subdirectories, replacing the path separator in their keys with this string.")
+	stripPrefix := flags.String("strip-prefix", "",
+		"A directory of the artifact that is removed from the paths of the files below it, like spec.Sync.stripPrefix.")
+	platform := flags.String("platform", "",
+		"The platform (os/architecture[/variant]) whose manifest is downloaded if the artifact is an image index.")
+	maxFileCount := flags.Int("max-file-count", 0, "The maximum number of files of the artifact, like spec.maxFileCount. "+
+		"0 means unlimited.")
+	includeConfig := flags.Bool("include-config", false,
+		"Add the config blob of the artifact under the key "+orasclient.ConfigKey+", like spec.includeConfig.")
+	output := flags.String("output", "text", "The output format, text or json.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *registry == "" || *tag == "" {
+		return fmt.Errorf("--registry and --tag are required")
+	}
+	if *output != "text" && *output != "json" {
+		return fmt.Errorf("unknown output format %q, use text or json", *output)
+	}
+	if *passwordStdin && *username == "" {
+		return fmt.Errorf("--password-stdin requires --username")
+	}
+
+	opts := orasclient.ClientOptions{
+		Username:  *username,
+		UserAgent: orasclient.DefaultUserAgent(),
+		Scopes:    splitList(*scopes),
+	}
+	if *passwordStdin {
+		password, err := io.ReadAll(in)
+		if err != nil {
+			return fmt.Errorf("failed to read the password: %w", err)
+		}
+		opts.Password = strings.TrimRight(string(password), "\r\n")
+	}
+	if *credsFile != "" {
+		credentials, err := os.ReadFile(*credsFile)
+		if err != nil {
+			return fmt.Errorf("failed to read the credentials: %w", err)
+		}
+		opts.Credentials = credentials
+	}
+	if *clientCert != "" || *clientKey != "" {
+		certificate, err := tls.LoadX509KeyPair(*clientCert, *clientKey)
+		if err != nil {
+			return fmt.Errorf("failed to load the client certificate: %w", err)
+		}
+		opts.ClientCertificate = &certificate
+	}
+	if *caCert != "" {
+		caCertificates, err := os.ReadFile(*caCert)
+		if err != nil {
+			return fmt.Errorf("failed to read the CA certificates: %w", err)
+		}
+		opts.CACertificates = caCertificates
+	}
+	if *credentialProvider != "" {
+		provider, err := orasclient.NewCredentialProvider(*credentialProvider)
+		if err != nil {
+			return err
+		}
+		opts.CredentialProvider = provider
+	}
+
+	pullOptions := orasclient.PullOptions{
+		AllowedMediaTypes: splitList(*allowedMediaTypes),
+		PathSeparator:     *pathSeparator,
+		ExtractArchives:   *extractArchives,
+		Decompress:        *decompress,
+		LayerTitles:       splitList(*layerTitles),
+		MediaTypes:        splitList(*mediaTypes),
+		Platform:          *platform,
+		StripPrefix:       *stripPrefix,
+		MaxFileCount:      *maxFileCount,
+		IncludeConfig:     *includeConfig,
+		MemoryThreshold:   orasclient.DefaultMemoryThreshold,
+	}
+	content, err := orasclient.GetFiles(ctx, *registry, *tag, opts, pullOptions)
+	if err != nil {
+		return err
+	}
+	// Filter like the reconcile, so the output shows the keys that would be written to the Secret
+	var filtered []string
+	if include := splitList(*files); len(include) > 0 {
+		filtered = utils.FilterMapInPlace(content.Files, include)
+	}
+	filtered = append(filtered, utils.FilterMapExcludeInPlace(content.Files, splitList(*excludeFiles))...)
+
+	sort.Strings(filtered)
+	result := pullResult{Digest: content.Digest.String(), Annotations: content.Annotations, Files: []pulledFile{},
+		Filtered: filtered}
+	for name, data := range content.Files {
+		result.Files = append(result.Files, pulledFile{Name: name, Size: len(data)})
+	}
+	sort.Slice(result.Files, func(i, j int) bool { return result.Files[i].Name < result.Files[j].Name })
+
+	if *output == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+	fmt.Fprintf(out, "Digest: %s\n", result.Digest)
+	annotationKeys := make([]string, 0, len(result.Annotations))
+	for key := range result.Annotations {
+		annotationKeys = append(annotationKeys, key)
+	}
+	sort.Strings(annotationKeys)
+	for _, key := range annotationKeys {
+		fmt.Fprintf(out, "Annotation: %s=%s\n", key, result.Annotations[key])
+	}
+	for _, file := range result.Files {
+		fmt.Fprintf(out, "%10d  %s\n", file.Size, file.Name)
+	}
+	if len(result.Filtered) > 0 {
+		fmt.Fprintf(out, "Kept %d of %d files, filtered: %s\n", len(result.Files), len(result.Files)+len(result.Filtered),
+			strings.Join(result.Filtered, ", "))
+	}
+	return nil
+}
+
+// splitList splits a comma-separated flag value; an empty value results in no elements.
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// testCreated is the created annotation of the artifacts served by newTestRegistry.
+const testCreated = "2025-01-01T00:00:00Z"
+
+// newTestRegistry serves an artifact with the files as titled layers as configs:v1 with the pull endpoints
+// of the distribution spec over TLS. It requires the basic auth credentials user and secret. It returns the
+// repository, the path of a file with the CA certificate of the registry and the digest of the artifact.
+func newTestRegistry(t *testing.T, files map[string]string) (string, string, digest.Digest) {
+	t.Helper()
+	blobs := map[digest.Digest][]byte{ocispec.DescriptorEmptyJSON.Digest: ocispec.DescriptorEmptyJSON.Data}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var layers []ocispec.Descriptor
+	for _, name := range names {
+		layerDigest := digest.FromString(files[name])
+		blobs[layerDigest] = []byte(files[name])
+		layers = append(layers, ocispec.Descriptor{MediaType: "application/yaml", Digest: layerDigest,
+			Size: int64(len(files[name])), Annotations: map[string]string{ocispec.AnnotationTitle: name}})
+	}
+	manifest, err := json.Marshal(ocispec.Manifest{
+		Versioned:    specs.Versioned{SchemaVersion: 2},
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: "application/vnd.example",
+		Config:       ocispec.DescriptorEmptyJSON,
+		Layers:       layers,
+		Annotations:  map[string]string{ocispec.AnnotationCreated: testCreated},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifestDigest := digest.FromBytes(manifest)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if username, password, ok := req.BasicAuth(); !ok || username != "user" || password != "secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var data []byte
+		switch path := strings.TrimPrefix(req.URL.Path, "/v2/configs/"); {
+		case req.URL.Path == "/v2/":
+			w.WriteHeader(http.StatusOK)
+			return
+		case path == "manifests/v1" || path == "manifests/"+manifestDigest.String():
+			data = manifest
+			w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+			w.Header().Set("Docker-Content-Digest", manifestDigest.String())
+		case strings.HasPrefix(path, "blobs/") && blobs[digest.Digest(strings.TrimPrefix(path, "blobs/"))] != nil:
+			data = blobs[digest.Digest(strings.TrimPrefix(path, "blobs/"))]
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Docker-Content-Digest", strings.TrimPrefix(path, "blobs/"))
+		default:
+			http.NotFound(w, req)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		if req.Method == http.MethodGet {
+			w.Write(data)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	caCert := filepath.Join(t.TempDir(), "ca.crt")
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caCert, certificate, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimPrefix(server.URL, "https://") + "/configs", caCert, manifestDigest
+}
+
+func TestRunPullValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "missing registry", args: []string{"--tag", "v1"}, wantErr: "--registry and --tag are required"},
+		{name: "missing tag", args: []string{"--registry", "ghcr.io/my-org/configs"},
+			wantErr: "--registry and --tag are required"},
+		{name: "unknown output", args: []string{"--registry", "ghcr.io/my-org/configs", "--tag", "v1", "--output", "yaml"},
+			wantErr: `unknown output format "yaml"`},
+		{name: "password without username", args: []string{"--registry", "ghcr.io/my-org/configs", "--tag", "v1",
+			"--password-stdin"}, wantErr: "--password-stdin requires --username"},
+		{name: "password flag", args: []string{"--registry", "ghcr.io/my-org/configs", "--tag", "v1", "--password", "secret"},
+			wantErr: "flag provided but not defined: -password"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := runPull(context.Background(), tt.args, strings.NewReader(""), &out)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+			if out.Len() > 0 {
+				t.Errorf("expected no output, got %q", out.String())
+			}
+		})
+	}
+}
+
+func TestRunPullOutput(t *testing.T) {
+	repository, caCert, manifestDigest := newTestRegistry(t, map[string]string{"app.yaml": "app", "db.yaml": "db"})
+	args := []string{"--registry", repository, "--tag", "v1", "--ca-cert", caCert, "--username", "user", "--password-stdin"}
+
+	tests := []struct {
+		name    string
+		args    []string
+		stdin   string
+		want    string
+		wantErr bool
+	}{
+		{name: "text", stdin: "secret\n", want: fmt.Sprintf("Digest: %s\n"+
+			"Annotation: %s=%s\n"+
+			"         3  app.yaml\n"+
+			"         2  db.yaml\n", manifestDigest, ocispec.AnnotationCreated, testCreated)},
+		{name: "filtered text", args: []string{"--files", "app.yaml"}, stdin: "secret", want: fmt.Sprintf("Digest: %s\n"+
+			"Annotation: %s=%s\n"+
+			"         3  app.yaml\n"+
+			"Kept 1 of 2 files, filtered: db.yaml\n", manifestDigest, ocispec.AnnotationCreated, testCreated)},
+		{name: "wrong password", stdin: "wrong\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := runPull(context.Background(), append(append([]string{}, args...), tt.args...),
+				strings.NewReader(tt.stdin), &out)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got the output %q", out.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("got the output\n%s\nwant\n%s", out.String(), tt.want)
+			}
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var out bytes.Buffer
+		err := runPull(context.Background(), append(append([]string{}, args...), "--output", "json", "--exclude-files", "db.yaml"),
+			strings.NewReader("secret\n"), &out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got pullResult
+		if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+			t.Fatalf("invalid JSON output %q: %v", out.String(), err)
+		}
+		want := pullResult{
+			Digest:      manifestDigest.String(),
+			Annotations: map[string]string{ocispec.AnnotationCreated: testCreated},
+			Files:       []pulledFile{{Name: "app.yaml", Size: 3}},
+			Filtered:    []string{"db.yaml"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+}
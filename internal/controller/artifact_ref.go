@@ -0,0 +1,39 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+	"github.com/mariusbertram/oci-resource-sync-operator/internal/orasclient"
+)
+
+// resolveArtifactRef splits the ArtifactRef of the OCISecret into the deprecated ArtefactRegistry and
+// OrasArtefact the rest of the reconcile works with, replacing their values. The OCISecret is only changed
+// in memory; without ArtifactRef the split fields are kept. It returns orasclient.ErrInvalidReference
+// (wrapped) if the ArtifactRef can't be parsed.
+func resolveArtifactRef(ocisecret *ocisyncv1aplha1.OCISecret) error {
+	if ocisecret.Spec.ArtifactRef == "" {
+		return nil
+	}
+	repository, tag, err := orasclient.SplitArtifactRef(ocisecret.Spec.ArtifactRef)
+	if err != nil {
+		return err
+	}
+	ocisecret.Spec.ArtefactRegistry = repository
+	ocisecret.Spec.OrasArtefact = tag
+	return nil
+}
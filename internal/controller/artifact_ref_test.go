@@ -0,0 +1,102 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/mariusbertram/oci-resource-sync-operator/internal/orasclient"
+	"github.com/mariusbertram/oci-resource-sync-operator/internal/orasclient/fake"
+)
+
+var _ = Describe("Artifact references", func() {
+	ctx := context.Background()
+
+	It("should prefer the ArtifactRef over the split registry and tag", func() {
+		ocisecret := newTestOCISecret("artifact-ref")
+		ocisecret.Spec.ArtifactRef = "ghcr.io/org/configs:v2"
+
+		Expect(resolveArtifactRef(ocisecret)).To(Succeed())
+		Expect(ocisecret.Spec.ArtefactRegistry).To(Equal("ghcr.io/org/configs"))
+		Expect(ocisecret.Spec.OrasArtefact).To(Equal("v2"))
+	})
+
+	It("should keep the split registry and tag without an ArtifactRef", func() {
+		ocisecret := newTestOCISecret("artifact-ref")
+
+		Expect(resolveArtifactRef(ocisecret)).To(Succeed())
+		Expect(ocisecret.Spec.ArtefactRegistry).To(Equal("registry.example.com/configs"))
+		Expect(ocisecret.Spec.OrasArtefact).To(Equal("v1"))
+	})
+
+	It("should reject an ArtifactRef without a tag", func() {
+		ocisecret := newTestOCISecret("artifact-ref")
+		ocisecret.Spec.ArtifactRef = "ghcr.io/org/configs"
+
+		Expect(resolveArtifactRef(ocisecret)).To(MatchError(orasclient.ErrInvalidReference))
+	})
+
+	It("should reject an OCISecret without any artifact reference", func() {
+		ocisecret := newTestOCISecret("artifact-ref-missing")
+		ocisecret.Spec.ArtefactRegistry = ""
+
+		Expect(k8sClient.Create(ctx, ocisecret)).To(MatchError(ContainSubstring("artifactRef or ArtefactRegistry")))
+	})
+
+	It("should sync the artifact of the ArtifactRef", func() {
+		ocisecret := newTestOCISecret("artifact-ref-sync")
+		ocisecret.Spec.ArtefactRegistry = ""
+		ocisecret.Spec.OrasArtefact = ""
+		ocisecret.Spec.ArtifactRef = "registry.example.com/configs:v1"
+		Expect(k8sClient.Create(ctx, ocisecret)).To(Succeed())
+		targetName := types.NamespacedName{Name: ocisecret.Name, Namespace: ocisecret.Namespace}
+		DeferCleanup(func() {
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, ocisecret))).To(Succeed())
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &v1core.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: targetName.Name, Namespace: targetName.Namespace}}))).To(Succeed())
+		})
+
+		artifacts := fake.NewClient()
+		artifacts.SetArtifact("registry.example.com/configs", "v1", map[string][]byte{"app.yaml": []byte("app")})
+		controllerReconciler := &OCISecretReconciler{
+			Client:         k8sClient,
+			Scheme:         k8sClient.Scheme(),
+			Recorder:       record.NewFakeRecorder(10),
+			ArtifactClient: artifacts,
+		}
+		_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: targetName})
+		Expect(err).NotTo(HaveOccurred())
+
+		secret := &v1core.Secret{}
+		Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+		Expect(secret.Data).To(Equal(map[string][]byte{"app.yaml": []byte("app")}))
+
+		// The split fields resolved from the ArtifactRef aren't written back
+		Expect(k8sClient.Get(ctx, targetName, ocisecret)).To(Succeed())
+		Expect(ocisecret.Spec.ArtefactRegistry).To(BeEmpty())
+		Expect(ocisecret.Spec.OrasArtefact).To(BeEmpty())
+	})
+})
@@ -0,0 +1,124 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1core "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+	"github.com/mariusbertram/oci-resource-sync-operator/internal/utils"
+)
+
+// driftedKeys is the number of keys of the target Secrets of an OCISecret that differ from the artifact,
+// as of the last reconcile in audit-only mode.
+var driftedKeys = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "ocisecret_drifted_keys",
+	Help: "Number of keys of the target Secrets that differ from the artifact, reported with --audit-only.",
+}, []string{"namespace", "name"})
+
+// auditTargets compares the target Secret and the additional target Secrets with the data a sync would
+// write into them. The target Secret is compared with data, the additional targets with their selection
+// of the files of the artifact (see targetData). Missing Secrets are compared as empty Secrets. The
+// differences are returned per Secret (namespace/name).
+func (r *OCISecretReconciler) auditTargets(ctx context.Context, ocisecret *ocisyncv1aplha1.OCISecret,
+	targetSecret *v1core.Secret, data map[string][]byte, files map[string][]byte) (map[string]utils.MapDiff, error) {
+	target := ocisecret.Spec.TargetSecret
+	diffs := map[string]utils.MapDiff{
+		target.Namespace + "/" + target.Name: utils.DiffMaps(targetSecret.Data, data),
+	}
+	for _, additional := range ocisecret.Spec.AdditionalTargets {
+		secret := &v1core.Secret{}
+		err := r.Get(ctx, types.NamespacedName{Name: additional.Name, Namespace: additional.Namespace}, secret)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+		want := mergedData(secret, targetData(files, additional), ocisecret.Spec.MergeMode)
+		diffs[additional.Namespace+"/"+additional.Name] = utils.DiffMaps(secret.Data, want)
+	}
+	return diffs, nil
+}
+
+// reportDrift publishes the differences between the target Secrets and the artifact with the digest in
+// the Drift condition, the ocisecret_drifted_keys metric, an event and the sync record, without writing
+// any Secret. The Ready condition is set to false with the AuditOnly reason, the target Secrets are
+// not synced by this operator.
+func (r *OCISecretReconciler) reportDrift(ctx context.Context, ocisecret *ocisyncv1aplha1.OCISecret,
+	record *SyncRecord, diffs map[string]utils.MapDiff, digest string) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var drifted []string
+	var keys []string
+	count := 0
+	for name, diff := range diffs {
+		if changed := diff.Keys(); len(changed) > 0 {
+			drifted = append(drifted, name)
+			count += len(changed)
+			if name == ocisecret.Spec.TargetSecret.Namespace+"/"+ocisecret.Spec.TargetSecret.Name {
+				keys = changed
+			}
+		}
+	}
+	sort.Strings(drifted)
+	driftedKeys.WithLabelValues(ocisecret.Namespace, ocisecret.Name).Set(float64(count))
+
+	drift := metav1.Condition{
+		Type:               ocisyncv1aplha1.ConditionTypeDrift,
+		Status:             metav1.ConditionFalse,
+		Reason:             ocisyncv1aplha1.ReasonInSync,
+		Message:            fmt.Sprintf("The target Secrets match the artefact %s", digest),
+		ObservedGeneration: ocisecret.Generation,
+	}
+	if len(drifted) > 0 {
+		drift.Status = metav1.ConditionTrue
+		drift.Reason = ocisyncv1aplha1.ReasonDrifted
+		drift.Message = fmt.Sprintf("%d keys of the target Secrets %s differ from the artefact %s",
+			count, strings.Join(drifted, ", "), digest)
+		logger.Info("TargetSecrets drifted from the artefact.", "secrets", drifted, "keys", count)
+	}
+	changed := meta.SetStatusCondition(&ocisecret.Status.Conditions, drift)
+	meta.SetStatusCondition(&ocisecret.Status.Conditions, metav1.Condition{
+		Type:               ocisyncv1aplha1.ConditionTypeReady,
+		Status:             metav1.ConditionFalse,
+		Reason:             ocisyncv1aplha1.ReasonAuditOnly,
+		Message:            "Audit-only: the target Secrets are compared with the artefact but not written",
+		ObservedGeneration: ocisecret.Generation,
+	})
+	if err := r.Status().Update(ctx, ocisecret); err != nil {
+		logger.Error(err, "Failed to update OCISecret status.")
+		return ctrl.Result{}, err
+	}
+	if changed && len(drifted) > 0 {
+		r.Recorder.Event(ocisecret, v1core.EventTypeWarning, ocisyncv1aplha1.ReasonDrifted, drift.Message)
+	}
+
+	record.Result = SyncResultAudited
+	record.Digest = digest
+	record.ChangedKeys = keys
+	return ctrl.Result{RequeueAfter: pollInterval}, nil
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+	"github.com/mariusbertram/oci-resource-sync-operator/internal/orasclient/fake"
+)
+
+var _ = Describe("Registry authentication", func() {
+	ctx := context.Background()
+	var ocisecret *ocisyncv1aplha1.OCISecret
+	var pullSecret *v1core.Secret
+	var artifacts *fake.Client
+	var controllerReconciler *OCISecretReconciler
+	var request reconcile.Request
+	targetName := types.NamespacedName{Name: "registry-auth", Namespace: "default"}
+
+	BeforeEach(func() {
+		pullSecret = &v1core.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "registry-auth-pull-secret", Namespace: "default"},
+			Type:       v1core.SecretTypeDockerConfigJson,
+			Data: map[string][]byte{
+				v1core.DockerConfigJsonKey: []byte(`{"auths":{"registry.example.com":{"auth":"c3RhbGU6c3RhbGU="}}}`),
+			},
+		}
+		Expect(k8sClient.Create(ctx, pullSecret)).To(Succeed())
+
+		ocisecret = newTestOCISecret("registry-auth")
+		ocisecret.Spec.ArtefactPullSecret = v1core.SecretReference{Name: pullSecret.Name, Namespace: "default"}
+		request = reconcile.Request{NamespacedName: types.NamespacedName{Name: ocisecret.Name, Namespace: ocisecret.Namespace}}
+
+		artifacts = fake.NewClient()
+		artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"app.yaml": []byte("app")})
+		artifacts.RejectCredentials(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact)
+		controllerReconciler = &OCISecretReconciler{
+			Client:         k8sClient,
+			Scheme:         k8sClient.Scheme(),
+			Recorder:       record.NewFakeRecorder(10),
+			ArtifactClient: artifacts,
+		}
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.Delete(ctx, ocisecret)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, pullSecret)).To(Succeed())
+		secret := &v1core.Secret{}
+		if k8sClient.Get(ctx, targetName, secret) == nil {
+			Expect(k8sClient.Delete(ctx, secret)).To(Succeed())
+		}
+	})
+
+	It("should fail on rejected credentials without AnonymousFallback", func() {
+		Expect(k8sClient.Create(ctx, ocisecret)).To(Succeed())
+
+		result, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).NotTo(BeZero())
+
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		condition := meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeReady)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Reason).To(Equal(ocisyncv1aplha1.ReasonAuthenticationFailed))
+	})
+
+	It("should retry anonymously with AnonymousFallback and report the auth mode", func() {
+		ocisecret.Spec.AnonymousFallback = true
+		Expect(k8sClient.Create(ctx, ocisecret)).To(Succeed())
+
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		secret := &v1core.Secret{}
+		Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+		Expect(secret.Data).To(Equal(map[string][]byte{"app.yaml": []byte("app")}))
+
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		Expect(ocisecret.Status.AuthMode).To(Equal(ocisyncv1aplha1.AuthModeAnonymousFallback))
+	})
+
+	It("should reject more than one source of credentials", func() {
+		ocisecret.Spec.CredentialProvider = "aws"
+		Expect(k8sClient.Create(ctx, ocisecret)).To(Succeed())
+
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		condition := meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeReady)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Reason).To(Equal(ocisyncv1aplha1.ReasonInvalidPullSecret))
+	})
+
+	Context("with a pull secret of another type", func() {
+		// replacePullSecret recreates the pull secret, the type of a Secret is immutable
+		replacePullSecret := func(secretType v1core.SecretType, annotations map[string]string, data map[string][]byte) {
+			Expect(k8sClient.Delete(ctx, pullSecret)).To(Succeed())
+			pullSecret = &v1core.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: pullSecret.Name, Namespace: "default", Annotations: annotations},
+				Type:       secretType,
+				Data:       data,
+			}
+			Expect(k8sClient.Create(ctx, pullSecret)).To(Succeed())
+		}
+		readyReason := func() string {
+			_, err := controllerReconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+			condition := meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeReady)
+			Expect(condition).NotTo(BeNil())
+			return condition.Reason
+		}
+
+		for _, unsupported := range []struct {
+			secretType  v1core.SecretType
+			annotations map[string]string
+			data        map[string][]byte
+		}{
+			{secretType: v1core.SecretTypeBasicAuth, data: map[string][]byte{v1core.BasicAuthUsernameKey: []byte("user")}},
+			{secretType: v1core.SecretTypeTLS, data: map[string][]byte{v1core.TLSCertKey: []byte("cert"), v1core.TLSPrivateKeyKey: []byte("key")}},
+			{secretType: v1core.SecretTypeSSHAuth, data: map[string][]byte{v1core.SSHAuthPrivateKey: []byte("key")}},
+			{secretType: v1core.SecretTypeServiceAccountToken,
+				annotations: map[string]string{v1core.ServiceAccountNameKey: "default"}},
+			{secretType: "example.com/registry-token", data: map[string][]byte{v1core.DockerConfigJsonKey: []byte("{}")}},
+		} {
+			It("should report the unsupported type "+string(unsupported.secretType), func() {
+				replacePullSecret(unsupported.secretType, unsupported.annotations, unsupported.data)
+				Expect(k8sClient.Create(ctx, ocisecret)).To(Succeed())
+
+				Expect(readyReason()).To(Equal(ocisyncv1aplha1.ReasonUnsupportedPullSecretType))
+				Expect(meta.FindStatusCondition(ocisecret.Status.Conditions,
+					ocisyncv1aplha1.ConditionTypeReady).Message).To(ContainSubstring(string(unsupported.secretType)))
+			})
+		}
+
+		It("should use the legacy .dockercfg of a kubernetes.io/dockercfg Secret", func() {
+			replacePullSecret(v1core.SecretTypeDockercfg, nil, map[string][]byte{
+				v1core.DockerConfigKey: []byte(`{"registry.example.com":{"auth":"c3RhbGU6c3RhbGU="}}`),
+			})
+			Expect(k8sClient.Create(ctx, ocisecret)).To(Succeed())
+
+			// The fake rejects every request with credentials, so an auth failure proves they were sent
+			Expect(readyReason()).To(Equal(ocisyncv1aplha1.ReasonAuthenticationFailed))
+		})
+
+		It("should use the .dockerconfigjson of an Opaque Secret", func() {
+			replacePullSecret(v1core.SecretTypeOpaque, nil, map[string][]byte{
+				v1core.DockerConfigJsonKey: []byte(`{"auths":{"registry.example.com":{"auth":"c3RhbGU6c3RhbGU="}}}`),
+			})
+			Expect(k8sClient.Create(ctx, ocisecret)).To(Succeed())
+
+			Expect(readyReason()).To(Equal(ocisyncv1aplha1.ReasonAuthenticationFailed))
+		})
+
+		It("should report an Opaque Secret without Docker credentials", func() {
+			replacePullSecret(v1core.SecretTypeOpaque, nil, map[string][]byte{"token": []byte("secret")})
+			Expect(k8sClient.Create(ctx, ocisecret)).To(Succeed())
+
+			Expect(readyReason()).To(Equal(ocisyncv1aplha1.ReasonInvalidPullSecret))
+		})
+	})
+
+	Context("with a basic-auth Secret", func() {
+		var basicAuthSecret *v1core.Secret
+
+		BeforeEach(func() {
+			basicAuthSecret = &v1core.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "registry-auth-basic-auth", Namespace: "default"},
+				Type:       v1core.SecretTypeBasicAuth,
+				Data: map[string][]byte{
+					v1core.BasicAuthUsernameKey: []byte("user"),
+					v1core.BasicAuthPasswordKey: []byte("secret"),
+				},
+			}
+			ocisecret.Spec.ArtefactPullSecret = v1core.SecretReference{}
+			ocisecret.Spec.BasicAuthSecretRef = &v1core.SecretReference{Name: basicAuthSecret.Name}
+		})
+
+		AfterEach(func() {
+			Expect(k8sClient.Delete(ctx, basicAuthSecret)).To(Succeed())
+		})
+
+		It("should authenticate with the username and password", func() {
+			Expect(k8sClient.Create(ctx, basicAuthSecret)).To(Succeed())
+			Expect(k8sClient.Create(ctx, ocisecret)).To(Succeed())
+
+			// The fake rejects every request with credentials, so an auth failure proves they were sent
+			_, err := controllerReconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+			condition := meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeReady)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Reason).To(Equal(ocisyncv1aplha1.ReasonAuthenticationFailed))
+		})
+
+		It("should report a basic-auth Secret without username", func() {
+			delete(basicAuthSecret.Data, v1core.BasicAuthUsernameKey)
+			basicAuthSecret.Type = v1core.SecretTypeOpaque
+			Expect(k8sClient.Create(ctx, basicAuthSecret)).To(Succeed())
+			Expect(k8sClient.Create(ctx, ocisecret)).To(Succeed())
+
+			_, err := controllerReconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+			condition := meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeReady)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Reason).To(Equal(ocisyncv1aplha1.ReasonInvalidPullSecret))
+		})
+	})
+})
@@ -0,0 +1,88 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// failureBackoff tracks the consecutive failed syncs of every OCISecret and delays their requeue
+// exponentially, so an OCISecret that keeps failing (e.g. with an invalid signature) doesn't poll
+// the registry at the regular interval forever.
+type failureBackoff struct {
+	base time.Duration
+	max  time.Duration
+
+	mu       sync.Mutex
+	failures map[types.NamespacedName]int
+}
+
+// newFailureBackoff returns a failureBackoff whose delay starts at base and doubles with every
+// further failure up to max.
+func newFailureBackoff(base, max time.Duration) *failureBackoff {
+	return &failureBackoff{base: base, max: max, failures: map[types.NamespacedName]int{}}
+}
+
+// next records a failed sync of the OCISecret and returns the jittered delay before it's retried.
+func (b *failureBackoff) next(name types.NamespacedName) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	failures := b.failures[name]
+	b.failures[name] = failures + 1
+	return jitter(exponentialDelay(b.base, b.max, failures))
+}
+
+// reset forgets the failures of the OCISecret after it synced successfully or was deleted.
+func (b *failureBackoff) reset(name types.NamespacedName) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.failures, name)
+}
+
+// exponentialDelay returns base doubled for every previous failure, capped at max.
+func exponentialDelay(base, max time.Duration, failures int) time.Duration {
+	delay := base
+	for i := 0; i < failures && delay < max; i++ {
+		delay *= 2
+	}
+	return min(delay, max)
+}
+
+// jitter returns a random delay between half of the delay and the delay, so OCISecrets that failed
+// at the same time (e.g. during a registry outage) don't retry in lockstep.
+func jitter(delay time.Duration) time.Duration {
+	if delay <= 1 {
+		return delay
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// jitteredRateLimiter adds jitter to the delays of a rate limiter of the workqueue.
+type jitteredRateLimiter struct {
+	workqueue.TypedRateLimiter[reconcile.Request]
+}
+
+// When returns the jittered delay of the wrapped rate limiter.
+func (l jitteredRateLimiter) When(request reconcile.Request) time.Duration {
+	return jitter(l.TypedRateLimiter.When(request))
+}
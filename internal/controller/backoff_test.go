@@ -0,0 +1,54 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("Failure backoff", func() {
+	It("should double the delay with every failure up to the maximum", func() {
+		Expect(exponentialDelay(time.Second, time.Minute, 0)).To(Equal(time.Second))
+		Expect(exponentialDelay(time.Second, time.Minute, 3)).To(Equal(8 * time.Second))
+		Expect(exponentialDelay(time.Second, time.Minute, 10)).To(Equal(time.Minute))
+		Expect(exponentialDelay(time.Second, time.Minute, 1000)).To(Equal(time.Minute))
+	})
+
+	It("should jitter the delay between half of it and the full delay", func() {
+		for range 100 {
+			Expect(jitter(10 * time.Second)).To(BeNumerically("~", 7500*time.Millisecond, 2500*time.Millisecond))
+		}
+	})
+
+	It("should grow per OCISecret and start over after a reset", func() {
+		backoff := newFailureBackoff(time.Second, time.Minute)
+		first := types.NamespacedName{Name: "first", Namespace: "default"}
+		second := types.NamespacedName{Name: "second", Namespace: "default"}
+
+		Expect(backoff.next(first)).To(BeNumerically("<=", time.Second))
+		Expect(backoff.next(first)).To(BeNumerically(">=", time.Second))
+		Expect(backoff.next(first)).To(BeNumerically(">=", 2*time.Second))
+		Expect(backoff.next(second)).To(BeNumerically("<=", time.Second))
+
+		backoff.reset(first)
+		Expect(backoff.next(first)).To(BeNumerically("<=", time.Second))
+	})
+})
@@ -0,0 +1,117 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+)
+
+// targetSecretIndex is the field index of the OCISecrets by the Secrets they write ("<namespace>/<name>").
+const targetSecretIndex = "spec.targetSecrets"
+
+// targetSecretKeys returns the "<namespace>/<name>" keys of the target Secrets of the OCISecret,
+// defaulting empty namespaces like resolveNamespaces.
+func (r *OCISecretReconciler) targetSecretKeys(ocisecret *ocisyncv1aplha1.OCISecret) []string {
+	key := func(name, namespace string) string {
+		if namespace == "" {
+			namespace = ocisecret.Namespace
+		}
+		if namespace == "" {
+			namespace = r.DefaultNamespace
+		}
+		return namespace + "/" + name
+	}
+
+	keys := []string{key(ocisecret.Spec.TargetSecret.Name, ocisecret.Spec.TargetSecret.Namespace)}
+	for _, target := range ocisecret.Spec.AdditionalTargets {
+		keys = append(keys, key(target.Name, target.Namespace))
+	}
+	return keys
+}
+
+// indexTargetSecrets is the IndexerFunc of targetSecretIndex.
+func (r *OCISecretReconciler) indexTargetSecrets(obj client.Object) []string {
+	ocisecret, ok := obj.(*ocisyncv1aplha1.OCISecret)
+	if !ok {
+		return nil
+	}
+	return r.targetSecretKeys(ocisecret)
+}
+
+// findTargetConflicts returns the names ("<namespace>/<name>", sorted) of the other OCISecrets that
+// write one of the target Secrets of the OCISecret. Without the field index (e.g. when the reconciler
+// isn't set up with a manager) all OCISecrets are listed and filtered.
+func (r *OCISecretReconciler) findTargetConflicts(ctx context.Context, ocisecret *ocisyncv1aplha1.OCISecret) ([]string, error) {
+	conflicts := map[string]bool{}
+	for _, key := range r.targetSecretKeys(ocisecret) {
+		list := &ocisyncv1aplha1.OCISecretList{}
+		if r.targetSecretIndexed {
+			if err := r.List(ctx, list, client.MatchingFields{targetSecretIndex: key}); err != nil {
+				return nil, err
+			}
+		} else if err := r.List(ctx, list); err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			other := &list.Items[i]
+			if other.UID == ocisecret.UID {
+				continue
+			}
+			for _, otherKey := range r.targetSecretKeys(other) {
+				if otherKey == key {
+					conflicts[other.Namespace+"/"+other.Name] = true
+				}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(conflicts))
+	for name := range conflicts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// updateConflictCondition sets the Conflict condition while other OCISecrets write the same target
+// Secrets and emits a warning event; it removes the condition once the conflict is resolved. The
+// status is only persisted if it changed.
+func (r *OCISecretReconciler) updateConflictCondition(ctx context.Context, ocisecret *ocisyncv1aplha1.OCISecret,
+	conflicts []string) error {
+	if len(conflicts) == 0 {
+		if !meta.RemoveStatusCondition(&ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeConflict) {
+			return nil
+		}
+		return r.Status().Update(ctx, ocisecret)
+	}
+
+	message := fmt.Sprintf("The target Secrets are also written by the OCISecrets %s; none of them is synced "+
+		"until each Secret has a single OCISecret", strings.Join(conflicts, ", "))
+	r.Recorder.Event(ocisecret, v1core.EventTypeWarning, ocisyncv1aplha1.ReasonTargetConflict, message)
+	return r.setCondition(ctx, ocisecret, ocisyncv1aplha1.ConditionTypeConflict, metav1.ConditionTrue,
+		ocisyncv1aplha1.ReasonTargetConflict, message)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+	"github.com/mariusbertram/oci-resource-sync-operator/internal/orasclient/fake"
+)
+
+var _ = Describe("Target Secret conflicts", func() {
+	ctx := context.Background()
+	var first, second *ocisyncv1aplha1.OCISecret
+	var artifacts *fake.Client
+	var controllerReconciler *OCISecretReconciler
+
+	BeforeEach(func() {
+		first = newTestOCISecret("conflict-first")
+		second = newTestOCISecret("conflict-second")
+		second.Spec.AdditionalTargets = []ocisyncv1aplha1.SecretTarget{{Name: first.Spec.TargetSecret.Name}}
+		Expect(k8sClient.Create(ctx, first)).To(Succeed())
+		Expect(k8sClient.Create(ctx, second)).To(Succeed())
+
+		artifacts = fake.NewClient()
+		artifacts.SetArtifact(first.Spec.ArtefactRegistry, first.Spec.OrasArtefact, map[string][]byte{"app.yaml": []byte("app")})
+		controllerReconciler = &OCISecretReconciler{
+			Client:         k8sClient,
+			Scheme:         k8sClient.Scheme(),
+			Recorder:       record.NewFakeRecorder(10),
+			ArtifactClient: artifacts,
+		}
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.Delete(ctx, first)).To(Succeed())
+		if second != nil {
+			Expect(k8sClient.Delete(ctx, second)).To(Succeed())
+		}
+		secret := &v1core.Secret{}
+		targetName := types.NamespacedName{Name: first.Spec.TargetSecret.Name, Namespace: first.Namespace}
+		if k8sClient.Get(ctx, targetName, secret) == nil {
+			Expect(k8sClient.Delete(ctx, secret)).To(Succeed())
+		}
+	})
+
+	It("should index the target Secrets with defaulted namespaces", func() {
+		Expect(controllerReconciler.indexTargetSecrets(second)).To(Equal([]string{
+			"default/conflict-second", "default/conflict-first",
+		}))
+	})
+
+	It("should report the conflict on all involved OCISecrets and resume once it is resolved", func() {
+		for _, ocisecret := range []*ocisyncv1aplha1.OCISecret{first, second} {
+			name := types.NamespacedName{Name: ocisecret.Name, Namespace: ocisecret.Namespace}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: name})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, name, ocisecret)).To(Succeed())
+			Expect(meta.IsStatusConditionTrue(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeConflict)).To(BeTrue())
+			condition := meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeReady)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Reason).To(Equal(ocisyncv1aplha1.ReasonTargetConflict))
+		}
+		Expect(artifacts.Pulls(first.Spec.ArtefactRegistry, first.Spec.OrasArtefact)).To(BeZero())
+
+		Expect(k8sClient.Delete(ctx, second)).To(Succeed())
+		second = nil
+		name := types.NamespacedName{Name: first.Name, Namespace: first.Namespace}
+		_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: name})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, name, first)).To(Succeed())
+		Expect(meta.FindStatusCondition(first.Status.Conditions, ocisyncv1aplha1.ConditionTypeConflict)).To(BeNil())
+		Expect(meta.IsStatusConditionTrue(first.Status.Conditions, ocisyncv1aplha1.ConditionTypeReady)).To(BeTrue())
+	})
+})
@@ -0,0 +1,94 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+
+	v1core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// errUnsupportedPullSecretType is returned when the pull secret has a type that can't hold Docker credentials.
+var errUnsupportedPullSecretType = errors.New("unsupported ArtefactPullSecret type")
+
+// errInvalidPullSecret is returned when the pull secret has no Docker credentials.
+var errInvalidPullSecret = errors.New("invalid ArtefactPullSecret")
+
+// pullSecretCredentials returns the Docker config JSON of the pull secret. kubernetes.io/dockerconfigjson,
+// kubernetes.io/dockercfg and Opaque Secrets are accepted if they have a .dockerconfigjson or a legacy
+// .dockercfg key; the latter is wrapped into the auths section of a config JSON. Secrets of other types,
+// e.g. a basic-auth Secret that should be the BasicAuthSecretRef, fail with errUnsupportedPullSecretType
+// (wrapped) instead of with the missing key.
+func pullSecretCredentials(secret *v1core.Secret) ([]byte, error) {
+	switch secret.Type {
+	case v1core.SecretTypeDockerConfigJson, v1core.SecretTypeDockercfg, v1core.SecretTypeOpaque, "":
+	default:
+		return nil, fmt.Errorf("%w %s of Secret %s/%s, expected %s, %s or %s", errUnsupportedPullSecretType,
+			secret.Type, secret.Namespace, secret.Name, v1core.SecretTypeDockerConfigJson, v1core.SecretTypeDockercfg,
+			v1core.SecretTypeOpaque)
+	}
+	if config := secret.Data[v1core.DockerConfigJsonKey]; len(config) > 0 {
+		return config, nil
+	}
+	if config := secret.Data[v1core.DockerConfigKey]; len(config) > 0 {
+		return fmt.Appendf(nil, `{"auths":%s}`, config), nil
+	}
+	return nil, fmt.Errorf("%w: Secret %s/%s has no %s or %s data", errInvalidPullSecret, secret.Namespace, secret.Name,
+		v1core.DockerConfigJsonKey, v1core.DockerConfigKey)
+}
+
+// errInvalidBasicAuth is returned when the basic-auth Secret has no username.
+var errInvalidBasicAuth = errors.New("invalid basic-auth Secret")
+
+// errInvalidClientCertificate is returned when the client certificate Secret can't be used for mutual TLS.
+var errInvalidClientCertificate = errors.New("invalid client certificate")
+
+// loadClientCertificate reads the client certificate and key (tls.crt and tls.key) and the optional
+// CA bundle (ca.crt) for mutual TLS from the referenced Secret. The key pair is parsed right away so
+// a broken Secret is reported before any connection to the registry is attempted.
+func (r *OCISecretReconciler) loadClientCertificate(ctx context.Context, ref *v1core.SecretReference) (*tls.Certificate, []byte, error) {
+	secret := &v1core.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}, secret); err != nil {
+		return nil, nil, err
+	}
+
+	certificate, err := tls.X509KeyPair(secret.Data[v1core.TLSCertKey], secret.Data[v1core.TLSPrivateKeyKey])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w in Secret %s/%s: %v", errInvalidClientCertificate, ref.Namespace, ref.Name, err)
+	}
+	return &certificate, secret.Data["ca.crt"], nil
+}
+
+// loadBasicAuth reads the username and password (the keys of kubernetes.io/basic-auth Secrets) for
+// the registry from the referenced Secret.
+func (r *OCISecretReconciler) loadBasicAuth(ctx context.Context, ref *v1core.SecretReference) (string, string, error) {
+	secret := &v1core.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}, secret); err != nil {
+		return "", "", err
+	}
+
+	username := string(secret.Data[v1core.BasicAuthUsernameKey])
+	if username == "" {
+		return "", "", fmt.Errorf("%w: Secret %s/%s has no %s", errInvalidBasicAuth, ref.Namespace, ref.Name,
+			v1core.BasicAuthUsernameKey)
+	}
+	return username, string(secret.Data[v1core.BasicAuthPasswordKey]), nil
+}
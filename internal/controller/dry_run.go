@@ -0,0 +1,80 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+	"github.com/mariusbertram/oci-resource-sync-operator/internal/utils"
+)
+
+// reportDryRun publishes the changes a sync would apply to the target Secret in the status, as an
+// event and in the sync record, without writing the Secret. The Ready condition is set to false with
+// the DryRun reason so it is obvious the target Secret is not being synced.
+func (r *OCISecretReconciler) reportDryRun(ctx context.Context, ocisecret *ocisyncv1aplha1.OCISecret,
+	record *SyncRecord, diff utils.MapDiff, digest string) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	message := fmt.Sprintf("Dry-run: TargetSecret is not updated; syncing %s would add %d, change %d and remove %d keys",
+		digest, len(diff.Added), len(diff.Changed), len(diff.Removed))
+	logger.Info("Dry-run, TargetSecret not updated.", "added", diff.Added, "changed", diff.Changed, "removed", diff.Removed)
+
+	ocisecret.Status.Plan = &ocisyncv1aplha1.SyncPlan{
+		Digest:      digest,
+		AddedKeys:   diff.Added,
+		ChangedKeys: diff.Changed,
+		RemovedKeys: diff.Removed,
+	}
+	meta.SetStatusCondition(&ocisecret.Status.Conditions, metav1.Condition{
+		Type:               ocisyncv1aplha1.ConditionTypeReady,
+		Status:             metav1.ConditionFalse,
+		Reason:             ocisyncv1aplha1.ReasonDryRun,
+		Message:            message,
+		ObservedGeneration: ocisecret.Generation,
+	})
+	if err := r.Status().Update(ctx, ocisecret); err != nil {
+		logger.Error(err, "Failed to update OCISecret status.")
+		return ctrl.Result{}, err
+	}
+	if !diff.Empty() {
+		r.Recorder.Event(ocisecret, v1core.EventTypeNormal, ocisyncv1aplha1.ReasonDryRun, message)
+	}
+
+	record.Result = SyncResultDryRun
+	record.Digest = digest
+	record.ChangedKeys = diff.Keys()
+	return ctrl.Result{RequeueAfter: time.Duration(60) * time.Second}, nil
+}
+
+// lastPlan returns the changes of the plan in the status if it was computed for the digest. A reconcile
+// that downloads nothing must not replace a plan with pending changes by an empty one.
+func lastPlan(ocisecret *ocisyncv1aplha1.OCISecret, digest string) utils.MapDiff {
+	plan := ocisecret.Status.Plan
+	if plan == nil || plan.Digest != digest {
+		return utils.MapDiff{}
+	}
+	return utils.MapDiff{Added: plan.AddedKeys, Changed: plan.ChangedKeys, Removed: plan.RemovedKeys}
+}
@@ -0,0 +1,629 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	v1core "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+	"github.com/mariusbertram/oci-resource-sync-operator/internal/orasclient"
+	"github.com/mariusbertram/oci-resource-sync-operator/internal/orasclient/fake"
+)
+
+var _ = Describe("Reconcile with a fake registry", func() {
+	ctx := context.Background()
+	var ocisecret *ocisyncv1aplha1.OCISecret
+	var artifacts *fake.Client
+	var recorder *record.FakeRecorder
+	var controllerReconciler *OCISecretReconciler
+	var request reconcile.Request
+	targetName := types.NamespacedName{Name: "fake-registry", Namespace: "default"}
+
+	BeforeEach(func() {
+		ocisecret = newTestOCISecret("fake-registry")
+		Expect(k8sClient.Create(ctx, ocisecret)).To(Succeed())
+		request = reconcile.Request{NamespacedName: types.NamespacedName{Name: ocisecret.Name, Namespace: ocisecret.Namespace}}
+
+		artifacts = fake.NewClient()
+		recorder = record.NewFakeRecorder(10)
+		controllerReconciler = &OCISecretReconciler{
+			Client:         k8sClient,
+			Scheme:         k8sClient.Scheme(),
+			Recorder:       recorder,
+			ArtifactClient: artifacts,
+		}
+	})
+
+	AfterEach(func() {
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, ocisecret))).To(Succeed())
+		secret := &v1core.Secret{}
+		if k8sClient.Get(ctx, targetName, secret) == nil {
+			Expect(k8sClient.Delete(ctx, secret)).To(Succeed())
+		}
+	})
+
+	It("should create the target Secret and update it when the digest changes", func() {
+		firstDigest := artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"app.yaml": []byte("v1")})
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		secret := &v1core.Secret{}
+		Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+		Expect(secret.Data).To(Equal(map[string][]byte{"app.yaml": []byte("v1")}))
+		Expect(secret.Annotations).To(HaveKeyWithValue(revisionAnnotation, string(firstDigest)))
+		Expect(recorder.Events).To(Receive(And(ContainSubstring(ocisyncv1aplha1.ReasonSynced),
+			ContainSubstring("added 1 key"))))
+
+		secondDigest := artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"app.yaml": []byte("v2")})
+		Expect(secondDigest).NotTo(Equal(firstDigest))
+		_, err = controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+		Expect(secret.Data).To(Equal(map[string][]byte{"app.yaml": []byte("v2")}))
+		Expect(secret.Annotations).To(HaveKeyWithValue(revisionAnnotation, string(secondDigest)))
+		Expect(recorder.Events).To(Receive(And(ContainSubstring(ocisyncv1aplha1.ReasonSynced),
+			ContainSubstring("updated 1 key"))))
+
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		Expect(ocisecret.Status.FileDigests).To(Equal(fileDigests(map[string][]byte{"app.yaml": []byte("v2")})))
+		Expect(ocisecret.Status.SyncHistory).To(HaveLen(2))
+		Expect(ocisecret.Status.SyncHistory[0].Digest).To(Equal(string(secondDigest)))
+		Expect(ocisecret.Status.SyncHistory[1].Digest).To(Equal(string(firstDigest)))
+	})
+
+	It("should record the observed generation and skip downloads while nothing changed", func() {
+		artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"app.yaml": []byte("app")})
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		Expect(ocisecret.Status.ObservedGeneration).To(Equal(ocisecret.Generation))
+
+		_, err = controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(artifacts.Pulls(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact)).To(Equal(1))
+
+		// A spec change is applied even though the digest is the same
+		ocisecret.Spec.Sync.Files = []string{"missing.yaml"}
+		Expect(k8sClient.Update(ctx, ocisecret)).To(Succeed())
+		_, err = controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(artifacts.Pulls(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact)).To(Equal(2))
+
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		Expect(ocisecret.Status.ObservedGeneration).To(Equal(ocisecret.Generation))
+	})
+
+	It("should download the artefact again when the force-sync annotation changes", func() {
+		artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"app.yaml": []byte("app")})
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		ocisecret.Annotations = map[string]string{ocisyncv1aplha1.ForceSyncAnnotation: "1"}
+		Expect(k8sClient.Update(ctx, ocisecret)).To(Succeed())
+		_, err = controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(artifacts.Pulls(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact)).To(Equal(2))
+
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		Expect(ocisecret.Status.LastForceSync).To(Equal("1"))
+
+		// The handled value doesn't force further downloads
+		_, err = controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(artifacts.Pulls(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact)).To(Equal(2))
+	})
+
+	It("should not sync while suspended", func() {
+		ocisecret.Spec.Suspend = true
+		Expect(k8sClient.Update(ctx, ocisecret)).To(Succeed())
+		artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"app.yaml": []byte("app")})
+
+		result, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeZero())
+		Expect(artifacts.Pulls(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact)).To(BeZero())
+
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeSuspended)).To(BeTrue())
+
+		ocisecret.Spec.Suspend = false
+		Expect(k8sClient.Update(ctx, ocisecret)).To(Succeed())
+		_, err = controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(artifacts.Pulls(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact)).To(Equal(1))
+
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		Expect(meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeSuspended)).To(BeNil())
+	})
+
+	It("should delete the target Secret with a finalizer instead of an owner reference", func() {
+		ocisecret.Spec.SetOwnerReference = pointer.Bool(false)
+		Expect(k8sClient.Update(ctx, ocisecret)).To(Succeed())
+		artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"app.yaml": []byte("app")})
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		secret := &v1core.Secret{}
+		Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+		Expect(secret.OwnerReferences).To(BeEmpty())
+		Expect(secret.Data).To(Equal(map[string][]byte{"app.yaml": []byte("app")}))
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		Expect(ocisecret.Finalizers).To(ContainElement(cleanupFinalizer))
+
+		// The next sync still recognises the Secret without the owner reference
+		_, err = controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Delete(ctx, ocisecret)).To(Succeed())
+		_, err = controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(k8sClient.Get(ctx, targetName, secret)).To(Satisfy(apierrors.IsNotFound))
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Satisfy(apierrors.IsNotFound))
+	})
+
+	It("should keep manually managed keys in merge mode", func() {
+		ocisecret.Spec.MergeMode = ocisyncv1aplha1.MergeModeMerge
+		Expect(k8sClient.Update(ctx, ocisecret)).To(Succeed())
+		artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"app.yaml": []byte("app"), "old.yaml": []byte("old")})
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		secret := &v1core.Secret{}
+		Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+		secret.Data["manual.yaml"] = []byte("manual")
+		Expect(k8sClient.Update(ctx, secret)).To(Succeed())
+
+		artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"app.yaml": []byte("v2")})
+		_, err = controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+		Expect(secret.Data).To(Equal(map[string][]byte{"app.yaml": []byte("v2"), "manual.yaml": []byte("manual")}))
+	})
+
+	It("should only sync the selected files", func() {
+		ocisecret.Spec.Sync.Files = []string{"app.yaml"}
+		Expect(k8sClient.Update(ctx, ocisecret)).To(Succeed())
+		artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"app.yaml": []byte("app"), "other.yaml": []byte("other")})
+
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		secret := &v1core.Secret{}
+		Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+		Expect(secret.Data).To(Equal(map[string][]byte{"app.yaml": []byte("app")}))
+		Expect(recorder.Events).To(Receive(And(ContainSubstring(ocisyncv1aplha1.ReasonFilesFiltered),
+			ContainSubstring("Kept 1 of 2 files"))))
+	})
+
+	It("should sync the files matching a glob pattern", func() {
+		ocisecret.Spec.Sync.Files = []string{"*.yaml"}
+		Expect(k8sClient.Update(ctx, ocisecret)).To(Succeed())
+		artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"app.yaml": []byte("app"), "db.yaml": []byte("db"), "app.json": []byte("json")})
+
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		secret := &v1core.Secret{}
+		Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+		Expect(secret.Data).To(Equal(map[string][]byte{"app.yaml": []byte("app"), "db.yaml": []byte("db")}))
+	})
+
+	It("should warn when no file matches", func() {
+		ocisecret.Spec.Sync.Files = []string{"*.toml"}
+		Expect(k8sClient.Update(ctx, ocisecret)).To(Succeed())
+		artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"app.yaml": []byte("app")})
+
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		secret := &v1core.Secret{}
+		Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+		Expect(secret.Data).To(BeEmpty())
+		Expect(recorder.Events).To(Receive(ContainSubstring(ocisyncv1aplha1.ReasonNoFilesMatched)))
+	})
+
+	It("should not sync the excluded files", func() {
+		ocisecret.Spec.Sync.Files = []string{"app.yaml", "other.yaml"}
+		ocisecret.Spec.Sync.ExcludeFiles = []string{"other.yaml"}
+		Expect(k8sClient.Update(ctx, ocisecret)).To(Succeed())
+		artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"app.yaml": []byte("app"), "other.yaml": []byte("other"), "db.yaml": []byte("db")})
+
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		secret := &v1core.Secret{}
+		Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+		Expect(secret.Data).To(Equal(map[string][]byte{"app.yaml": []byte("app")}))
+	})
+
+	It("should only download the selected layers", func() {
+		ocisecret.Spec.Sync.LayerTitles = []string{"app.yaml"}
+		Expect(k8sClient.Update(ctx, ocisecret)).To(Succeed())
+		artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"app.yaml": []byte("app"), "large.bin": []byte("large")})
+
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		secret := &v1core.Secret{}
+		Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+		Expect(secret.Data).To(Equal(map[string][]byte{"app.yaml": []byte("app")}))
+	})
+
+	It("should report a missing layer", func() {
+		ocisecret.Spec.Sync.LayerTitles = []string{"missing.yaml"}
+		Expect(k8sClient.Update(ctx, ocisecret)).To(Succeed())
+		artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"app.yaml": []byte("app")})
+
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		condition := meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeReady)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Reason).To(Equal(ocisyncv1aplha1.ReasonLayerNotFound))
+	})
+
+	It("should report a missing artefact", func() {
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		condition := meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeReady)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Reason).To(Equal(ocisyncv1aplha1.ReasonArtifactNotFound))
+	})
+
+	It("should refuse an artefact with another digest than the expected one", func() {
+		firstDigest := artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"app.yaml": []byte("v1")})
+		ocisecret.Spec.ExpectedDigest = string(firstDigest)
+		Expect(k8sClient.Update(ctx, ocisecret)).To(Succeed())
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		// The tag moves to unexpected content
+		artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"app.yaml": []byte("tampered")})
+		_, err = controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		secret := &v1core.Secret{}
+		Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+		Expect(secret.Data).To(Equal(map[string][]byte{"app.yaml": []byte("v1")}))
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		condition := meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeReady)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Reason).To(Equal(ocisyncv1aplha1.ReasonDigestMismatch))
+	})
+
+	It("should skip artefacts below the minimum version", func() {
+		ocisecret.Spec.MinVersion = "1.4.0"
+		Expect(k8sClient.Update(ctx, ocisecret)).To(Succeed())
+		registry, tag := ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact
+		artifacts.SetArtifact(registry, tag, map[string][]byte{"app.yaml": []byte("v1.4")})
+		artifacts.SetAnnotations(registry, tag, map[string]string{"org.opencontainers.image.version": "1.4.0"})
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		// The tag moves back to an older version, which is skipped without downloading its layers
+		artifacts.SetArtifact(registry, tag, map[string][]byte{"app.yaml": []byte("v1.3")})
+		artifacts.SetAnnotations(registry, tag, map[string]string{"org.opencontainers.image.version": "1.3.2"})
+		result, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+		Expect(artifacts.Pulls(registry, tag)).To(Equal(1))
+
+		secret := &v1core.Secret{}
+		Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+		Expect(secret.Data).To(Equal(map[string][]byte{"app.yaml": []byte("v1.4")}))
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		skipped := meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeSyncSkipped)
+		Expect(skipped).NotTo(BeNil())
+		Expect(skipped.Status).To(Equal(metav1.ConditionTrue))
+		Expect(skipped.Reason).To(Equal(ocisyncv1aplha1.ReasonBelowGate))
+		Expect(skipped.Message).To(ContainSubstring("1.3.2 is below the minimum version 1.4.0"))
+		ready := meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeReady)
+		Expect(ready).NotTo(BeNil())
+		Expect(ready.Status).To(Equal(metav1.ConditionTrue))
+
+		// A newer version is synced and clears the condition
+		artifacts.SetArtifact(registry, tag, map[string][]byte{"app.yaml": []byte("v1.5")})
+		artifacts.SetAnnotations(registry, tag, map[string]string{"org.opencontainers.image.version": "1.5.0"})
+		_, err = controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+		Expect(secret.Data).To(Equal(map[string][]byte{"app.yaml": []byte("v1.5")}))
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		Expect(meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeSyncSkipped)).To(BeNil())
+	})
+
+	It("should return transient errors to be retried with backoff", func() {
+		transientErr := fmt.Errorf("%w: registry unavailable", orasclient.ErrTransient)
+		artifacts.SetError(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact, transientErr)
+
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).To(MatchError(orasclient.ErrTransient))
+	})
+
+	It("should warn about artefacts without files", func() {
+		artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact, map[string][]byte{})
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		condition := meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeEmptyArtifact)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(recorder.Events).To(Receive(ContainSubstring(ocisyncv1aplha1.ReasonEmptyArtifact)))
+
+		artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"app.yaml": []byte("app")})
+		_, err = controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		Expect(meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeEmptyArtifact)).To(BeNil())
+	})
+
+	It("should refuse data beyond the size limit of a Secret", func() {
+		artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"large.bin": make([]byte, v1core.MaxSecretSize+1)})
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		condition := meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeTooLarge)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Message).To(ContainSubstring(fmt.Sprint(v1core.MaxSecretSize + 1)))
+		condition = meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeReady)
+		Expect(condition.Reason).To(Equal(ocisyncv1aplha1.ReasonSecretTooLarge))
+
+		secret := &v1core.Secret{}
+		Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+		Expect(secret.Data).NotTo(HaveKey("large.bin"))
+	})
+
+	It("should leave the target Secret unchanged when no files are left and failOnEmpty is set", func() {
+		artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"app.yaml": []byte("app")})
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		ocisecret.Spec.FailOnEmpty = true
+		ocisecret.Spec.Sync.Files = []string{"missing.yaml"}
+		Expect(k8sClient.Update(ctx, ocisecret)).To(Succeed())
+		_, err = controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		secret := &v1core.Secret{}
+		Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+		Expect(secret.Data).To(Equal(map[string][]byte{"app.yaml": []byte("app")}))
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		condition := meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeReady)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Reason).To(Equal(ocisyncv1aplha1.ReasonNoFilesMatched))
+	})
+
+	It("should report requested files that are missing in the artefact", func() {
+		artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"app.yaml": []byte("app")})
+		ocisecret.Spec.Sync.Files = []string{"app.yaml", "db.yaml"}
+		Expect(k8sClient.Update(ctx, ocisecret)).To(Succeed())
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		secret := &v1core.Secret{}
+		Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+		Expect(secret.Data).To(Equal(map[string][]byte{"app.yaml": []byte("app")}))
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		condition := meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeMissingFiles)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Message).To(ContainSubstring("db.yaml"))
+
+		// With requireAllFiles the sync fails instead
+		ocisecret.Spec.RequireAllFiles = true
+		Expect(k8sClient.Update(ctx, ocisecret)).To(Succeed())
+		_, err = controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		condition = meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeReady)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Reason).To(Equal(ocisyncv1aplha1.ReasonFilesMissing))
+	})
+
+	It("should only report the drift of the target Secret in audit-only mode", func() {
+		controllerReconciler.AuditOnly = true
+		artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"app.yaml": []byte("v1")})
+		// A Secret that is still managed by hand
+		manual := &v1core.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: targetName.Name, Namespace: targetName.Namespace},
+			Data:       map[string][]byte{"app.yaml": []byte("v0"), "manual.yaml": []byte("manual")},
+		}
+		Expect(k8sClient.Create(ctx, manual)).To(Succeed())
+
+		result, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(pollInterval))
+
+		secret := &v1core.Secret{}
+		Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+		Expect(secret.Data).To(Equal(manual.Data))
+		Expect(secret.OwnerReferences).To(BeEmpty())
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		condition := meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeDrift)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(ocisyncv1aplha1.ReasonDrifted))
+		condition = meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeReady)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Reason).To(Equal(ocisyncv1aplha1.ReasonAuditOnly))
+		Expect(testutil.ToFloat64(driftedKeys.WithLabelValues(ocisecret.Namespace, ocisecret.Name))).To(Equal(2.0))
+
+		secret.Data = map[string][]byte{"app.yaml": []byte("v1")}
+		Expect(k8sClient.Update(ctx, secret)).To(Succeed())
+		_, err = controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		condition = meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeDrift)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(testutil.ToFloat64(driftedKeys.WithLabelValues(ocisecret.Namespace, ocisecret.Name))).To(BeZero())
+	})
+
+	It("should only plan the sync without creating the target Secret in dry-run mode", func() {
+		ocisecret.Spec.DryRun = true
+		Expect(k8sClient.Update(ctx, ocisecret)).To(Succeed())
+		digest := artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"app.yaml": []byte("app")})
+
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(k8sClient.Get(ctx, targetName, &v1core.Secret{})).To(Satisfy(apierrors.IsNotFound))
+		Expect(recorder.Events).To(Receive(ContainSubstring(ocisyncv1aplha1.ReasonDryRun)))
+
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		Expect(ocisecret.Status.Plan).To(Equal(&ocisyncv1aplha1.SyncPlan{Digest: string(digest), AddedKeys: []string{"app.yaml"}}))
+		condition := meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeReady)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(ocisyncv1aplha1.ReasonDryRun))
+
+		// Turning the dry-run off syncs the planned changes
+		ocisecret.Spec.DryRun = false
+		Expect(k8sClient.Update(ctx, ocisecret)).To(Succeed())
+		_, err = controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		secret := &v1core.Secret{}
+		Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+		Expect(secret.Data).To(Equal(map[string][]byte{"app.yaml": []byte("app")}))
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		Expect(ocisecret.Status.Plan).To(BeNil())
+		Expect(meta.IsStatusConditionTrue(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeReady)).To(BeTrue())
+	})
+
+	It("should plan added, changed and removed keys without updating the target Secret in dry-run mode", func() {
+		artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"app.yaml": []byte("v1"), "old.yaml": []byte("old")})
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		synced := &v1core.Secret{}
+		Expect(k8sClient.Get(ctx, targetName, synced)).To(Succeed())
+
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		ocisecret.Spec.DryRun = true
+		Expect(k8sClient.Update(ctx, ocisecret)).To(Succeed())
+		digest := artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"app.yaml": []byte("v2"), "new.yaml": []byte("new")})
+		_, err = controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		secret := &v1core.Secret{}
+		Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+		Expect(secret.ResourceVersion).To(Equal(synced.ResourceVersion))
+		Expect(secret.Data).To(Equal(synced.Data))
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		Expect(ocisecret.Status.Plan).To(Equal(&ocisyncv1aplha1.SyncPlan{
+			Digest:      string(digest),
+			AddedKeys:   []string{"new.yaml"},
+			ChangedKeys: []string{"app.yaml"},
+			RemovedKeys: []string{"old.yaml"},
+		}))
+	})
+
+	It("should abandon reconciles that exceed the maximum duration", func() {
+		controllerReconciler.MaxReconcileDuration = time.Nanosecond
+		artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"app.yaml": []byte("v1")})
+
+		result, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(60 * time.Second))
+
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		condition := meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeReady)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Reason).To(Equal(ocisyncv1aplha1.ReasonTimedOut))
+	})
+
+	It("should requeue when the registry rate limit is reached", func() {
+		artifacts.SetError(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			&orasclient.RateLimitError{Host: "registry.example.com", RetryAfter: 42 * time.Second})
+
+		result, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(42 * time.Second))
+	})
+
+	It("should requeue after the Retry-After of a throttling registry", func() {
+		artifacts.SetError(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			&orasclient.ThrottledError{Host: "registry.example.com", RetryAfter: 90 * time.Second})
+		throttled := testutil.ToFloat64(throttledPulls.WithLabelValues("registry.example.com"))
+
+		result, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(90 * time.Second))
+		Expect(testutil.ToFloat64(throttledPulls.WithLabelValues("registry.example.com"))).To(Equal(throttled + 1))
+	})
+
+	It("should return pull errors", func() {
+		pullErr := errors.New("registry unavailable")
+		artifacts.SetError(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact, pullErr)
+
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).To(MatchError(pullErr))
+		Expect(artifacts.Pulls(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact)).To(BeZero())
+	})
+})
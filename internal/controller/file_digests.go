@@ -0,0 +1,30 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/opencontainers/go-digest"
+)
+
+// fileDigests returns the sha256 digest ("sha256:<hex>") of the content of every file.
+func fileDigests(files map[string][]byte) map[string]string {
+	digests := make(map[string]string, len(files))
+	for key, value := range files {
+		digests[key] = digest.FromBytes(value).String()
+	}
+	return digests
+}
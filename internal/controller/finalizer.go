@@ -0,0 +1,86 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+
+	v1core "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+)
+
+// cleanupFinalizer is the finalizer of the OCISecrets that don't set owner references on their target
+// Secrets, so the operator deletes the target Secrets instead of the garbage collector.
+const cleanupFinalizer = "oci-sync.brtrm.de/cleanup"
+
+// reconcileFinalizer adds the cleanupFinalizer to an OCISecret that doesn't set owner references and
+// removes it from one that does. When the OCISecret is being deleted, it deletes the target Secrets
+// before removing the finalizer and reports deleting, the caller must not sync the OCISecret then.
+func (r *OCISecretReconciler) reconcileFinalizer(ctx context.Context, ocisecret *ocisyncv1aplha1.OCISecret) (deleting bool, err error) {
+	if !ocisecret.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(ocisecret, cleanupFinalizer) {
+			return true, nil
+		}
+		if err := r.deleteTargets(ctx, ocisecret); err != nil {
+			return true, err
+		}
+		controllerutil.RemoveFinalizer(ocisecret, cleanupFinalizer)
+		return true, r.Update(ctx, ocisecret)
+	}
+
+	var changed bool
+	if setsOwnerReference(ocisecret) {
+		changed = controllerutil.RemoveFinalizer(ocisecret, cleanupFinalizer)
+	} else {
+		changed = controllerutil.AddFinalizer(ocisecret, cleanupFinalizer)
+	}
+	if !changed {
+		return false, nil
+	}
+	return false, r.Update(ctx, ocisecret)
+}
+
+// deleteTargets deletes the target Secrets of the OCISecret, including the additional targets and the
+// shard Secrets. Secrets that aren't managed by the OCISecret are left alone.
+func (r *OCISecretReconciler) deleteTargets(ctx context.Context, ocisecret *ocisyncv1aplha1.OCISecret) error {
+	for _, key := range r.targetSecretKeys(ocisecret) {
+		namespace, name, _ := strings.Cut(key, "/")
+		target := types.NamespacedName{Name: name, Namespace: namespace}
+		secret := &v1core.Secret{}
+		err := r.Get(ctx, target, secret)
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if !controlledBy(secret, ocisecret) {
+			continue
+		}
+		if err := r.deleteStaleShards(ctx, ocisecret, target, 0); err != nil {
+			return err
+		}
+		if err := r.Delete(ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	v1core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+)
+
+// checkKeyCount sets the TooManyKeys advisory condition of the OCISecret and emits a warning event
+// when the target Secret would contain more keys than KeyCountThreshold. The advisory never blocks
+// the sync; it only suggests splitting the artifact. A KeyCountThreshold of 0 disables the check.
+func (r *OCISecretReconciler) checkKeyCount(ctx context.Context, ocisecret *ocisyncv1aplha1.OCISecret, keyCount int) error {
+	if r.KeyCountThreshold <= 0 {
+		return nil
+	}
+
+	if keyCount > r.KeyCountThreshold {
+		message := fmt.Sprintf("TargetSecret has %d keys which exceeds the advisory threshold of %d; "+
+			"consider splitting the files across several Secrets to reduce kubelet load", keyCount, r.KeyCountThreshold)
+		r.Recorder.Event(ocisecret, v1core.EventTypeWarning, ocisyncv1aplha1.ReasonKeyCountExceeded, message)
+		return r.setCondition(ctx, ocisecret, ocisyncv1aplha1.ConditionTypeTooManyKeys, metav1.ConditionTrue,
+			ocisyncv1aplha1.ReasonKeyCountExceeded, message)
+	}
+
+	return r.setCondition(ctx, ocisecret, ocisyncv1aplha1.ConditionTypeTooManyKeys, metav1.ConditionFalse,
+		ocisyncv1aplha1.ReasonKeyCountWithinThreshold,
+		fmt.Sprintf("TargetSecret has %d keys, the advisory threshold is %d", keyCount, r.KeyCountThreshold))
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+)
+
+// newTestOCISecret returns a minimal valid OCISecret for tests that only exercise the API server.
+func newTestOCISecret(name string) *ocisyncv1aplha1.OCISecret {
+	return &ocisyncv1aplha1.OCISecret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: ocisyncv1aplha1.OCISecretSpec{
+			ArtefactRegistry: "registry.example.com/configs",
+			OrasArtefact:     "v1",
+			TargetSecret:     v1core.SecretReference{Name: name, Namespace: "default"},
+		},
+	}
+}
+
+var _ = Describe("Key count advisory", func() {
+	ctx := context.Background()
+	var ocisecret *ocisyncv1aplha1.OCISecret
+	var recorder *record.FakeRecorder
+	var controllerReconciler *OCISecretReconciler
+
+	BeforeEach(func() {
+		ocisecret = newTestOCISecret("key-count-advisory")
+		Expect(k8sClient.Create(ctx, ocisecret)).To(Succeed())
+
+		recorder = record.NewFakeRecorder(10)
+		controllerReconciler = &OCISecretReconciler{
+			Client:            k8sClient,
+			Scheme:            k8sClient.Scheme(),
+			Recorder:          recorder,
+			KeyCountThreshold: 3,
+		}
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.Delete(ctx, ocisecret)).To(Succeed())
+	})
+
+	It("should raise the advisory above the threshold", func() {
+		Expect(controllerReconciler.checkKeyCount(ctx, ocisecret, 4)).To(Succeed())
+
+		condition := meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeTooManyKeys)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(recorder.Events).To(Receive(ContainSubstring(ocisyncv1aplha1.ReasonKeyCountExceeded)))
+	})
+
+	It("should not raise the advisory at or below the threshold", func() {
+		Expect(controllerReconciler.checkKeyCount(ctx, ocisecret, 3)).To(Succeed())
+
+		condition := meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeTooManyKeys)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(recorder.Events).NotTo(Receive())
+	})
+
+	It("should do nothing when the advisory is disabled", func() {
+		controllerReconciler.KeyCountThreshold = 0
+		Expect(controllerReconciler.checkKeyCount(ctx, ocisecret, 1000)).To(Succeed())
+
+		Expect(ocisecret.Status.Conditions).To(BeEmpty())
+		Expect(recorder.Events).NotTo(Receive())
+	})
+})
@@ -0,0 +1,107 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+	"github.com/mariusbertram/oci-resource-sync-operator/internal/orasclient"
+)
+
+// errInvalidKeyName is returned when files have keys that aren't valid Secret keys and can't be sanitized.
+var errInvalidKeyName = errors.New("invalid Secret key")
+
+// invalidKeyCharacters matches the characters that aren't allowed in Secret keys.
+var invalidKeyCharacters = regexp.MustCompile(`[^-._a-zA-Z0-9]`)
+
+// checkKeyNames validates the keys of the files as Secret keys. With the sanitize policy the characters of
+// invalid keys that aren't allowed are replaced with "_" and the files, their paths and permission bits are
+// stored under the sanitized keys; it returns the sanitized keys by their original key. Keys that are still
+// invalid and files sanitized to the key of another file fail with errInvalidKeyName (wrapped).
+func checkKeyNames(content orasclient.Filemap, policy ocisyncv1aplha1.KeyNamePolicy) (orasclient.Filemap, map[string]string, error) {
+	var invalid []string
+	for key := range content.Files {
+		if len(validation.IsConfigMapKey(key)) > 0 {
+			invalid = append(invalid, key)
+		}
+	}
+	if len(invalid) == 0 {
+		return content, nil, nil
+	}
+	sort.Strings(invalid)
+	if policy != ocisyncv1aplha1.KeyNamePolicySanitize {
+		return orasclient.Filemap{}, nil, fmt.Errorf("%w: %s, set sync.keyNamePolicy to sanitize to replace the invalid characters",
+			errInvalidKeyName, describeInvalidKeys(invalid))
+	}
+
+	sanitized := make(map[string]string, len(invalid))
+	for _, key := range invalid {
+		newKey := invalidKeyCharacters.ReplaceAllString(key, "_")
+		if errs := validation.IsConfigMapKey(newKey); len(errs) > 0 {
+			return orasclient.Filemap{}, nil, fmt.Errorf("%w: %q sanitized from %q is not a valid Secret key: %s",
+				errInvalidKeyName, newKey, key, strings.Join(errs, ", "))
+		}
+		if _, exists := content.Files[newKey]; exists {
+			return orasclient.Filemap{}, nil, fmt.Errorf("%w: %q is sanitized to the key of the file %s",
+				errInvalidKeyName, key, newKey)
+		}
+		for other, otherKey := range sanitized {
+			if otherKey == newKey {
+				return orasclient.Filemap{}, nil, fmt.Errorf("%w: %q and %q are both sanitized to %s",
+					errInvalidKeyName, other, key, newKey)
+			}
+		}
+		sanitized[key] = newKey
+	}
+
+	renamed := content
+	renamed.Files = sanitizeKeys(content.Files, sanitized)
+	renamed.Paths = sanitizeKeys(content.Paths, sanitized)
+	renamed.Modes = sanitizeKeys(content.Modes, sanitized)
+	return renamed, sanitized, nil
+}
+
+// sanitizeKeys returns the map with the keys replaced by their sanitized keys. Nil maps stay nil.
+func sanitizeKeys[V any](values map[string]V, sanitized map[string]string) map[string]V {
+	if values == nil {
+		return nil
+	}
+	renamed := make(map[string]V, len(values))
+	for key, value := range values {
+		if newKey, ok := sanitized[key]; ok {
+			key = newKey
+		}
+		renamed[key] = value
+	}
+	return renamed
+}
+
+// describeInvalidKeys lists the invalid keys with the reasons they are rejected.
+func describeInvalidKeys(keys []string) string {
+	descriptions := make([]string, 0, len(keys))
+	for _, key := range keys {
+		descriptions = append(descriptions, fmt.Sprintf("%q (%s)", key, strings.Join(validation.IsConfigMapKey(key), ", ")))
+	}
+	return strings.Join(descriptions, "; ")
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"io/fs"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+	"github.com/mariusbertram/oci-resource-sync-operator/internal/orasclient"
+)
+
+var _ = Describe("Key names", func() {
+	It("should keep valid keys", func() {
+		content := orasclient.Filemap{Files: map[string][]byte{"app.yaml": []byte("app"), "tls_cert-1.pem": []byte("cert")}}
+		checked, sanitized, err := checkKeyNames(content, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sanitized).To(BeEmpty())
+		Expect(checked.Files).To(Equal(content.Files))
+	})
+
+	It("should reject invalid keys by default", func() {
+		content := orasclient.Filemap{Files: map[string][]byte{"app.yaml": []byte("app"), "my config.yaml": []byte("config")}}
+		_, _, err := checkKeyNames(content, "")
+		Expect(err).To(MatchError(errInvalidKeyName))
+		Expect(err.Error()).To(ContainSubstring(`"my config.yaml"`))
+		Expect(err.Error()).NotTo(ContainSubstring("app.yaml"))
+
+		_, _, err = checkKeyNames(content, ocisyncv1aplha1.KeyNamePolicyReject)
+		Expect(err).To(MatchError(errInvalidKeyName))
+	})
+
+	It("should sanitize invalid keys with the sanitize policy", func() {
+		checked, sanitized, err := checkKeyNames(orasclient.Filemap{
+			Files: map[string][]byte{"app.yaml": []byte("app"), "my config:v1.yaml": []byte("config")},
+			Paths: map[string]string{"my config:v1.yaml": "conf/my config:v1.yaml"},
+			Modes: map[string]fs.FileMode{"app.yaml": 0o644, "my config:v1.yaml": 0o600},
+		}, ocisyncv1aplha1.KeyNamePolicySanitize)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sanitized).To(Equal(map[string]string{"my config:v1.yaml": "my_config_v1.yaml"}))
+		Expect(checked.Files).To(Equal(map[string][]byte{"app.yaml": []byte("app"), "my_config_v1.yaml": []byte("config")}))
+		Expect(checked.Paths).To(Equal(map[string]string{"my_config_v1.yaml": "conf/my config:v1.yaml"}))
+		Expect(checked.Modes).To(Equal(map[string]fs.FileMode{"app.yaml": 0o644, "my_config_v1.yaml": 0o600}))
+	})
+
+	It("should refuse keys that can't be sanitized", func() {
+		_, _, err := checkKeyNames(orasclient.Filemap{Files: map[string][]byte{
+			"my config.yaml": []byte("a"), "my_config.yaml": []byte("b"),
+		}}, ocisyncv1aplha1.KeyNamePolicySanitize)
+		Expect(err).To(MatchError(errInvalidKeyName))
+		Expect(err.Error()).To(ContainSubstring("sanitized to the key of the file my_config.yaml"))
+
+		_, _, err = checkKeyNames(orasclient.Filemap{Files: map[string][]byte{
+			"a b.yaml": []byte("a"), "a:b.yaml": []byte("b"),
+		}}, ocisyncv1aplha1.KeyNamePolicySanitize)
+		Expect(err).To(MatchError(errInvalidKeyName))
+		Expect(err.Error()).To(ContainSubstring(`"a b.yaml" and "a:b.yaml" are both sanitized to a_b.yaml`))
+
+		_, _, err = checkKeyNames(orasclient.Filemap{Files: map[string][]byte{"..data": []byte("a")}},
+			ocisyncv1aplha1.KeyNamePolicySanitize)
+		Expect(err).To(MatchError(errInvalidKeyName))
+	})
+})
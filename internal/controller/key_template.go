@@ -0,0 +1,89 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/mariusbertram/oci-resource-sync-operator/internal/orasclient"
+)
+
+// errInvalidKeyTemplate is returned when the key template can't be parsed or renders invalid or
+// colliding Secret keys.
+var errInvalidKeyTemplate = errors.New("invalid key template")
+
+// keyTemplateData is the data the key template of Sync.KeyTemplate is rendered with.
+type keyTemplateData struct {
+	// Filename is the key of the file in the artifact
+	Filename string
+	// Tag is the tag (or digest reference) of the artifact
+	Tag string
+	// Digest is the digest of the artifact
+	Digest string
+	// Annotations are the annotations of the manifest
+	Annotations map[string]string
+}
+
+// renderKeys returns the content with its files, their paths and permission bits stored under the keys
+// rendered from the key template for every file. Missing fields or annotations fail like keys that aren't
+// valid Secret keys and files rendered to the same key, with errInvalidKeyTemplate (wrapped).
+func renderKeys(content orasclient.Filemap, keyTemplate string, data keyTemplateData) (orasclient.Filemap, error) {
+	tmpl, err := template.New("keyTemplate").Option("missingkey=error").Parse(keyTemplate)
+	if err != nil {
+		return orasclient.Filemap{}, fmt.Errorf("%w: %v", errInvalidKeyTemplate, err)
+	}
+
+	renamed := content
+	renamed.Files = make(map[string][]byte, len(content.Files))
+	if content.Paths != nil {
+		renamed.Paths = make(map[string]string, len(content.Paths))
+	}
+	if content.Modes != nil {
+		renamed.Modes = make(map[string]fs.FileMode, len(content.Modes))
+	}
+	renamedFrom := make(map[string]string, len(content.Files))
+	for filename, fileContent := range content.Files {
+		data.Filename = filename
+		var key strings.Builder
+		if err := tmpl.Execute(&key, data); err != nil {
+			return orasclient.Filemap{}, fmt.Errorf("%w: %v", errInvalidKeyTemplate, err)
+		}
+		if errs := validation.IsConfigMapKey(key.String()); len(errs) > 0 {
+			return orasclient.Filemap{}, fmt.Errorf("%w: %q rendered for %s is not a valid Secret key: %s",
+				errInvalidKeyTemplate, key.String(), filename, strings.Join(errs, ", "))
+		}
+		if other, ok := renamedFrom[key.String()]; ok {
+			return orasclient.Filemap{}, fmt.Errorf("%w: %s and %s are both rendered to %s", errInvalidKeyTemplate,
+				min(filename, other), max(filename, other), key.String())
+		}
+		renamedFrom[key.String()] = filename
+		renamed.Files[key.String()] = fileContent
+		if path, ok := content.Paths[filename]; ok {
+			renamed.Paths[key.String()] = path
+		}
+		if mode, ok := content.Modes[filename]; ok {
+			renamed.Modes[key.String()] = mode
+		}
+	}
+	return renamed, nil
+}
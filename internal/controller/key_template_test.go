@@ -0,0 +1,67 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"io/fs"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mariusbertram/oci-resource-sync-operator/internal/orasclient"
+)
+
+var _ = Describe("Key templates", func() {
+	content := orasclient.Filemap{Files: map[string][]byte{"app.yaml": []byte("app"), "db.yaml": []byte("db")}}
+	data := keyTemplateData{
+		Tag:         "v1.2.3",
+		Digest:      "sha256:1",
+		Annotations: map[string]string{"org.opencontainers.image.version": "1.2.3"},
+	}
+
+	It("should render the keys from the file name and the artifact", func() {
+		renamed, err := renderKeys(orasclient.Filemap{
+			Files: content.Files,
+			Paths: map[string]string{"app.yaml": "config/app.yaml"},
+			Modes: map[string]fs.FileMode{"app.yaml": 0o755, "db.yaml": 0o644},
+		}, "{{ .Tag }}-{{ .Filename }}", data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(renamed.Files).To(Equal(map[string][]byte{"v1.2.3-app.yaml": []byte("app"), "v1.2.3-db.yaml": []byte("db")}))
+		Expect(renamed.Paths).To(Equal(map[string]string{"v1.2.3-app.yaml": "config/app.yaml"}))
+		Expect(renamed.Modes).To(Equal(map[string]fs.FileMode{"v1.2.3-app.yaml": 0o755, "v1.2.3-db.yaml": 0o644}))
+
+		renamed, err = renderKeys(orasclient.Filemap{Files: map[string][]byte{"app.yaml": []byte("app")}},
+			`{{ index .Annotations "org.opencontainers.image.version" }}.{{ .Filename }}`, data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(renamed.Files).To(HaveKey("1.2.3.app.yaml"))
+	})
+
+	It("should refuse invalid and colliding keys", func() {
+		_, err := renderKeys(content, "config-{{ .Tag }}.yaml", data)
+		Expect(err).To(MatchError(errInvalidKeyTemplate))
+		Expect(err.Error()).To(ContainSubstring("app.yaml and db.yaml are both rendered to config-v1.2.3.yaml"))
+
+		_, err = renderKeys(content, "{{ .Digest }}/{{ .Filename }}", data)
+		Expect(err).To(MatchError(errInvalidKeyTemplate))
+
+		_, err = renderKeys(content, "{{ .Unknown }}", data)
+		Expect(err).To(MatchError(errInvalidKeyTemplate))
+
+		_, err = renderKeys(content, "{{ .Tag", data)
+		Expect(err).To(MatchError(errInvalidKeyTemplate))
+	})
+})
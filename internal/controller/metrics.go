@@ -0,0 +1,67 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// syncLatency is the time from first observing a new digest of an artifact until its target Secret was
+// synced to it, see OCISecretStatus.LastSyncLatency.
+var syncLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Name:    "ocisecret_digest_sync_latency_seconds",
+	Help:    "Time from first observing a new artifact digest until the target Secret was synced to it.",
+	Buckets: prometheus.ExponentialBuckets(1, 2, 14),
+})
+
+// syncState reports per OCISecret whether its last sync succeeded (0) or failed (1). Postponed syncs keep
+// the previous state and deleted OCISecrets are removed.
+var syncState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "ocisecret_sync_state",
+	Help: "Sync state of an OCISecret: 0 ok, 1 failing.",
+}, []string{"namespace", "name", "registry"})
+
+// recordSyncState updates the syncState of the OCISecret with the result of a reconcile.
+func recordSyncState(record *SyncRecord) {
+	labels := prometheus.Labels{"namespace": record.Namespace, "name": record.Name}
+	switch record.Result {
+	case SyncResultThrottled:
+		return
+	case SyncResultNotFound:
+		syncState.DeletePartialMatch(labels)
+		return
+	}
+	state := 0.0
+	if record.Result == SyncResultError {
+		state = 1
+	}
+	// Drop the series of a previous registry of the OCISecret
+	syncState.DeletePartialMatch(labels)
+	syncState.WithLabelValues(record.Namespace, record.Name, record.Registry).Set(state)
+}
+
+// throttledPulls counts the reconciles that were requeued because a registry host responded with 429 Too
+// Many Requests.
+var throttledPulls = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "ocisecret_registry_throttled_pulls_total",
+	Help: "Number of reconciles requeued because the registry host responded with 429 Too Many Requests.",
+}, []string{"host"})
+
+func init() {
+	metrics.Registry.MustRegister(syncLatency, driftedKeys, syncState, throttledPulls)
+}
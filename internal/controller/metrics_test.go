@@ -0,0 +1,50 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var _ = Describe("Sync state metric", func() {
+	record := func(registry, result string) *SyncRecord {
+		return &SyncRecord{Namespace: "default", Name: "metrics", Registry: registry, Result: result}
+	}
+
+	It("should report failing OCISecrets until they sync again", func() {
+		recordSyncState(record("ghcr.io/org/configs", SyncResultError))
+		Expect(testutil.ToFloat64(syncState.WithLabelValues("default", "metrics", "ghcr.io/org/configs"))).To(Equal(1.0))
+
+		// Postponed syncs keep the state
+		recordSyncState(record("ghcr.io/org/configs", SyncResultThrottled))
+		Expect(testutil.ToFloat64(syncState.WithLabelValues("default", "metrics", "ghcr.io/org/configs"))).To(Equal(1.0))
+
+		recordSyncState(record("ghcr.io/org/configs", SyncResultSynced))
+		Expect(testutil.ToFloat64(syncState.WithLabelValues("default", "metrics", "ghcr.io/org/configs"))).To(BeZero())
+	})
+
+	It("should drop the series of previous registries and deleted OCISecrets", func() {
+		recordSyncState(record("ghcr.io/org/configs", SyncResultSynced))
+		recordSyncState(record("registry.example.com/configs", SyncResultUnchanged))
+		Expect(testutil.CollectAndCount(syncState)).To(Equal(1))
+
+		recordSyncState(record("", SyncResultNotFound))
+		Expect(testutil.CollectAndCount(syncState)).To(BeZero())
+	})
+})
@@ -0,0 +1,72 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+	"github.com/mariusbertram/oci-resource-sync-operator/internal/orasclient"
+)
+
+// mirrorRepositories returns the repository of the OCISecret on each of its Mirrors, in order. It returns
+// orasclient.ErrInvalidReference (wrapped) for an invalid mirror host and errRegistryNotAllowed (wrapped)
+// for a mirror that isn't on the allowlist, so mirrors can't be used to bypass it.
+func mirrorRepositories(ocisecret *ocisyncv1aplha1.OCISecret, allowed []string) ([]string, error) {
+	repositories := make([]string, 0, len(ocisecret.Spec.Mirrors))
+	for _, mirror := range ocisecret.Spec.Mirrors {
+		repository, err := orasclient.MirrorRepository(ocisecret.Spec.ArtefactRegistry, mirror)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkRegistryAllowed(repository, allowed); err != nil {
+			return nil, err
+		}
+		repositories = append(repositories, repository)
+	}
+	return repositories, nil
+}
+
+// withMirrors calls pull with the repository and, as long as it fails because the registry is unavailable
+// (see orasclient.IsUnavailableError), with the mirror repositories in order. It returns the result and the
+// repository of the first successful call, or the error of the last call.
+func withMirrors[T any](ctx context.Context, repository string, mirrors []string,
+	pull func(repository string) (T, error)) (T, string, error) {
+	result, err := pull(repository)
+	for _, mirror := range mirrors {
+		if !orasclient.IsUnavailableError(err) {
+			break
+		}
+		log.FromContext(ctx).Info("The registry is unavailable, trying the next mirror.", "mirror", mirror,
+			"error", err.Error())
+		repository = mirror
+		result, err = pull(repository)
+	}
+	return result, repository, err
+}
+
+// mirrorHost returns the host of the mirror repository, or "" if the repository is the one of the OCISecret.
+func mirrorHost(ocisecret *ocisyncv1aplha1.OCISecret, repository string) string {
+	if repository == ocisecret.Spec.ArtefactRegistry {
+		return ""
+	}
+	host, _, _ := strings.Cut(repository, "/")
+	return host
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+	"github.com/mariusbertram/oci-resource-sync-operator/internal/orasclient"
+	"github.com/mariusbertram/oci-resource-sync-operator/internal/orasclient/fake"
+)
+
+var _ = Describe("Registry mirrors", func() {
+	ctx := context.Background()
+	var ocisecret *ocisyncv1aplha1.OCISecret
+	var artifacts *fake.Client
+	var controllerReconciler *OCISecretReconciler
+	var request reconcile.Request
+	targetName := types.NamespacedName{Name: "registry-mirrors", Namespace: "default"}
+	const mirrorRepository = "mirror.example.com:5000/configs"
+
+	BeforeEach(func() {
+		ocisecret = newTestOCISecret("registry-mirrors")
+		ocisecret.Spec.Mirrors = []string{"unavailable.example.com", "mirror.example.com:5000"}
+		request = reconcile.Request{NamespacedName: types.NamespacedName{Name: ocisecret.Name, Namespace: ocisecret.Namespace}}
+
+		artifacts = fake.NewClient()
+		artifacts.SetArtifact(mirrorRepository, ocisecret.Spec.OrasArtefact, map[string][]byte{"app.yaml": []byte("mirrored")})
+		artifacts.SetError("unavailable.example.com/configs", ocisecret.Spec.OrasArtefact,
+			fmt.Errorf("%w: connection refused", orasclient.ErrTransient))
+		controllerReconciler = &OCISecretReconciler{
+			Client:         k8sClient,
+			Scheme:         k8sClient.Scheme(),
+			Recorder:       record.NewFakeRecorder(10),
+			ArtifactClient: artifacts,
+		}
+	})
+
+	AfterEach(func() {
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, ocisecret))).To(Succeed())
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &v1core.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: targetName.Name, Namespace: targetName.Namespace}}))).To(Succeed())
+	})
+
+	readyReason := func() string {
+		Expect(k8sClient.Get(ctx, request.NamespacedName, ocisecret)).To(Succeed())
+		condition := meta.FindStatusCondition(ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeReady)
+		Expect(condition).NotTo(BeNil())
+		return condition.Reason
+	}
+
+	It("should pull from the next mirror while the registry is unavailable", func() {
+		artifacts.SetError(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			fmt.Errorf("%w: 503 Service Unavailable", orasclient.ErrTransient))
+		Expect(k8sClient.Create(ctx, ocisecret)).To(Succeed())
+
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		secret := &v1core.Secret{}
+		Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+		Expect(secret.Data).To(Equal(map[string][]byte{"app.yaml": []byte("mirrored")}))
+		Expect(readyReason()).To(Equal(ocisyncv1aplha1.ReasonSynced))
+		Expect(ocisecret.Status.Mirror).To(Equal("mirror.example.com:5000"))
+		Expect(artifacts.Pulls(mirrorRepository, ocisecret.Spec.OrasArtefact)).To(Equal(1))
+	})
+
+	It("should pull from the registry while it is available", func() {
+		artifacts.SetArtifact(ocisecret.Spec.ArtefactRegistry, ocisecret.Spec.OrasArtefact,
+			map[string][]byte{"app.yaml": []byte("primary")})
+		Expect(k8sClient.Create(ctx, ocisecret)).To(Succeed())
+
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		secret := &v1core.Secret{}
+		Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+		Expect(secret.Data).To(Equal(map[string][]byte{"app.yaml": []byte("primary")}))
+		Expect(readyReason()).To(Equal(ocisyncv1aplha1.ReasonSynced))
+		Expect(ocisecret.Status.Mirror).To(BeEmpty())
+	})
+
+	It("should not try the mirrors when the artifact doesn't exist", func() {
+		Expect(k8sClient.Create(ctx, ocisecret)).To(Succeed())
+
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(readyReason()).To(Equal(ocisyncv1aplha1.ReasonArtifactNotFound))
+		Expect(artifacts.Pulls(mirrorRepository, ocisecret.Spec.OrasArtefact)).To(BeZero())
+	})
+
+	It("should refuse mirrors that aren't on the allowlist", func() {
+		controllerReconciler.AllowedRegistries = []string{"registry.example.com"}
+		Expect(k8sClient.Create(ctx, ocisecret)).To(Succeed())
+
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(readyReason()).To(Equal(ocisyncv1aplha1.ReasonRegistryNotAllowed))
+	})
+
+	It("should report an invalid mirror", func() {
+		ocisecret.Spec.Mirrors = []string{"mirror.example.com/path"}
+		Expect(k8sClient.Create(ctx, ocisecret)).To(Succeed())
+
+		_, err := controllerReconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(readyReason()).To(Equal(ocisyncv1aplha1.ReasonInvalidReference))
+	})
+})
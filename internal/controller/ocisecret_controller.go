@@ -26,19 +26,29 @@ package controller
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
 	"github.com/mariusbertram/oci-resource-sync-operator/internal/orasclient"
 	"github.com/mariusbertram/oci-resource-sync-operator/internal/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"io"
 	v1core "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/utils/pointer"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"strings"
 	"time"
 )
 
@@ -57,11 +67,60 @@ type OCISecretReconciler struct {
 	client.Client
 	// Scheme provides runtime type information for API objects
 	Scheme *runtime.Scheme
+	// ArtifactClient retrieves the artefacts from the registries; nil uses orasclient.OrasClient
+	ArtifactClient orasclient.ArtifactClient
+	// Recorder emits Kubernetes events for the reconciled OCISecrets
+	Recorder record.EventRecorder
+	// RecordWriter receives one JSON SyncRecord line per completed reconcile; nil disables the records
+	RecordWriter io.Writer
+	// KeyCountThreshold is the number of keys above which an advisory is raised; 0 disables the advisory
+	KeyCountThreshold int
+	// RetryPolicy configures the retries of failed registry requests within a reconcile
+	RetryPolicy orasclient.RetryPolicy
+	// AllowedMediaTypes are the layer media types (path.Match patterns) artefacts may contain;
+	// empty allows every media type
+	AllowedMediaTypes []string
+	// FailureBackoffBase and FailureBackoffMax bound the jittered exponential requeue delay of failing
+	// reconciles; zero values keep the controller-runtime defaults and retry failed syncs at the poll interval
+	FailureBackoffBase time.Duration
+	FailureBackoffMax  time.Duration
+	// MaxConcurrentReconciles is the number of OCISecrets reconciled in parallel; zero keeps the
+	// controller-runtime default of one
+	MaxConcurrentReconciles int
+	// UserAgent is sent to the registries unless the OCISecret sets one; empty uses orasclient.DefaultUserAgent
+	UserAgent string
+	// AuthScopes are requested with the bearer tokens of all registries in addition to the scopes of
+	// the OCISecrets, see orasclient.ClientOptions.Scopes
+	AuthScopes []string
+	// AllowedRegistries are the patterns of the registries OCISecrets may pull from, see
+	// checkRegistryAllowed; empty allows all registries
+	AllowedRegistries []string
+	// Health tracks the results of the reconciles for the sync health check; nil disables the tracking
+	Health *SyncHealth
+	// failureBackoff delays the requeue of OCISecrets whose syncs keep failing; nil requeues them at
+	// the regular poll interval
+	failureBackoff *failureBackoff
+	// targetSecretIndexed is set once the targetSecretIndex is registered with the manager
+	targetSecretIndexed bool
+	// DefaultNamespace is used for Secret references of cluster-scoped OCISecrets without a namespace,
+	// except for the credential Secrets (see resolveNamespaces); empty requires the namespace to be set
+	DefaultNamespace string
+	// MaxReconcileDuration abandons a reconcile that runs longer, e.g. while pulling a huge artefact, so it
+	// doesn't block a worker; zero doesn't limit the reconciles
+	MaxReconcileDuration time.Duration
+	// AuditOnly only compares the target Secrets with the artefacts and reports the drift (see
+	// reportDrift) instead of writing them, including Secrets the OCISecrets don't manage yet
+	AuditOnly bool
+	// MemoryThreshold is the announced artefact size up to which the layers are fetched into memory
+	// instead of a temporary directory, see orasclient.PullOptions.MemoryThreshold
+	MemoryThreshold int64
 }
 
 // +kubebuilder:rbac:groups=oci-sync.brtrm.de,resources=ocisecrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=oci-sync.brtrm.de,resources=ocisecrets/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=oci-sync.brtrm.de,resources=ocisecrets/finalizers,verbs=update
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -73,9 +132,94 @@ type OCISecretReconciler struct {
 // 4. Create or update the target Secret with the artifact contents
 // 5. Schedule the next reconciliation
 //
+// If a RecordWriter is configured, a SyncRecord describing the outcome is written
+// exactly once per call.
+//
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.19.0/pkg/reconcile
 func (r *OCISecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	start := time.Now()
+	record := &SyncRecord{
+		Name:      req.Name,
+		Namespace: req.Namespace,
+		Result:    SyncResultUnchanged,
+	}
+	ctx, span := tracer.Start(ctx, "reconcile", trace.WithAttributes(
+		attribute.String("namespace", req.Namespace), attribute.String("name", req.Name)))
+
+	reconcileCtx := ctx
+	if r.MaxReconcileDuration > 0 {
+		var cancel context.CancelFunc
+		reconcileCtx, cancel = context.WithTimeout(ctx, r.MaxReconcileDuration)
+		defer cancel()
+	}
+	result, err := r.reconcile(reconcileCtx, req, record)
+	if err != nil && errors.Is(reconcileCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		// The reconcile took too long, report it on the OCISecret with the context of the Reconcile call
+		// and requeue like other failed syncs
+		result, err = r.abandonReconcile(ctx, req, record)
+	}
+	var rateLimitErr *orasclient.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		// The registry budget of the operator is used up, try again once it allows another request
+		log.FromContext(ctx).Info("Registry rate limit reached, requeueing.", "host", rateLimitErr.Host,
+			"retryAfter", rateLimitErr.RetryAfter)
+		record.Result = SyncResultThrottled
+		result, err = ctrl.Result{RequeueAfter: rateLimitErr.RetryAfter}, nil
+	}
+	var throttledErr *orasclient.ThrottledError
+	if errors.As(err, &throttledErr) {
+		// The registry rejected the request with 429, come back when its Retry-After allows it
+		log.FromContext(ctx).Info("Registry throttled the request, requeueing.", "host", throttledErr.Host,
+			"retryAfter", throttledErr.RetryAfter)
+		throttledPulls.WithLabelValues(throttledErr.Host).Inc()
+		record.Result = SyncResultThrottled
+		result, err = ctrl.Result{RequeueAfter: throttledErr.RetryAfter}, nil
+	}
+	var circuitErr *orasclient.CircuitOpenError
+	if errors.As(err, &circuitErr) {
+		// The registry keeps failing, don't add to its load until the circuit breaker lets a probe through
+		log.FromContext(ctx).Info("Registry circuit breaker open, requeueing.", "host", circuitErr.Host,
+			"retryAfter", circuitErr.RetryAfter)
+		record.Result = SyncResultThrottled
+		result, err = ctrl.Result{RequeueAfter: circuitErr.RetryAfter}, nil
+	}
+	if err != nil {
+		record.Result = SyncResultError
+		record.Error = err.Error()
+	}
+	if r.Health != nil {
+		r.Health.recordResult(req.NamespacedName, record.Result)
+	}
+	recordSyncState(record)
+	if r.failureBackoff != nil {
+		switch {
+		case record.Result == SyncResultError && err == nil:
+			// failSync requeues at the poll interval, back off instead while the OCISecret keeps failing;
+			// returned errors are backed off by the rate limiter of the workqueue
+			result.RequeueAfter = r.failureBackoff.next(req.NamespacedName)
+		case record.Result != SyncResultError && record.Result != SyncResultThrottled:
+			r.failureBackoff.reset(req.NamespacedName)
+		}
+	}
+
+	if r.RecordWriter != nil {
+		record.Time = time.Now().UTC()
+		record.DurationMs = time.Since(start).Milliseconds()
+		if writeErr := writeSyncRecord(r.RecordWriter, record); writeErr != nil {
+			log.FromContext(ctx).Error(writeErr, "Failed to write sync record.")
+		}
+	}
+
+	span.SetAttributes(attribute.String("registry", record.Registry), attribute.String("tag", record.Reference),
+		attribute.String("digest", record.Digest), attribute.String("result", record.Result))
+	endSpan(span, err)
+	return result, err
+}
+
+// reconcile contains the actual reconciliation steps described on Reconcile.
+// It fills in the given record as it progresses.
+func (r *OCISecretReconciler) reconcile(ctx context.Context, req ctrl.Request, record *SyncRecord) (ctrl.Result, error) {
 	// Get a logger from the context
 	logger := log.FromContext(ctx)
 
@@ -86,18 +230,106 @@ func (r *OCISecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		if apierrors.IsNotFound(err) {
 			// The OCISecret resource has been deleted, nothing to do
 			logger.Info("OCISecret resource not found.")
+			driftedKeys.DeleteLabelValues(req.Namespace, req.Name)
+			record.Result = SyncResultNotFound
 			return ctrl.Result{}, nil
 		}
 		// Error reading the object
 		logger.Error(err, "Failed to get OCISecret.")
 		return ctrl.Result{}, err
 	}
+	// Without owner references the target Secrets are deleted by the operator before the OCISecret
+	if deleting, err := r.reconcileFinalizer(ctx, OCIsecret); err != nil {
+		logger.Error(err, "Failed to clean up the target Secrets.")
+		return ctrl.Result{}, err
+	} else if deleting {
+		record.Result = SyncResultNotFound
+		return ctrl.Result{}, nil
+	}
+
+	// The ArtifactRef replaces the split registry and tag for the rest of the reconcile; the OCISecret isn't
+	// updated after this, only its status
+	if err := resolveArtifactRef(OCIsecret); err != nil {
+		record.Reference = OCIsecret.Spec.ArtifactRef
+		return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonInvalidReference, err)
+	}
+	record.Registry = OCIsecret.Spec.ArtefactRegistry
+	record.Reference = OCIsecret.Spec.OrasArtefact
+
+	// Add the artefact to every log line of this reconcile, including the ones of the helpers that
+	// take the logger from the context, so the logs can be filtered by resource
+	logger = logger.WithValues("registry", OCIsecret.Spec.ArtefactRegistry, "tag", OCIsecret.Spec.OrasArtefact)
+	ctx = log.IntoContext(ctx, logger)
+
+	// Leave the target Secrets alone while suspended; unsuspending changes the generation and
+	// triggers a reconcile, so no requeue is needed
+	if err := r.updateSuspendedCondition(ctx, OCIsecret); err != nil {
+		logger.Error(err, "Failed to update OCISecret status.")
+		return ctrl.Result{}, err
+	}
+	if OCIsecret.Spec.Suspend {
+		logger.Info("OCISecret is suspended, skipping the sync.")
+		record.Result = SyncResultSuspended
+		return ctrl.Result{}, nil
+	}
+
+	// Default the namespaces of the referenced Secrets
+	if err := resolveNamespaces(OCIsecret, r.DefaultNamespace); errors.Is(err, errCrossNamespaceReference) {
+		return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonCrossNamespaceReference, err)
+	} else if err != nil {
+		return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonMissingNamespace, err)
+	}
+	logger = logger.WithValues("targetSecret", types.NamespacedName{
+		Name: OCIsecret.Spec.TargetSecret.Name, Namespace: OCIsecret.Spec.TargetSecret.Namespace})
+	ctx = log.IntoContext(ctx, logger)
+
+	// Only pull from the registries the operator is allowed to access, before any credentials are sent
+	if err := checkRegistryAllowed(OCIsecret.Spec.ArtefactRegistry, r.AllowedRegistries); err != nil {
+		return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonRegistryNotAllowed, err)
+	}
+
+	// A malformed reference can't be fixed by retrying, report it instead of contacting the registry
+	if _, err := orasclient.ParseArtifactReference(OCIsecret.Spec.ArtefactRegistry, OCIsecret.Spec.OrasArtefact); err != nil {
+		return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonInvalidReference, err)
+	}
+	mirrors, err := mirrorRepositories(OCIsecret, r.AllowedRegistries)
+	if errors.Is(err, errRegistryNotAllowed) {
+		return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonRegistryNotAllowed, err)
+	} else if err != nil {
+		return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonInvalidReference, err)
+	}
+
+	// Stop syncing while other OCISecrets write the same Secrets, they would overwrite each other
+	conflicts, err := r.findTargetConflicts(ctx, OCIsecret)
+	if err != nil {
+		logger.Error(err, "Failed to list OCISecrets.")
+		return ctrl.Result{}, err
+	}
+	if err := r.updateConflictCondition(ctx, OCIsecret, conflicts); err != nil {
+		logger.Error(err, "Failed to update OCISecret status.")
+		return ctrl.Result{}, err
+	}
+	if len(conflicts) > 0 {
+		return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonTargetConflict,
+			fmt.Errorf("the target Secrets are also written by the OCISecrets %s", strings.Join(conflicts, ", ")))
+	}
+
+	// Never overwrite Secrets the OCISecret doesn't manage; in audit-only mode nothing is written, so
+	// Secrets that are still managed by hand can be compared before the operator takes them over
+	if !r.AuditOnly {
+		if err := r.checkTargetConflicts(ctx, OCIsecret); errors.Is(err, errTargetConflict) {
+			return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonTargetConflict, err)
+		} else if err != nil {
+			logger.Error(err, "Failed to get target Secrets.")
+			return ctrl.Result{}, err
+		}
+	}
 
 	// Step 2: Get the pull secret for OCI registry authentication (if specified)
 	var secretData string
 	OCIPullSecret := &v1core.Secret{}
 
-	if OCIsecret.Spec.ArtefactPullSecret.Name == "" || OCIsecret.Spec.ArtefactPullSecret.Namespace == "" {
+	if OCIsecret.Spec.ArtefactPullSecret.Name == "" {
 		// No pull secret specified, will use anonymous access to the registry
 		logger.Info("No ArtefactPullSecret specified.")
 	} else {
@@ -120,22 +352,132 @@ func (r *OCISecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		}
 
 		// Extract the Docker config JSON from the pull secret
-		for key, value := range OCIPullSecret.Data {
-			if key == ".dockerconfigjson" {
-				secretData = string(value)
-			}
+		config, err := pullSecretCredentials(OCIPullSecret)
+		if errors.Is(err, errUnsupportedPullSecretType) {
+			return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonUnsupportedPullSecretType, err)
+		} else if err != nil {
+			// The pull secret doesn't contain Docker config JSON
+			return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonInvalidPullSecret, err)
 		}
+		secretData = string(config)
+	}
 
-		if secretData == "" {
-			// The pull secret doesn't contain Docker config JSON
-			logger.Info("No PullSecret Data found.")
-			return ctrl.Result{}, nil
+	clientOptions := orasclient.ClientOptions{
+		Credentials: []byte(secretData),
+		Retry:       r.RetryPolicy,
+		ProxyURL:    OCIsecret.Spec.Proxy,
+		Logger:      logger,
+		UserAgent:   r.UserAgent,
+	}
+	if OCIsecret.Spec.UserAgent != "" {
+		clientOptions.UserAgent = OCIsecret.Spec.UserAgent
+	}
+	if len(r.AuthScopes) > 0 || len(OCIsecret.Spec.AuthScopes) > 0 {
+		clientOptions.Scopes = append(append([]string{}, r.AuthScopes...), OCIsecret.Spec.AuthScopes...)
+	}
+	// A new value of the force-sync annotation re-syncs the artefact even if its digest didn't change
+	forceSync := OCIsecret.Annotations[ocisyncv1aplha1.ForceSyncAnnotation]
+	forced := forceSync != "" && forceSync != OCIsecret.Status.LastForceSync
+	clientOptions.NoCache = forced
+	// The target Secrets are rewritten at the ForceResyncInterval even if the digest didn't change
+	resync := resyncDue(OCIsecret, time.Now())
+
+	// Only one source of credentials may be configured
+	credentialSources := 0
+	for _, configured := range []bool{OCIsecret.Spec.ArtefactPullSecret.Name != "",
+		OCIsecret.Spec.BasicAuthSecretRef != nil, OCIsecret.Spec.CredentialProvider != ""} {
+		if configured {
+			credentialSources++
+		}
+	}
+	if credentialSources > 1 {
+		return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonInvalidPullSecret,
+			errors.New("only one of ArtefactPullSecret, basicAuthSecretRef and credentialProvider may be set"))
+	}
+
+	// Authenticate with the username and password of a basic-auth Secret instead of a Docker config
+	if OCIsecret.Spec.BasicAuthSecretRef != nil {
+		clientOptions.Username, clientOptions.Password, err = r.loadBasicAuth(ctx, OCIsecret.Spec.BasicAuthSecretRef)
+		if errors.Is(err, errInvalidBasicAuth) {
+			return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonInvalidPullSecret, err)
+		} else if err != nil {
+			logger.Error(err, "Failed to get BasicAuthSecret.")
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Obtain short-lived credentials from the cloud provider, they are refreshed on every reconcile
+	if OCIsecret.Spec.CredentialProvider != "" {
+		clientOptions.CredentialProvider, err = orasclient.NewCredentialProvider(OCIsecret.Spec.CredentialProvider)
+		if err != nil {
+			return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonInvalidPullSecret, err)
+		}
+	}
+
+	// Load the client certificate for registries that require mutual TLS
+	if OCIsecret.Spec.ClientCertSecretRef != nil {
+		clientOptions.ClientCertificate, clientOptions.CACertificates, err = r.loadClientCertificate(ctx, OCIsecret.Spec.ClientCertSecretRef)
+		if errors.Is(err, errInvalidClientCertificate) {
+			return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonInvalidClientCertificate, err)
+		} else if err != nil {
+			logger.Error(err, "Failed to get ClientCertSecret.")
+			return ctrl.Result{}, err
 		}
 	}
 
 	// Step 3: Get the digest of the OCI artifact to detect changes
 	// This will be used to determine if the target Secret needs to be updated
-	currentDigest := orasclient.GetDigest(OCIsecret.Spec.ArtefactRegistry, OCIsecret.Spec.OrasArtefact, []byte(secretData))
+	authMode := ocisyncv1aplha1.AuthModeAnonymous
+	if secretData != "" || clientOptions.Username != "" {
+		authMode = ocisyncv1aplha1.AuthModePullSecret
+	} else if clientOptions.CredentialProvider != nil {
+		authMode = ocisyncv1aplha1.AuthModeCredentialProvider
+	}
+	// The mirrors are tried while the registry is unavailable; the rest of the reconcile pulls from the
+	// registry or mirror that resolved the digest
+	getDigest := func(repository string) (string, error) {
+		return r.artifactClient().GetDigest(ctx, repository, OCIsecret.Spec.OrasArtefact, clientOptions)
+	}
+	currentDigest, repository, err := withMirrors(ctx, OCIsecret.Spec.ArtefactRegistry, mirrors, getDigest)
+	if err != nil && OCIsecret.Spec.AnonymousFallback && authMode != ocisyncv1aplha1.AuthModeAnonymous && orasclient.IsAuthError(err) {
+		// The registry rejected the credentials, continue anonymously for the rest of this reconcile
+		message := fmt.Sprintf("The registry rejected the credentials, retrying anonymously: %s", err)
+		logger.Info(message)
+		r.Recorder.Event(OCIsecret, v1core.EventTypeWarning, ocisyncv1aplha1.ReasonAnonymousFallback, message)
+		clientOptions.Credentials = nil
+		clientOptions.Username = ""
+		clientOptions.Password = ""
+		clientOptions.CredentialProvider = nil
+		authMode = ocisyncv1aplha1.AuthModeAnonymousFallback
+		currentDigest, repository, err = withMirrors(ctx, OCIsecret.Spec.ArtefactRegistry, mirrors, getDigest)
+	}
+	if errors.Is(err, orasclient.ErrAuth) {
+		// Retrying quickly won't help until the credentials change, report it and check again on the next poll
+		return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonAuthenticationFailed, err)
+	} else if errors.Is(err, orasclient.ErrNotFound) {
+		return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonArtifactNotFound, err)
+	} else if errors.Is(err, orasclient.ErrPinnedTagMismatch) {
+		return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonDigestMismatch, err)
+	} else if err != nil {
+		// Transient and unknown errors are retried with the failure backoff
+		logger.Error(err, "Failed to get artefact digest.")
+		return ctrl.Result{}, err
+	}
+	record.Digest = currentDigest
+	mirror := mirrorHost(OCIsecret, repository)
+	logger = logger.WithValues("digest", currentDigest, "authMode", authMode)
+	if mirror != "" {
+		logger = logger.WithValues("mirror", mirror)
+	}
+	ctx = log.IntoContext(ctx, logger)
+	// Don't download an artefact that isn't the expected one
+	if err := checkExpectedDigest(OCIsecret, currentDigest); err != nil {
+		return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonDigestMismatch, err)
+	}
+	if err := r.observeDigest(ctx, OCIsecret, currentDigest); err != nil {
+		logger.Error(err, "Failed to update OCISecret status.")
+		return ctrl.Result{}, err
+	}
 
 	// Step 4a: Check if the target Secret exists, create it if it doesn't
 	TargetSecret := &v1core.Secret{}
@@ -148,32 +490,17 @@ func (r *OCISecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 
 	// Try to get the target Secret
 	err = r.Get(ctx, TargetSecretReq.NamespacedName, TargetSecret)
-	if err != nil && apierrors.IsNotFound(err) {
+	readOnly := OCIsecret.Spec.DryRun || r.AuditOnly
+	if err != nil && apierrors.IsNotFound(err) && readOnly {
+		// In dry-run and audit-only mode the target Secret is never created, compare with an empty Secret
+		logger.Info("TargetSecret doesn't exist, not creating it in dry-run or audit-only mode.")
+	} else if err != nil && apierrors.IsNotFound(err) {
 		// Target Secret doesn't exist, create it
 		// Initialize with a placeholder revision annotation that will be updated later
-		TargetSecret := &v1core.Secret{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      OCIsecret.Spec.TargetSecret.Name,
-				Namespace: OCIsecret.Spec.TargetSecret.Namespace,
-				Annotations: map[string]string{
-					"OCISecret.operator.rev": "00000", // Initial placeholder revision
-				},
-				// Set owner reference to the OCISecret so the Secret is deleted when the OCISecret is deleted
-				OwnerReferences: []metav1.OwnerReference{
-					{
-						APIVersion:         OCIsecret.APIVersion,
-						Kind:               OCIsecret.Kind,
-						Name:               OCIsecret.Name,
-						UID:                OCIsecret.UID,
-						Controller:         pointer.Bool(true),
-						BlockOwnerDeletion: pointer.Bool(true),
-					},
-				},
-			},
-		}
+		TargetSecret := newTargetSecret(OCIsecret, OCIsecret.Spec.TargetSecret.Name, OCIsecret.Spec.TargetSecret.Namespace)
 
 		// Create the target Secret
-		err = r.Create(ctx, TargetSecret)
+		err = r.Patch(ctx, TargetSecret, client.Apply, client.FieldOwner(fieldManager), client.ForceOwnership)
 		if err != nil {
 			logger.Error(err, "Failed to create TargetSecret.")
 		} else {
@@ -189,44 +516,406 @@ func (r *OCISecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	// Step 4b: Update the target Secret if needed
 	// Refresh our view of the target Secret to ensure we have the latest version
 	err = r.Get(ctx, TargetSecretReq.NamespacedName, TargetSecret)
-	if err != nil {
+	if err != nil && !(apierrors.IsNotFound(err) && readOnly) {
 		logger.Error(err, "Failed to get TargetSecret.")
 		return ctrl.Result{}, err
 	}
 
 	// Check if the target Secret needs to be updated:
 	// - If the digest has changed (content in the OCI registry has changed)
-	// - If the number of files to sync has changed
-	if TargetSecret.Annotations["OCISecret.operator.rev"] != currentDigest || len(TargetSecret.Data) != len(OCIsecret.Spec.Sync.Files) {
-		logger.Info("TargetSecret needs to be updated.")
+	// - If the spec has changed since the last successful sync
+	// - If an additional target Secret is missing or outdated
+	// - If a sync is forced with the force-sync annotation
+	// - If a resync is due according to ForceResyncInterval
+	// - Always in audit-only mode, to compare the target Secrets with the artefact
+	additionalOutdated, err := r.additionalTargetsOutdated(ctx, OCIsecret, currentDigest)
+	if err != nil {
+		logger.Error(err, "Failed to get additional target Secrets.")
+		return ctrl.Result{}, err
+	}
+	// The digests of the files written to the target Secret; nil if it isn't updated
+	var syncedDigests map[string]string
+	// The digest of the artefact written to the target Secret; empty if it isn't updated
+	var syncedDigest string
+	revision, _ := secretRevision(TargetSecret)
+	if revision != currentDigest || OCIsecret.Status.ObservedGeneration != OCIsecret.Generation || additionalOutdated || forced || resync || r.AuditOnly {
+		logger.Info("TargetSecret needs to be updated.", "forceSync", forced, "resync", resync)
 
 		// Download the files from the OCI registry
-		content := orasclient.GetFiles(OCIsecret.Spec.ArtefactRegistry, OCIsecret.Spec.OrasArtefact, []byte(secretData))
+		pullStart := time.Now()
+		pullOptions := orasclient.PullOptions{
+			AllowedMediaTypes: r.AllowedMediaTypes,
+			ExtractArchives:   OCIsecret.Spec.ExtractArchives,
+			LayerTitles:       OCIsecret.Spec.Sync.LayerTitles,
+			MediaTypes:        OCIsecret.Spec.Sync.MediaTypes,
+			Platform:          OCIsecret.Spec.Platform,
+			StripPrefix:       OCIsecret.Spec.Sync.StripPrefix,
+			MaxFileCount:      int(OCIsecret.Spec.MaxFileCount),
+			IncludeConfig:     OCIsecret.Spec.IncludeConfig,
+			MemoryThreshold:   r.MemoryThreshold,
+		}
+		pullOptions.Gate.MinVersion = OCIsecret.Spec.MinVersion
+		if notBefore := OCIsecret.Spec.NotBefore; notBefore != nil {
+			pullOptions.Gate.NotBefore = notBefore.Time
+		}
+		if OCIsecret.Spec.MaxArtifactSize != nil {
+			pullOptions.MaxArtifactSize = OCIsecret.Spec.MaxArtifactSize.Value()
+		}
+		if OCIsecret.Spec.KeyLayout != nil {
+			pullOptions.PathSeparator = OCIsecret.Spec.KeyLayout.Separator
+		}
+		if OCIsecret.Spec.Decompress != nil {
+			pullOptions.Decompress = true
+			pullOptions.DecompressFiles = OCIsecret.Spec.Decompress.Files
+		}
+		content, err := r.artifactClient().GetFiles(ctx, repository, OCIsecret.Spec.OrasArtefact, clientOptions, pullOptions)
+		record.PullDurationMs = time.Since(pullStart).Milliseconds()
+		if err != nil && ctx.Err() != nil {
+			// The operator is shutting down, the partially downloaded artefact is dropped before anything is written
+			logger.Info("Download cancelled.")
+			return ctrl.Result{}, ctx.Err()
+		} else if errors.Is(err, orasclient.ErrArtifactTooLarge) {
+			// An oversized artefact won't shrink by retrying quickly, report it and check again on the next poll
+			return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonArtifactTooLarge, err)
+		} else if errors.Is(err, orasclient.ErrTooManyFiles) {
+			return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonTooManyFiles, err)
+		} else if errors.Is(err, orasclient.ErrDisallowedMediaType) {
+			return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonDisallowedMediaType, err)
+		} else if errors.Is(err, orasclient.ErrUnsupportedManifest) {
+			return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonUnsupportedManifest, err)
+		} else if errors.Is(err, orasclient.ErrLayerNotFound) {
+			return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonLayerNotFound, err)
+		} else if errors.Is(err, orasclient.ErrNoMatchingMediaType) {
+			return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonNoMatchingMediaType, err)
+		} else if errors.Is(err, orasclient.ErrPlatformNotFound) {
+			return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonPlatformNotFound, err)
+		} else if errors.Is(err, orasclient.ErrConflictingFiles) {
+			return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonConflictingFiles, err)
+		} else if errors.Is(err, orasclient.ErrUnsafeArchiveEntry) {
+			return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonUnsafeArchive, err)
+		} else if errors.Is(err, orasclient.ErrAuth) {
+			return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonAuthenticationFailed, err)
+		} else if errors.Is(err, orasclient.ErrNotFound) {
+			return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonArtifactNotFound, err)
+		} else if errors.Is(err, orasclient.ErrPinnedTagMismatch) {
+			return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonDigestMismatch, err)
+		} else if errors.Is(err, orasclient.ErrInvalidGate) {
+			return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonInvalidGate, err)
+		} else if errors.Is(err, orasclient.ErrArtifactGated) {
+			// Keep the content of the last artefact that met the gate and check again on the next poll
+			return r.skipGatedSync(ctx, OCIsecret, record, currentDigest, err)
+		} else if err != nil {
+			logger.Error(err, "Failed to get artefact files.")
+			return ctrl.Result{}, err
+		}
+
+		if err := r.updateSyncSkippedCondition(ctx, OCIsecret, nil); err != nil {
+			logger.Error(err, "Failed to update OCISecret status.")
+			return ctrl.Result{}, err
+		}
+
+		// The tag may have moved since the digest was checked, so check the downloaded artefact again
+		if err := checkExpectedDigest(OCIsecret, string(content.Digest)); err != nil {
+			return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonDigestMismatch, err)
+		}
+
+		// Verify the signature of the artifact before accepting its content
+		if OCIsecret.Spec.Verification != nil {
+			err = r.artifactClient().VerifySignature(ctx, repository, content.Digest, []byte(OCIsecret.Spec.Verification.PublicKey), clientOptions)
+			if errors.Is(err, orasclient.ErrSignatureInvalid) {
+				// Keep the current content of the target Secret and check again on the next poll
+				return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonSignatureInvalid, err)
+			} else if err != nil {
+				logger.Error(err, "Failed to verify artefact signature.")
+				return ctrl.Result{}, err
+			}
+		}
+
+		// Keep all files for the additional targets, which select their files on their own
+		artefactFiles := make(map[string][]byte, len(content.Files))
+		for key, value := range content.Files {
+			artefactFiles[key] = value
+		}
+		// Warn about artefacts without files, the target Secret would be left without keys
+		if err := r.updateEmptyArtifactCondition(ctx, OCIsecret, len(artefactFiles)); err != nil {
+			logger.Error(err, "Failed to update OCISecret status.")
+			return ctrl.Result{}, err
+		}
+
+		// Report the entries of Sync.Files that match no file, they would silently be missing in the Secret
+		missingFiles := utils.UnmatchedPatterns(content.Files, OCIsecret.Spec.Sync.Files)
+		if err := r.updateMissingFilesCondition(ctx, OCIsecret, missingFiles); err != nil {
+			logger.Error(err, "Failed to update OCISecret status.")
+			return ctrl.Result{}, err
+		}
+		if len(missingFiles) > 0 && OCIsecret.Spec.RequireAllFiles {
+			return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonFilesMissing,
+				fmt.Errorf("the artefact contains no file matching %s", strings.Join(missingFiles, ", ")))
+		}
 
 		// Filter the files based on the OCISecret specification
+		var filteredFiles []string
 		if len(OCIsecret.Spec.Sync.Files) > 0 {
 			// Only keep files that are specified in the OCISecret.Spec.Sync.Files list
-			utils.FilterMapInPlace(content.Files, OCIsecret.Spec.Sync.Files)
+			filteredFiles = utils.FilterMapInPlace(content.Files, OCIsecret.Spec.Sync.Files)
+		}
+		// Drop the files that are specified in the OCISecret.Spec.Sync.ExcludeFiles list
+		filteredFiles = append(filteredFiles, utils.FilterMapExcludeInPlace(content.Files, OCIsecret.Spec.Sync.ExcludeFiles)...)
+		// Make the effect of the file selection visible, it is easily misjudged with glob patterns
+		if len(filteredFiles) > 0 && len(content.Files) > 0 {
+			message := fmt.Sprintf("Kept %d of %d files of the artefact", len(content.Files), len(artefactFiles))
+			logger.Info(message, "filteredFiles", filteredFiles)
+			r.Recorder.Event(OCIsecret, v1core.EventTypeNormal, ocisyncv1aplha1.ReasonFilesFiltered, message)
+		}
+		if len(content.Files) == 0 && len(artefactFiles) > 0 {
+			// Syncing an empty Secret is allowed, but most likely the patterns are wrong
+			message := "The file selection of the OCISecret matches no file of the artefact"
+			logger.Info(message, "files", OCIsecret.Spec.Sync.Files, "excludeFiles", OCIsecret.Spec.Sync.ExcludeFiles)
+			r.Recorder.Event(OCIsecret, v1core.EventTypeWarning, ocisyncv1aplha1.ReasonNoFilesMatched, message)
+		}
+		if len(content.Files) == 0 && OCIsecret.Spec.FailOnEmpty {
+			reason := ocisyncv1aplha1.ReasonEmptyArtifact
+			if len(artefactFiles) > 0 {
+				reason = ocisyncv1aplha1.ReasonNoFilesMatched
+			}
+			return r.failSync(ctx, OCIsecret, record, reason,
+				errors.New("no files left to sync, the target Secret is left unchanged because failOnEmpty is set"))
 		}
 
-		// Update the target Secret with the downloaded files
-		TargetSecret.Data = content.Files
-		// Update the revision annotation to track the current digest
-		TargetSecret.Annotations["OCISecret.operator.rev"] = string(content.Digest)
+		// Transform the content of the synced files, e.g. decode base64 encoded files
+		if err := applyTransforms(content.Files, OCIsecret.Spec.Transform); err != nil {
+			return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonInvalidTransform, err)
+		}
+		content, err = runTransforms(content, OCIsecret.Spec.Sync.Transforms)
+		if err != nil {
+			return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonInvalidTransform, err)
+		}
+
+		// Render the keys of the files, e.g. to include the tag of the artefact
+		if keyTemplate := OCIsecret.Spec.Sync.KeyTemplate; keyTemplate != "" {
+			content, err = renderKeys(content, keyTemplate, keyTemplateData{
+				Tag:         OCIsecret.Spec.OrasArtefact,
+				Digest:      string(content.Digest),
+				Annotations: content.Annotations,
+			})
+			if err != nil {
+				return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonInvalidKeyTemplate, err)
+			}
+		}
 
-		// Save the updated target Secret
-		err = r.Update(ctx, TargetSecret)
+		// Secret keys only allow letters, digits, "-", "_" and ".", reject or sanitize the other file names
+		content, sanitizedKeys, err := checkKeyNames(content, OCIsecret.Spec.Sync.KeyNamePolicy)
+		if err != nil {
+			return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonInvalidKeyName, err)
+		}
+		if len(sanitizedKeys) > 0 {
+			message := fmt.Sprintf("Sanitized %d keys that aren't valid Secret keys", len(sanitizedKeys))
+			logger.Info(message, "sanitizedKeys", sanitizedKeys)
+			r.Recorder.Event(OCIsecret, v1core.EventTypeNormal, ocisyncv1aplha1.ReasonKeysSanitized, message)
+		}
+
+		// Record the original paths of the synced files so consumers can rebuild the directory tree
+		if layout := OCIsecret.Spec.KeyLayout; layout != nil && layout.ManifestKey != "" {
+			if _, exists := content.Files[layout.ManifestKey]; exists {
+				err = fmt.Errorf("the manifest key %s is already used by a file of the artefact", layout.ManifestKey)
+				logger.Error(err, "Failed to add path manifest.")
+				return ctrl.Result{}, err
+			}
+			paths := make(map[string]string, len(content.Files))
+			for key := range content.Files {
+				paths[key] = content.Paths[key]
+			}
+			manifest, err := orasclient.EncodePathManifest(paths)
+			if err != nil {
+				logger.Error(err, "Failed to add path manifest.")
+				return ctrl.Result{}, err
+			}
+			content.Files[layout.ManifestKey] = manifest
+		}
+
+		// Add the referrers (e.g. signatures or SBOMs) of the artifact if requested
+		if len(OCIsecret.Spec.IncludeReferrers) > 0 {
+			// The referrers end up in the target Secret, so they can't be larger than a Secret or the artefact limit
+			maxReferrerSize := int64(v1core.MaxSecretSize)
+			if OCIsecret.Spec.MaxArtifactSize != nil {
+				maxReferrerSize = OCIsecret.Spec.MaxArtifactSize.Value()
+			}
+			referrerFiles, err := r.artifactClient().GetReferrerFiles(ctx, repository, content.Digest, OCIsecret.Spec.IncludeReferrers,
+				maxReferrerSize, clientOptions)
+			if errors.Is(err, orasclient.ErrArtifactTooLarge) {
+				return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonArtifactTooLarge, err)
+			} else if err != nil {
+				logger.Error(err, "Failed to get referrers.")
+				return ctrl.Result{}, err
+			}
+			for key, value := range referrerFiles {
+				content.Files[key] = value
+				artefactFiles[key] = value
+			}
+		}
+
+		// Describe the synced files in the Secret itself for consumers that need to know which keys are populated
+		if OCIsecret.Spec.IncludeManifest {
+			if _, exists := content.Files[ocisyncv1aplha1.SyncManifestKey]; exists {
+				err = fmt.Errorf("the manifest key %s is already used by a file of the artefact", ocisyncv1aplha1.SyncManifestKey)
+				logger.Error(err, "Failed to add sync manifest.")
+				return ctrl.Result{}, err
+			}
+			manifest, err := encodeSyncManifest(content.Files, content.Modes, string(content.Digest), time.Now())
+			if err != nil {
+				logger.Error(err, "Failed to add sync manifest.")
+				return ctrl.Result{}, err
+			}
+			content.Files[ocisyncv1aplha1.SyncManifestKey] = manifest
+		}
+
+		// Warn (without blocking) if the Secret gets too many keys to be mounted efficiently
+		if err := r.checkKeyCount(ctx, OCIsecret, len(content.Files)); err != nil {
+			logger.Error(err, "Failed to update OCISecret status.")
+			return ctrl.Result{}, err
+		}
+
+		// Refuse data the API server would reject, with the actual size instead of its opaque error, unless
+		// it is split across shard Secrets
+		data := mergedData(TargetSecret, content.Files, OCIsecret.Spec.MergeMode)
+		var shards []map[string][]byte
+		size := secretDataSize(data)
+		if OCIsecret.Spec.AutoShard && size > v1core.MaxSecretSize {
+			if shards, err = splitShards(content.Files); err != nil {
+				return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonSecretTooLarge, err)
+			}
+			size = 0
+		}
+		if err := r.updateTooLargeCondition(ctx, OCIsecret, size); err != nil {
+			logger.Error(err, "Failed to update OCISecret status.")
+			return ctrl.Result{}, err
+		}
+		if shards == nil {
+			if err := checkSecretSize(data); err != nil {
+				return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonSecretTooLarge, err)
+			}
+		}
+
+		// Only report the drift of the target Secrets in audit-only mode
+		if r.AuditOnly {
+			diffs, err := r.auditTargets(ctx, OCIsecret, TargetSecret, data, artefactFiles)
+			if err != nil {
+				logger.Error(err, "Failed to get target Secrets.")
+				return ctrl.Result{}, err
+			}
+			return r.reportDrift(ctx, OCIsecret, record, diffs, string(content.Digest))
+		}
+
+		// Only report what would change in dry-run mode
+		if OCIsecret.Spec.DryRun {
+			return r.reportDryRun(ctx, OCIsecret, record, utils.DiffMaps(TargetSecret.Data, data), string(content.Digest))
+		}
+
+		// Update the target Secret with the downloaded files
+		diff := utils.DiffMaps(TargetSecret.Data, data)
+		syncedDigests = fileDigests(content.Files)
+		syncedDigest = string(content.Digest)
+		annotations := propagatedAnnotations(content.Annotations, OCIsecret.Spec.PropagateAnnotations)
+		annotations[syncedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
+		targetFiles := content.Files
+		if shards != nil {
+			// Write the files into the shard Secrets, the target Secret only gets the manifest of the shards
+			previous, err := r.syncShards(ctx, OCIsecret, TargetSecretReq.NamespacedName, shards, annotations, string(content.Digest))
+			if errors.Is(err, errTargetConflict) {
+				return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonTargetConflict, err)
+			} else if err != nil {
+				logger.Error(err, "Failed to update shard Secrets.")
+				return ctrl.Result{}, err
+			}
+			diff = utils.DiffMaps(previous, data)
+			manifest, err := encodeShardManifest(TargetSecretReq.Name, shards, string(content.Digest))
+			if err != nil {
+				logger.Error(err, "Failed to add shard manifest.")
+				return ctrl.Result{}, err
+			}
+			targetFiles = map[string][]byte{ocisyncv1aplha1.ShardManifestKey: manifest}
+		}
+		// Apply the files, the propagated manifest annotations and the revision annotation tracking the
+		// current digest. An existing Secret is taken over, checkTargetConflicts only lets it through with
+		// AdoptExisting
+		err = r.applyTargetSecret(ctx, TargetSecret, OCIsecret, targetFiles, annotations, string(content.Digest))
 		if err != nil {
 			logger.Error(err, "Failed to update TargetSecret.")
 			return ctrl.Result{}, err
 		} else {
-			logger.Info("Updated TargetSecret.")
+			logger.Info("Updated TargetSecret.", "added", diff.Added, "changed", diff.Changed, "removed", diff.Removed)
 		}
+		// Name the changed keys, a resync or a new digest with the same files changes nothing
+		if !diff.Empty() {
+			r.Recorder.Event(OCIsecret, v1core.EventTypeNormal, ocisyncv1aplha1.ReasonSynced,
+				fmt.Sprintf("Synced %s to the target Secret: %s", content.Digest, diff))
+		}
+		// Drop the shard Secrets that are no longer needed
+		if err := r.deleteStaleShards(ctx, OCIsecret, TargetSecretReq.NamespacedName, len(shards)); err != nil {
+			logger.Error(err, "Failed to delete stale shard Secrets.")
+			return ctrl.Result{}, err
+		}
+		record.Result = SyncResultSynced
+		record.Digest = string(content.Digest)
+		record.ChangedKeys = diff.Keys()
+
+		// Fan the same download out to the additional target Secrets
+		if err := r.syncAdditionalTargets(ctx, OCIsecret, artefactFiles, annotations, string(content.Digest), resync); errors.Is(err, errSecretTooLarge) {
+			return r.failSync(ctx, OCIsecret, record, ocisyncv1aplha1.ReasonSecretTooLarge, err)
+		} else if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Nothing was downloaded, keep reporting the plan of the current digest
+	if OCIsecret.Spec.DryRun {
+		return r.reportDryRun(ctx, OCIsecret, record, lastPlan(OCIsecret, currentDigest), currentDigest)
+	}
+
+	// Record the synced generation, so the next reconcile only downloads the artefact if its digest changed
+	err = r.markSynced(ctx, OCIsecret, authMode, mirror, forceSync, syncedDigest, syncedDigests)
+	if err != nil {
+		logger.Error(err, "Failed to update OCISecret status.")
+		return ctrl.Result{}, err
 	}
 
 	// Step 5: Schedule the next reconciliation
-	// Requeue after 60 seconds to periodically check for changes in the OCI registry
-	return ctrl.Result{RequeueAfter: time.Duration(60) * time.Second}, nil
+	// Requeue after 60 seconds to periodically check for changes in the OCI registry, or earlier for a resync
+	return ctrl.Result{RequeueAfter: requeueInterval(OCIsecret, time.Now())}, nil
+}
+
+// errDigestMismatch is returned when the digest of the artefact isn't the ExpectedDigest of the OCISecret.
+var errDigestMismatch = errors.New("the artefact digest doesn't match the expected digest")
+
+// checkExpectedDigest returns errDigestMismatch (wrapped) if the OCISecret pins an ExpectedDigest and the
+// digest of the artefact is another one.
+func checkExpectedDigest(ocisecret *ocisyncv1aplha1.OCISecret, digest string) error {
+	expected := ocisecret.Spec.ExpectedDigest
+	if expected == "" || digest == expected {
+		return nil
+	}
+	return fmt.Errorf("%w: %s is %s, expected %s", errDigestMismatch, ocisecret.Spec.OrasArtefact, digest, expected)
+}
+
+// artifactClient returns the configured ArtifactClient or the ORAS based default.
+func (r *OCISecretReconciler) artifactClient() orasclient.ArtifactClient {
+	if r.ArtifactClient == nil {
+		return orasclient.OrasClient{}
+	}
+	return r.ArtifactClient
+}
+
+// forceSyncChangedPredicate accepts updates that change the force-sync annotation of an OCISecret.
+func forceSyncChangedPredicate() predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if e.ObjectOld == nil || e.ObjectNew == nil {
+				return false
+			}
+			return e.ObjectOld.GetAnnotations()[ocisyncv1aplha1.ForceSyncAnnotation] !=
+				e.ObjectNew.GetAnnotations()[ocisyncv1aplha1.ForceSyncAnnotation]
+		},
+	}
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -234,8 +923,9 @@ func (r *OCISecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 //
 // The controller-runtime library handles:
 // - Starting and stopping the controller
-// - Watching for changes to OCISecret resources
+// - Watching for spec changes to OCISecret resources
 // - Calling the Reconcile method when OCISecret resources change
+// - Requeuing failed reconciles with exponential backoff
 // - Managing the controller's lifecycle
 //
 // Parameters:
@@ -244,9 +934,29 @@ func (r *OCISecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 // Returns:
 //   - An error if the controller cannot be set up
 func (r *OCISecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Index the OCISecrets by their target Secrets to find OCISecrets writing the same Secret
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &ocisyncv1aplha1.OCISecret{},
+		targetSecretIndex, r.indexTargetSecrets); err != nil {
+		return err
+	}
+	r.targetSecretIndexed = true
+
+	options := controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}
+	if r.FailureBackoffBase > 0 && r.FailureBackoffMax > 0 {
+		// Back off exponentially while a reconcile keeps failing, e.g. during a registry brownout. The
+		// jitter spreads the retries of OCISecrets that failed at the same time.
+		options.RateLimiter = jitteredRateLimiter{workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](
+			r.FailureBackoffBase, r.FailureBackoffMax)}
+		r.failureBackoff = newFailureBackoff(r.FailureBackoffBase, r.FailureBackoffMax)
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
-		// Watch for changes to OCISecret resources
-		For(&ocisyncv1aplha1.OCISecret{}).
+		// Watch for spec changes of OCISecret resources and changes of the force-sync annotation; other
+		// status and metadata updates (including the controller's own status writes) are ignored, the
+		// timed requeue polls the registry
+		For(&ocisyncv1aplha1.OCISecret{}, builder.WithPredicates(predicate.Or[client.Object](
+			predicate.GenerationChangedPredicate{}, forceSyncChangedPredicate()))).
+		WithOptions(options).
 		// Complete sets up the controller with the reconciler
 		Complete(r)
 }
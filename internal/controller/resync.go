@@ -0,0 +1,60 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+)
+
+// syncedAtAnnotation is the annotation of a target Secret that holds the time it was last written, so a
+// resync of unchanged content still changes the Secret and bumps its resourceVersion.
+const syncedAtAnnotation = "oci-sync.brtrm.de/synced-at"
+
+// pollInterval is the interval at which the registry is checked for a new digest of the artifact.
+const pollInterval = 60 * time.Second
+
+// resyncDue reports whether the target Secrets have to be rewritten because the ForceResyncInterval of
+// the OCISecret elapsed since they were last written. Without a recorded write, e.g. for OCISecrets
+// synced by earlier versions of the operator, a resync is due right away.
+func resyncDue(ocisecret *ocisyncv1aplha1.OCISecret, now time.Time) bool {
+	interval := ocisecret.Spec.ForceResyncInterval
+	if interval == nil || interval.Duration <= 0 {
+		return false
+	}
+	lastWrite := ocisecret.Status.LastWriteTime
+	if lastWrite == nil {
+		return true
+	}
+	return !now.Before(lastWrite.Add(interval.Duration))
+}
+
+// requeueInterval returns the time until the next reconcile of the OCISecret: the pollInterval, or the
+// time until the next resync of ForceResyncInterval if that is due earlier.
+func requeueInterval(ocisecret *ocisyncv1aplha1.OCISecret, now time.Time) time.Duration {
+	interval := ocisecret.Spec.ForceResyncInterval
+	lastWrite := ocisecret.Status.LastWriteTime
+	if interval == nil || interval.Duration <= 0 || lastWrite == nil {
+		return pollInterval
+	}
+	if untilResync := lastWrite.Add(interval.Duration).Sub(now); untilResync < pollInterval {
+		// Don't requeue immediately if the resync is already due, e.g. after a failed write
+		return max(untilResync, time.Second)
+	}
+	return pollInterval
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+)
+
+var _ = Describe("Forced resyncs", func() {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	var ocisecret *ocisyncv1aplha1.OCISecret
+
+	BeforeEach(func() {
+		ocisecret = &ocisyncv1aplha1.OCISecret{}
+	})
+
+	It("should not resync without an interval", func() {
+		Expect(resyncDue(ocisecret, now)).To(BeFalse())
+		Expect(requeueInterval(ocisecret, now)).To(Equal(pollInterval))
+	})
+
+	It("should resync once the interval elapsed since the last write", func() {
+		ocisecret.Spec.ForceResyncInterval = &metav1.Duration{Duration: time.Hour}
+		ocisecret.Status.LastWriteTime = &metav1.Time{Time: now.Add(-30 * time.Minute)}
+		Expect(resyncDue(ocisecret, now)).To(BeFalse())
+		Expect(resyncDue(ocisecret, now.Add(30*time.Minute))).To(BeTrue())
+	})
+
+	It("should resync right away without a recorded write", func() {
+		ocisecret.Spec.ForceResyncInterval = &metav1.Duration{Duration: time.Hour}
+		Expect(resyncDue(ocisecret, now)).To(BeTrue())
+	})
+
+	It("should requeue for a resync that is due before the next poll", func() {
+		ocisecret.Spec.ForceResyncInterval = &metav1.Duration{Duration: time.Hour}
+		ocisecret.Status.LastWriteTime = &metav1.Time{Time: now.Add(-30 * time.Minute)}
+		Expect(requeueInterval(ocisecret, now)).To(Equal(pollInterval))
+		Expect(requeueInterval(ocisecret, now.Add(59*time.Minute+30*time.Second))).To(Equal(30 * time.Second))
+		Expect(requeueInterval(ocisecret, now.Add(2*time.Hour))).To(Equal(time.Second))
+	})
+})
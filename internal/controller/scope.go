@@ -0,0 +1,110 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+	"github.com/mariusbertram/oci-resource-sync-operator/internal/utils"
+)
+
+// errCrossNamespaceReference is returned when a namespaced OCISecret references a Secret in another namespace.
+var errCrossNamespaceReference = errors.New("namespaced OCISecrets may only reference Secrets in their own namespace")
+
+// errMissingNamespace is returned when a cluster-scoped OCISecret references a Secret without a namespace
+// and no default namespace is configured.
+var errMissingNamespace = errors.New("the Secret reference has no namespace and no default namespace is configured")
+
+// resolveNamespaces fills in the empty namespaces of the Secrets referenced by the OCISecret, in memory
+// only. A namespaced OCISecret defaults them to its own namespace and may not reference other namespaces,
+// so namespace-isolated RBAC can't be bypassed and the owner references of the target Secrets stay valid.
+// A cluster-scoped OCISecret defaults the credential Secrets (ArtefactPullSecret, BasicAuthSecretRef and
+// ClientCertSecretRef) to the namespace of its TargetSecret, so teams can keep their pull secret next to
+// the synced Secret, and the other references to defaultNamespace, which may be empty to require them.
+// Namespaces that are set are always kept.
+func resolveNamespaces(ocisecret *ocisyncv1aplha1.OCISecret, defaultNamespace string) error {
+	resolve := func(field string, namespace *string) error {
+		switch {
+		case ocisecret.Namespace != "" && *namespace == "":
+			*namespace = ocisecret.Namespace
+		case ocisecret.Namespace != "" && *namespace != ocisecret.Namespace:
+			return fmt.Errorf("%w: %s is in namespace %s", errCrossNamespaceReference, field, *namespace)
+		case *namespace == "" && defaultNamespace != "":
+			*namespace = defaultNamespace
+		case *namespace == "":
+			return fmt.Errorf("%w: %s", errMissingNamespace, field)
+		}
+		return nil
+	}
+
+	spec := &ocisecret.Spec
+	if err := resolve("targetSecret", &spec.TargetSecret.Namespace); err != nil {
+		return err
+	}
+	resolveCredentials := func(field string, namespace *string) error {
+		if ocisecret.Namespace == "" && *namespace == "" {
+			*namespace = spec.TargetSecret.Namespace
+		}
+		return resolve(field, namespace)
+	}
+	// The pull secret is optional, only default its namespace if it is referenced
+	if spec.ArtefactPullSecret.Name != "" {
+		if err := resolveCredentials("ArtefactPullSecret", &spec.ArtefactPullSecret.Namespace); err != nil {
+			return err
+		}
+	}
+	if spec.BasicAuthSecretRef != nil {
+		if err := resolveCredentials("basicAuthSecretRef", &spec.BasicAuthSecretRef.Namespace); err != nil {
+			return err
+		}
+	}
+	if spec.ClientCertSecretRef != nil {
+		if err := resolveCredentials("clientCertSecretRef", &spec.ClientCertSecretRef.Namespace); err != nil {
+			return err
+		}
+	}
+	for i := range spec.AdditionalTargets {
+		if err := resolve(fmt.Sprintf("additionalTargets[%d]", i), &spec.AdditionalTargets[i].Namespace); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// errRegistryNotAllowed is returned when the registry of an OCISecret isn't on the allowlist.
+var errRegistryNotAllowed = errors.New("the registry is not on the list of allowed registries")
+
+// checkRegistryAllowed returns errRegistryNotAllowed (wrapped) unless the registry (e.g.
+// "ghcr.io/org/configs") or one of its leading path segments ("ghcr.io", "ghcr.io/org") matches one of
+// the allowed patterns, see utils.MatchesAny. "ghcr.io/org" therefore allows all repositories below
+// the organization and "*.dkr.ecr.*.amazonaws.com" all ECR registries. An empty allowlist allows all
+// registries.
+func checkRegistryAllowed(registry string, allowed []string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+	segments := strings.Split(registry, "/")
+	for i := range segments {
+		if utils.MatchesAny(strings.Join(segments[:i+1], "/"), allowed) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", errRegistryNotAllowed, registry)
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1core "k8s.io/api/core/v1"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+)
+
+var _ = Describe("Namespace resolution", func() {
+	It("should default empty namespaces to the namespace of the OCISecret", func() {
+		ocisecret := newTestOCISecret("team-config")
+		ocisecret.Namespace = "team-a"
+		ocisecret.Spec.TargetSecret.Namespace = ""
+		ocisecret.Spec.ArtefactPullSecret = v1core.SecretReference{Name: "pull-secret"}
+		ocisecret.Spec.AdditionalTargets = []ocisyncv1aplha1.SecretTarget{{Name: "other"}}
+
+		Expect(resolveNamespaces(ocisecret, "")).To(Succeed())
+		Expect(ocisecret.Spec.TargetSecret.Namespace).To(Equal("team-a"))
+		Expect(ocisecret.Spec.ArtefactPullSecret.Namespace).To(Equal("team-a"))
+		Expect(ocisecret.Spec.AdditionalTargets[0].Namespace).To(Equal("team-a"))
+	})
+
+	It("should reject references to other namespaces", func() {
+		ocisecret := newTestOCISecret("team-config")
+		ocisecret.Namespace = "team-a"
+		ocisecret.Spec.TargetSecret.Namespace = "team-b"
+
+		Expect(resolveNamespaces(ocisecret, "")).To(MatchError(errCrossNamespaceReference))
+	})
+
+	It("should default empty namespaces of a cluster-scoped OCISecret to the default namespace", func() {
+		ocisecret := newTestOCISecret("global-config")
+		ocisecret.Namespace = ""
+		ocisecret.Spec.TargetSecret.Namespace = ""
+
+		Expect(resolveNamespaces(ocisecret, "operator-defaults")).To(Succeed())
+		Expect(ocisecret.Spec.TargetSecret.Namespace).To(Equal("operator-defaults"))
+	})
+
+	It("should look up the credentials of a cluster-scoped OCISecret in the namespace of the target Secret", func() {
+		ocisecret := newTestOCISecret("global-config")
+		ocisecret.Namespace = ""
+		ocisecret.Spec.TargetSecret.Namespace = "team-b"
+		ocisecret.Spec.ArtefactPullSecret = v1core.SecretReference{Name: "pull-secret"}
+		ocisecret.Spec.BasicAuthSecretRef = &v1core.SecretReference{Name: "basic-auth", Namespace: "registry-credentials"}
+		ocisecret.Spec.AdditionalTargets = []ocisyncv1aplha1.SecretTarget{{Name: "other"}}
+
+		Expect(resolveNamespaces(ocisecret, "operator-defaults")).To(Succeed())
+		Expect(ocisecret.Spec.ArtefactPullSecret.Namespace).To(Equal("team-b"))
+		// Explicit namespaces are kept, other references use the default namespace
+		Expect(ocisecret.Spec.BasicAuthSecretRef.Namespace).To(Equal("registry-credentials"))
+		Expect(ocisecret.Spec.AdditionalTargets[0].Namespace).To(Equal("operator-defaults"))
+	})
+
+	It("should reject empty namespaces of a cluster-scoped OCISecret without a default namespace", func() {
+		ocisecret := newTestOCISecret("global-config")
+		ocisecret.Namespace = ""
+		ocisecret.Spec.TargetSecret.Namespace = ""
+
+		Expect(resolveNamespaces(ocisecret, "")).To(MatchError(errMissingNamespace))
+	})
+
+	It("should leave the references of a cluster-scoped OCISecret unchanged", func() {
+		ocisecret := newTestOCISecret("global-config")
+		ocisecret.Namespace = ""
+		ocisecret.Spec.TargetSecret.Namespace = "team-b"
+
+		Expect(resolveNamespaces(ocisecret, "")).To(Succeed())
+		Expect(ocisecret.Spec.TargetSecret.Namespace).To(Equal("team-b"))
+		Expect(ocisecret.Spec.ArtefactPullSecret.Namespace).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Registry allowlist", func() {
+	allowed := []string{"ghcr.io/my-org", "*.dkr.ecr.*.amazonaws.com", "registry.example.com"}
+
+	It("should allow the registries matching a pattern or below it", func() {
+		Expect(checkRegistryAllowed("ghcr.io/my-org/configs", allowed)).To(Succeed())
+		Expect(checkRegistryAllowed("123456789012.dkr.ecr.eu-west-1.amazonaws.com/configs", allowed)).To(Succeed())
+		Expect(checkRegistryAllowed("registry.example.com/team/configs", allowed)).To(Succeed())
+	})
+
+	It("should reject other registries", func() {
+		Expect(checkRegistryAllowed("ghcr.io/other-org/configs", allowed)).To(MatchError(errRegistryNotAllowed))
+		Expect(checkRegistryAllowed("ghcr.io/my-org-fork/configs", allowed)).To(MatchError(errRegistryNotAllowed))
+		Expect(checkRegistryAllowed("docker.io/library/configs", allowed)).To(MatchError(errRegistryNotAllowed))
+	})
+
+	It("should allow all registries without an allowlist", func() {
+		Expect(checkRegistryAllowed("docker.io/library/configs", nil)).To(Succeed())
+	})
+})
@@ -0,0 +1,140 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	v1core "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+)
+
+// shardManifest is the content of the ocisyncv1aplha1.ShardManifestKey key of a sharded target Secret.
+type shardManifest struct {
+	// Digest is the digest of the synced artifact
+	Digest string `json:"digest"`
+	// Keys maps every synced key to the name of the shard Secret holding it
+	Keys map[string]string `json:"keys"`
+}
+
+// shardName returns the name of the shard Secret with the index for the target Secret.
+func shardName(target string, index int) string {
+	return fmt.Sprintf("%s-%d", target, index)
+}
+
+// splitShards distributes the files over as few maps as possible that each fit into a Secret, taking
+// the keys in sorted order so the same files always end up in the same shards. A single file that
+// exceeds v1core.MaxSecretSize on its own fails with errSecretTooLarge (wrapped).
+func splitShards(files map[string][]byte) ([]map[string][]byte, error) {
+	keys := make([]string, 0, len(files))
+	for key := range files {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var shards []map[string][]byte
+	size := 0
+	for _, key := range keys {
+		value := files[key]
+		if len(value) > v1core.MaxSecretSize {
+			return nil, fmt.Errorf("%w: the file %s has %d bytes, at most %d bytes fit into a Secret",
+				errSecretTooLarge, key, len(value), v1core.MaxSecretSize)
+		}
+		if len(shards) == 0 || size+len(value) > v1core.MaxSecretSize {
+			shards = append(shards, map[string][]byte{})
+			size = 0
+		}
+		shards[len(shards)-1][key] = value
+		size += len(value)
+	}
+	return shards, nil
+}
+
+// encodeShardManifest returns the JSON encoded manifest mapping the keys of the shards to the names of
+// the shard Secrets of the target Secret.
+func encodeShardManifest(target string, shards []map[string][]byte, digest string) ([]byte, error) {
+	manifest := shardManifest{Digest: digest, Keys: map[string]string{}}
+	for i, shard := range shards {
+		for key := range shard {
+			manifest.Keys[key] = shardName(target, i)
+		}
+	}
+	return json.Marshal(manifest)
+}
+
+// syncShards writes the shards into the numbered shard Secrets of the target Secret (<name>-0, <name>-1,
+// ...), which are owned by the OCISecret like the target Secret. It returns the previous data of the
+// shard Secrets. A shard Secret that exists without being controlled by the OCISecret fails with
+// errTargetConflict (wrapped) before anything is written, unless it was left behind by a previous
+// OCISecret with the same name (see orphanedTarget).
+func (r *OCISecretReconciler) syncShards(ctx context.Context, ocisecret *ocisyncv1aplha1.OCISecret,
+	target types.NamespacedName, shards []map[string][]byte, annotations map[string]string,
+	digest string) (map[string][]byte, error) {
+	secrets := make([]*v1core.Secret, len(shards))
+	for i := range shards {
+		secret := &v1core.Secret{}
+		name := types.NamespacedName{Name: shardName(target.Name, i), Namespace: target.Namespace}
+		err := r.Get(ctx, name, secret)
+		if apierrors.IsNotFound(err) {
+			secret = newTargetSecret(ocisecret, name.Name, name.Namespace)
+		} else if err != nil {
+			return nil, err
+		} else if !controlledBy(secret, ocisecret) && !orphanedTarget(secret, ocisecret) {
+			return nil, fmt.Errorf("%w: the shard Secret %s exists", errTargetConflict, name)
+		}
+		secrets[i] = secret
+	}
+
+	previous := map[string][]byte{}
+	for i, secret := range secrets {
+		for key, value := range secret.Data {
+			previous[key] = value
+		}
+		if err := r.applyTargetSecret(ctx, secret, ocisecret, shards[i], annotations, digest); err != nil {
+			return nil, err
+		}
+	}
+	return previous, nil
+}
+
+// deleteStaleShards deletes the shard Secrets of the target Secret from the index on, e.g. after the
+// files shrank and fit into fewer shards or into the target Secret itself. Shard Secrets are numbered
+// without gaps, so the first one that doesn't exist or isn't controlled by the OCISecret ends the search.
+func (r *OCISecretReconciler) deleteStaleShards(ctx context.Context, ocisecret *ocisyncv1aplha1.OCISecret,
+	target types.NamespacedName, from int) error {
+	for i := from; ; i++ {
+		secret := &v1core.Secret{}
+		err := r.Get(ctx, types.NamespacedName{Name: shardName(target.Name, i), Namespace: target.Namespace}, secret)
+		if apierrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if !controlledBy(secret, ocisecret) && !orphanedTarget(secret, ocisecret) {
+			return nil
+		}
+		if err := r.Delete(ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+)
+
+var _ = Describe("Shards", func() {
+	half := v1core.MaxSecretSize / 2
+
+	It("should split the files into shards that fit into a Secret", func() {
+		shards, err := splitShards(map[string][]byte{
+			"a.bin": make([]byte, half),
+			"b.bin": make([]byte, half),
+			"c.bin": make([]byte, half),
+			"d.txt": []byte("d"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(shards).To(HaveLen(2))
+		Expect(shards[0]).To(HaveLen(2))
+		Expect(shards[1]).To(HaveKey("c.bin"))
+		Expect(shards[1]).To(HaveKey("d.txt"))
+
+		manifest, err := encodeShardManifest("target", shards, "sha256:1")
+		Expect(err).NotTo(HaveOccurred())
+		var decoded shardManifest
+		Expect(json.Unmarshal(manifest, &decoded)).To(Succeed())
+		Expect(decoded.Digest).To(Equal("sha256:1"))
+		Expect(decoded.Keys).To(HaveKeyWithValue("a.bin", "target-0"))
+		Expect(decoded.Keys).To(HaveKeyWithValue("d.txt", "target-1"))
+	})
+
+	It("should refuse a single file that doesn't fit into a Secret", func() {
+		_, err := splitShards(map[string][]byte{"large.bin": make([]byte, v1core.MaxSecretSize+1)})
+		Expect(err).To(MatchError(errSecretTooLarge))
+	})
+
+	Context("When writing the shard Secrets", func() {
+		ctx := context.Background()
+		var ocisecret *ocisyncv1aplha1.OCISecret
+		var controllerReconciler *OCISecretReconciler
+		target := types.NamespacedName{Name: "sharded", Namespace: "default"}
+
+		BeforeEach(func() {
+			ocisecret = newTestOCISecret("sharded")
+			Expect(k8sClient.Create(ctx, ocisecret)).To(Succeed())
+			controllerReconciler = &OCISecretReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		})
+
+		AfterEach(func() {
+			Expect(controllerReconciler.deleteStaleShards(ctx, ocisecret, target, 0)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, ocisecret)).To(Succeed())
+		})
+
+		It("should create owned shard Secrets and delete the ones no longer needed", func() {
+			shards := []map[string][]byte{{"a.yaml": []byte("a")}, {"b.yaml": []byte("b")}}
+			_, err := controllerReconciler.syncShards(ctx, ocisecret, target, shards, nil, "sha256:1")
+			Expect(err).NotTo(HaveOccurred())
+
+			secret := &v1core.Secret{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "sharded-1", Namespace: "default"}, secret)).To(Succeed())
+			Expect(secret.Data).To(Equal(shards[1]))
+			Expect(secret.Annotations).To(HaveKeyWithValue(revisionAnnotation, "sha256:1"))
+			Expect(secret.OwnerReferences).To(HaveLen(1))
+
+			previous, err := controllerReconciler.syncShards(ctx, ocisecret, target, shards[:1], nil, "sha256:2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(previous).To(Equal(map[string][]byte{"a.yaml": []byte("a")}))
+			Expect(controllerReconciler.deleteStaleShards(ctx, ocisecret, target, 1)).To(Succeed())
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "sharded-0", Namespace: "default"}, secret)).To(Succeed())
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: "sharded-1", Namespace: "default"}, secret)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,283 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	v1core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+)
+
+// setCondition sets a condition on the OCISecret and persists its status if the condition changed.
+// The condition's ObservedGeneration is set to the current generation of the OCISecret.
+func (r *OCISecretReconciler) setCondition(ctx context.Context, ocisecret *ocisyncv1aplha1.OCISecret,
+	conditionType string, status metav1.ConditionStatus, reason, message string) error {
+	changed := meta.SetStatusCondition(&ocisecret.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: ocisecret.Generation,
+	})
+	if !changed {
+		return nil
+	}
+	return r.Status().Update(ctx, ocisecret)
+}
+
+// updateSuspendedCondition sets the Suspended condition while the OCISecret is suspended and removes
+// it once syncing is resumed. The status is only persisted if it changed.
+func (r *OCISecretReconciler) updateSuspendedCondition(ctx context.Context, ocisecret *ocisyncv1aplha1.OCISecret) error {
+	if ocisecret.Spec.Suspend {
+		return r.setCondition(ctx, ocisecret, ocisyncv1aplha1.ConditionTypeSuspended, metav1.ConditionTrue,
+			ocisyncv1aplha1.ReasonSuspended, "Syncing is suspended, the target Secret is left unchanged")
+	}
+	if !meta.RemoveStatusCondition(&ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeSuspended) {
+		return nil
+	}
+	return r.Status().Update(ctx, ocisecret)
+}
+
+// updateEmptyArtifactCondition sets the EmptyArtifact condition and emits a warning event while the
+// artifact contains no files and removes the condition once it has files again. The status is only
+// persisted if it changed.
+func (r *OCISecretReconciler) updateEmptyArtifactCondition(ctx context.Context, ocisecret *ocisyncv1aplha1.OCISecret,
+	fileCount int) error {
+	if fileCount == 0 {
+		message := "The artefact contains no files, the target Secret would have no keys"
+		r.Recorder.Event(ocisecret, v1core.EventTypeWarning, ocisyncv1aplha1.ReasonEmptyArtifact, message)
+		return r.setCondition(ctx, ocisecret, ocisyncv1aplha1.ConditionTypeEmptyArtifact, metav1.ConditionTrue,
+			ocisyncv1aplha1.ReasonEmptyArtifact, message)
+	}
+	if !meta.RemoveStatusCondition(&ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeEmptyArtifact) {
+		return nil
+	}
+	return r.Status().Update(ctx, ocisecret)
+}
+
+// updateMissingFilesCondition sets the MissingFiles condition and emits a warning event while entries
+// of Sync.Files match no file of the artifact and removes the condition once all of them match. The
+// status is only persisted if it changed.
+func (r *OCISecretReconciler) updateMissingFilesCondition(ctx context.Context, ocisecret *ocisyncv1aplha1.OCISecret,
+	missing []string) error {
+	if len(missing) > 0 {
+		message := fmt.Sprintf("The artefact contains no file matching %s", strings.Join(missing, ", "))
+		r.Recorder.Event(ocisecret, v1core.EventTypeWarning, ocisyncv1aplha1.ReasonFilesMissing, message)
+		return r.setCondition(ctx, ocisecret, ocisyncv1aplha1.ConditionTypeMissingFiles, metav1.ConditionTrue,
+			ocisyncv1aplha1.ReasonFilesMissing, message)
+	}
+	if !meta.RemoveStatusCondition(&ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeMissingFiles) {
+		return nil
+	}
+	return r.Status().Update(ctx, ocisecret)
+}
+
+// updateTooLargeCondition sets the TooLarge condition and emits a warning event while the data synced
+// into the target Secret has more than v1core.MaxSecretSize bytes and removes the condition once it fits
+// again. The status is only persisted if it changed.
+func (r *OCISecretReconciler) updateTooLargeCondition(ctx context.Context, ocisecret *ocisyncv1aplha1.OCISecret,
+	size int) error {
+	if size > v1core.MaxSecretSize {
+		message := fmt.Sprintf("The synced data has %d bytes, a Secret holds at most %d bytes; "+
+			"select fewer files with Sync.Files or exclude large ones with Sync.ExcludeFiles", size, v1core.MaxSecretSize)
+		r.Recorder.Event(ocisecret, v1core.EventTypeWarning, ocisyncv1aplha1.ReasonSecretTooLarge, message)
+		return r.setCondition(ctx, ocisecret, ocisyncv1aplha1.ConditionTypeTooLarge, metav1.ConditionTrue,
+			ocisyncv1aplha1.ReasonSecretTooLarge, message)
+	}
+	if !meta.RemoveStatusCondition(&ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeTooLarge) {
+		return nil
+	}
+	return r.Status().Update(ctx, ocisecret)
+}
+
+// updateSyncSkippedCondition sets the SyncSkipped condition with the reason the artifact doesn't meet
+// MinVersion or NotBefore (gateErr) and removes the condition once an artifact meets them (gateErr is nil).
+// The status is only persisted if it changed.
+func (r *OCISecretReconciler) updateSyncSkippedCondition(ctx context.Context, ocisecret *ocisyncv1aplha1.OCISecret,
+	gateErr error) error {
+	if gateErr != nil {
+		return r.setCondition(ctx, ocisecret, ocisyncv1aplha1.ConditionTypeSyncSkipped, metav1.ConditionTrue,
+			ocisyncv1aplha1.ReasonBelowGate, fmt.Sprintf("The artefact is not synced, the target Secret is left unchanged: %v", gateErr))
+	}
+	if !meta.RemoveStatusCondition(&ocisecret.Status.Conditions, ocisyncv1aplha1.ConditionTypeSyncSkipped) {
+		return nil
+	}
+	return r.Status().Update(ctx, ocisecret)
+}
+
+// markSynced sets the Ready condition of the OCISecret to true, records its current generation as
+// observed together with the authentication mode and the mirror (empty for the registry itself) that
+// succeeded and the handled force-sync annotation value, and drops the plan of a previous dry-run. The
+// digests of the synced files and the time of the write are recorded if the target Secret was written
+// (fileDigests is not nil), a new synced digest (not empty) is added to the sync history. If the synced
+// digest is the pending one (see observeDigest), the time since it was first observed is recorded as
+// LastSyncLatency and in the sync latency metric. The status is only persisted if it changed.
+//
+// The target Secrets are already written at this point, so a conflicting status update (the OCISecret
+// changed since it was read) is retried on the latest version of the OCISecret instead of failing the
+// reconcile and syncing again. The generation that was synced is recorded, a spec change in between
+// therefore still triggers a sync.
+func (r *OCISecretReconciler) markSynced(ctx context.Context, ocisecret *ocisyncv1aplha1.OCISecret,
+	authMode, mirror, forceSync, syncedDigest string, fileDigests map[string]string) error {
+	generation := ocisecret.Generation
+	now := metav1.Now()
+	var latency *metav1.Duration
+	if pending := ocisecret.Status.PendingDigestTime; syncedDigest != "" &&
+		syncedDigest == ocisecret.Status.PendingDigest && pending != nil {
+		latency = &metav1.Duration{Duration: now.Sub(pending.Time)}
+	}
+	first := true
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if !first {
+			if err := r.Get(ctx, client.ObjectKeyFromObject(ocisecret), ocisecret); err != nil {
+				return err
+			}
+		}
+		first = false
+
+		previous := ocisecret.Status.DeepCopy()
+		if fileDigests != nil {
+			ocisecret.Status.FileDigests = fileDigests
+			ocisecret.Status.LastWriteTime = &now
+		}
+		if syncedDigest != "" {
+			ocisecret.Status.SyncHistory = addSyncHistory(ocisecret.Status.SyncHistory, syncedDigest, now)
+			ocisecret.Status.PendingDigest = ""
+			ocisecret.Status.PendingDigestTime = nil
+		}
+		if latency != nil {
+			ocisecret.Status.LastSyncLatency = latency
+		}
+		ocisecret.Status.ObservedGeneration = generation
+		ocisecret.Status.AuthMode = authMode
+		ocisecret.Status.Mirror = mirror
+		ocisecret.Status.LastForceSync = forceSync
+		ocisecret.Status.Plan = nil
+		meta.SetStatusCondition(&ocisecret.Status.Conditions, metav1.Condition{
+			Type:               ocisyncv1aplha1.ConditionTypeReady,
+			Status:             metav1.ConditionTrue,
+			Reason:             ocisyncv1aplha1.ReasonSynced,
+			Message:            "TargetSecret is in sync with the artefact",
+			ObservedGeneration: generation,
+		})
+		if equality.Semantic.DeepEqual(previous, &ocisecret.Status) {
+			return nil
+		}
+		return r.Status().Update(ctx, ocisecret)
+	})
+	if err == nil && latency != nil {
+		syncLatency.Observe(latency.Seconds())
+	}
+	return err
+}
+
+// observeDigest records the digest of the artifact as PendingDigest when it differs from the digest the
+// target Secret was last synced to, together with the time it was first observed, so the latency of the
+// sync can be measured even if it takes several reconciles. The first sync of an OCISecret doesn't
+// follow a change of the artifact and isn't measured. A pending digest is dropped when the artifact
+// changes back to the synced digest.
+func (r *OCISecretReconciler) observeDigest(ctx context.Context, ocisecret *ocisyncv1aplha1.OCISecret, digest string) error {
+	status := &ocisecret.Status
+	if len(status.SyncHistory) == 0 || status.PendingDigest == digest {
+		return nil
+	}
+	if status.SyncHistory[0].Digest == digest {
+		if status.PendingDigest == "" {
+			return nil
+		}
+		status.PendingDigest = ""
+		status.PendingDigestTime = nil
+	} else {
+		now := metav1.Now()
+		status.PendingDigest = digest
+		status.PendingDigestTime = &now
+	}
+	return r.Status().Update(ctx, ocisecret)
+}
+
+// addSyncHistory returns the history with an entry for the digest in front, unless the newest entry
+// already has the digest (e.g. after a forced sync). The oldest entries beyond MaxSyncHistory are dropped.
+func addSyncHistory(history []ocisyncv1aplha1.SyncHistoryEntry, digest string,
+	now metav1.Time) []ocisyncv1aplha1.SyncHistoryEntry {
+	if len(history) > 0 && history[0].Digest == digest {
+		return history
+	}
+	history = append([]ocisyncv1aplha1.SyncHistoryEntry{{Digest: digest, Time: now}}, history...)
+	if len(history) > ocisyncv1aplha1.MaxSyncHistory {
+		history = history[:ocisyncv1aplha1.MaxSyncHistory]
+	}
+	return history
+}
+
+// failSync handles a sync failure that retrying quickly won't fix (e.g. an invalid signature or an
+// oversized artefact). It sets the Ready condition to false with the given reason, records the error
+// and requeues at the regular poll interval so the artefact is checked again.
+func (r *OCISecretReconciler) failSync(ctx context.Context, ocisecret *ocisyncv1aplha1.OCISecret,
+	record *SyncRecord, reason string, syncErr error) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	logger.Info("Sync failed.", "reason", reason, "error", syncErr.Error())
+
+	if err := r.setCondition(ctx, ocisecret, ocisyncv1aplha1.ConditionTypeReady, metav1.ConditionFalse,
+		reason, syncErr.Error()); err != nil {
+		logger.Error(err, "Failed to update OCISecret status.")
+		return ctrl.Result{}, err
+	}
+	record.Result = SyncResultError
+	record.Error = syncErr.Error()
+	return ctrl.Result{RequeueAfter: time.Duration(60) * time.Second}, nil
+}
+
+// skipGatedSync handles an artifact with the digest that doesn't meet MinVersion or NotBefore (gateErr). The
+// target Secrets keep the content of the last artifact that did, the SyncSkipped condition reports the
+// skipped artifact and the reconcile is requeued at the regular interval so a newer artifact is picked up.
+func (r *OCISecretReconciler) skipGatedSync(ctx context.Context, ocisecret *ocisyncv1aplha1.OCISecret,
+	record *SyncRecord, digest string, gateErr error) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	logger.Info("Artefact does not meet the sync gate, skipping the sync.", "reason", gateErr.Error())
+
+	if err := r.updateSyncSkippedCondition(ctx, ocisecret, gateErr); err != nil {
+		logger.Error(err, "Failed to update OCISecret status.")
+		return ctrl.Result{}, err
+	}
+	record.Result = SyncResultSkipped
+	record.Digest = digest
+	return ctrl.Result{RequeueAfter: requeueInterval(ocisecret, time.Now())}, nil
+}
+
+// abandonReconcile reports a reconcile of the OCISecret that was cancelled after the MaxReconcileDuration
+// with the TimedOut reason, see failSync. Registry requests and Secret writes that were still running are
+// cancelled; the next reconcile starts over.
+func (r *OCISecretReconciler) abandonReconcile(ctx context.Context, req ctrl.Request, record *SyncRecord) (ctrl.Result, error) {
+	ocisecret := &ocisyncv1aplha1.OCISecret{}
+	if err := r.Get(ctx, req.NamespacedName, ocisecret); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	return r.failSync(ctx, ocisecret, record, ocisyncv1aplha1.ReasonTimedOut,
+		fmt.Errorf("the reconcile was abandoned after %s", r.MaxReconcileDuration))
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+)
+
+var _ = Describe("Sync history", func() {
+	now := metav1.NewTime(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	It("should add new digests in front", func() {
+		history := addSyncHistory(nil, "sha256:1", now)
+		history = addSyncHistory(history, "sha256:2", now)
+		Expect(history).To(Equal([]ocisyncv1aplha1.SyncHistoryEntry{
+			{Digest: "sha256:2", Time: now},
+			{Digest: "sha256:1", Time: now},
+		}))
+	})
+
+	It("should not repeat the newest digest", func() {
+		history := addSyncHistory(nil, "sha256:1", now)
+		Expect(addSyncHistory(history, "sha256:1", metav1.NewTime(now.Add(time.Hour)))).To(Equal(history))
+	})
+
+	It("should keep the newest entries only", func() {
+		var history []ocisyncv1aplha1.SyncHistoryEntry
+		for i := range ocisyncv1aplha1.MaxSyncHistory + 5 {
+			history = addSyncHistory(history, fmt.Sprintf("sha256:%d", i), now)
+		}
+		Expect(history).To(HaveLen(ocisyncv1aplha1.MaxSyncHistory))
+		Expect(history[0].Digest).To(Equal(fmt.Sprintf("sha256:%d", ocisyncv1aplha1.MaxSyncHistory+4)))
+	})
+})
+
+var _ = Describe("Marking an OCISecret as synced", func() {
+	ctx := context.Background()
+	var ocisecret *ocisyncv1aplha1.OCISecret
+
+	BeforeEach(func() {
+		ocisecret = newTestOCISecret("mark-synced")
+		Expect(k8sClient.Create(ctx, ocisecret)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.Delete(ctx, ocisecret)).To(Succeed())
+	})
+
+	It("should retry a conflicting status update on the latest version", func() {
+		stale := ocisecret.DeepCopy()
+		ocisecret.Status.AuthMode = ocisyncv1aplha1.AuthModeAnonymous
+		Expect(k8sClient.Status().Update(ctx, ocisecret)).To(Succeed())
+
+		reconciler := &OCISecretReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		Expect(reconciler.markSynced(ctx, stale, ocisyncv1aplha1.AuthModeAnonymous, "", "", "sha256:1", nil)).To(Succeed())
+		Expect(stale.Status.ObservedGeneration).To(Equal(ocisecret.Generation))
+		Expect(stale.Status.SyncHistory).To(HaveLen(1))
+	})
+
+	It("should record the latency of syncing a new digest", func() {
+		reconciler := &OCISecretReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+
+		// The first sync doesn't follow a change of the artifact
+		Expect(reconciler.observeDigest(ctx, ocisecret, "sha256:1")).To(Succeed())
+		Expect(ocisecret.Status.PendingDigest).To(BeEmpty())
+		Expect(reconciler.markSynced(ctx, ocisecret, ocisyncv1aplha1.AuthModeAnonymous, "", "", "sha256:1", nil)).To(Succeed())
+		Expect(ocisecret.Status.LastSyncLatency).To(BeNil())
+
+		// A new digest is pending until it is synced, observing it again keeps the first time
+		Expect(reconciler.observeDigest(ctx, ocisecret, "sha256:2")).To(Succeed())
+		Expect(ocisecret.Status.PendingDigest).To(Equal("sha256:2"))
+		observed := metav1.NewTime(ocisecret.Status.PendingDigestTime.Add(-time.Minute).Truncate(time.Second))
+		ocisecret.Status.PendingDigestTime = &observed
+		Expect(k8sClient.Status().Update(ctx, ocisecret)).To(Succeed())
+		Expect(reconciler.observeDigest(ctx, ocisecret, "sha256:2")).To(Succeed())
+		Expect(ocisecret.Status.PendingDigestTime.Time).To(BeTemporally("==", observed.Time))
+
+		Expect(reconciler.markSynced(ctx, ocisecret, ocisyncv1aplha1.AuthModeAnonymous, "", "", "sha256:2", nil)).To(Succeed())
+		Expect(ocisecret.Status.PendingDigest).To(BeEmpty())
+		Expect(ocisecret.Status.PendingDigestTime).To(BeNil())
+		Expect(ocisecret.Status.LastSyncLatency).NotTo(BeNil())
+		Expect(ocisecret.Status.LastSyncLatency.Duration).To(BeNumerically(">=", time.Minute))
+
+		// A tag moving back to the synced digest drops the pending digest
+		Expect(reconciler.observeDigest(ctx, ocisecret, "sha256:3")).To(Succeed())
+		Expect(reconciler.observeDigest(ctx, ocisecret, "sha256:2")).To(Succeed())
+		Expect(ocisecret.Status.PendingDigest).To(BeEmpty())
+	})
+})
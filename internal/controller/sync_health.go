@@ -0,0 +1,99 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// SyncHealth tracks the outcome of the latest reconcile of every OCISecret in memory and reports the
+// operator as unhealthy when syncs stop succeeding. Its Check method is a healthz.Checker.
+type SyncHealth struct {
+	// Window is how long the operator stays healthy without any successful sync; zero disables the check
+	Window time.Duration
+	// MaxFailureRatio is the fraction of failing OCISecrets (0 to 1) above which the operator is
+	// unhealthy; zero disables the check
+	MaxFailureRatio float64
+
+	now         func() time.Time
+	mu          sync.Mutex
+	failing     map[types.NamespacedName]bool
+	lastSuccess time.Time
+}
+
+// NewSyncHealth returns a SyncHealth with the window and maximum failure ratio. The window starts
+// now, so the operator is healthy until it has had the chance to sync.
+func NewSyncHealth(window time.Duration, maxFailureRatio float64) *SyncHealth {
+	return &SyncHealth{
+		Window:          window,
+		MaxFailureRatio: maxFailureRatio,
+		now:             time.Now,
+		failing:         map[types.NamespacedName]bool{},
+		lastSuccess:     time.Now(),
+	}
+}
+
+// recordResult records the result (see SyncRecord.Result) of the latest reconcile of the OCISecret.
+// OCISecrets that were deleted or are suspended aren't tracked, throttled reconciles are ignored.
+func (h *SyncHealth) recordResult(name types.NamespacedName, result string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch result {
+	case SyncResultNotFound, SyncResultSuspended:
+		delete(h.failing, name)
+	case SyncResultError:
+		h.failing[name] = true
+	case SyncResultThrottled:
+		// Postponed syncs neither succeed nor fail
+	default:
+		h.failing[name] = false
+		h.lastSuccess = h.now()
+	}
+}
+
+// Check returns an error if no OCISecret synced successfully within the window or if more than
+// MaxFailureRatio of the OCISecrets are failing. Without any OCISecret the operator is healthy.
+func (h *SyncHealth) Check(_ *http.Request) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.failing) == 0 {
+		return nil
+	}
+
+	if h.Window > 0 {
+		if since := h.now().Sub(h.lastSuccess); since > h.Window {
+			return fmt.Errorf("no OCISecret synced successfully for %s", since.Round(time.Second))
+		}
+	}
+	if h.MaxFailureRatio > 0 {
+		failures := 0
+		for _, failing := range h.failing {
+			if failing {
+				failures++
+			}
+		}
+		if ratio := float64(failures) / float64(len(h.failing)); ratio > h.MaxFailureRatio {
+			return fmt.Errorf("%d of %d OCISecrets are failing to sync", failures, len(h.failing))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("Sync health", func() {
+	var health *SyncHealth
+	var now time.Time
+	first := types.NamespacedName{Name: "first", Namespace: "default"}
+	second := types.NamespacedName{Name: "second", Namespace: "default"}
+
+	BeforeEach(func() {
+		now = time.Now()
+		health = NewSyncHealth(10*time.Minute, 0.5)
+		health.now = func() time.Time { return now }
+	})
+
+	It("should be healthy without OCISecrets", func() {
+		now = now.Add(time.Hour)
+		Expect(health.Check(nil)).To(Succeed())
+	})
+
+	It("should be unhealthy when no sync succeeded within the window", func() {
+		health.recordResult(first, SyncResultSynced)
+		Expect(health.Check(nil)).To(Succeed())
+
+		now = now.Add(11 * time.Minute)
+		health.recordResult(first, SyncResultError)
+		Expect(health.Check(nil)).To(MatchError(ContainSubstring("no OCISecret synced successfully")))
+
+		health.recordResult(first, SyncResultUnchanged)
+		Expect(health.Check(nil)).To(Succeed())
+	})
+
+	It("should be unhealthy when too many OCISecrets are failing", func() {
+		health.recordResult(first, SyncResultSynced)
+		health.recordResult(second, SyncResultError)
+		Expect(health.Check(nil)).To(Succeed())
+
+		health.recordResult(first, SyncResultError)
+		Expect(health.Check(nil)).To(MatchError(ContainSubstring("2 of 2 OCISecrets are failing")))
+
+		// Deleted OCISecrets don't count
+		health.recordResult(first, SyncResultNotFound)
+		health.recordResult(second, SyncResultNotFound)
+		Expect(health.Check(nil)).To(Succeed())
+	})
+})
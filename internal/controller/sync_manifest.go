@@ -0,0 +1,63 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"sort"
+	"time"
+)
+
+// syncManifest is the content of the ocisyncv1aplha1.SyncManifestKey key of a target Secret.
+type syncManifest struct {
+	// Digest is the digest of the synced artifact
+	Digest string `json:"digest"`
+	// SyncedAt is the time of the sync in RFC 3339 format
+	SyncedAt string `json:"syncedAt"`
+	// Files are the synced files, sorted by name
+	Files []syncManifestFile `json:"files"`
+}
+
+// syncManifestFile describes a file of a syncManifest.
+type syncManifestFile struct {
+	Name string `json:"name"`
+	Size int    `json:"size"`
+	// Mode are the octal permission bits of the file in the artifact (e.g. 0755), so consumers writing
+	// the files to disk can restore them; empty for keys that aren't files of the artifact
+	Mode string `json:"mode,omitempty"`
+}
+
+// encodeSyncManifest returns the JSON encoded manifest of the files synced from the artifact with the digest,
+// including the permission bits of the files listed in modes.
+func encodeSyncManifest(files map[string][]byte, modes map[string]fs.FileMode, digest string, syncedAt time.Time) ([]byte, error) {
+	manifest := syncManifest{
+		Digest:   digest,
+		SyncedAt: syncedAt.UTC().Format(time.RFC3339),
+		Files:    make([]syncManifestFile, 0, len(files)),
+	}
+	for name, content := range files {
+		file := syncManifestFile{Name: name, Size: len(content)}
+		if mode, ok := modes[name]; ok {
+			file.Mode = fmt.Sprintf("%04o", uint32(mode.Perm()))
+		}
+		manifest.Files = append(manifest.Files, file)
+	}
+	sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].Name < manifest.Files[j].Name })
+	return json.Marshal(manifest)
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"io/fs"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sync manifest", func() {
+	It("should list the files sorted by name with the digest and the time of the sync", func() {
+		syncedAt := time.Date(2025, 3, 1, 12, 30, 0, 0, time.FixedZone("CET", 3600))
+		manifest, err := encodeSyncManifest(map[string][]byte{"b.yaml": []byte("hello"), "a.txt": {}},
+			map[string]fs.FileMode{"b.yaml": 0o755}, "sha256:abc", syncedAt)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifest).To(MatchJSON(`{
+			"digest": "sha256:abc",
+			"syncedAt": "2025-03-01T11:30:00Z",
+			"files": [{"name": "a.txt", "size": 0}, {"name": "b.yaml", "size": 5, "mode": "0755"}]
+		}`))
+	})
+})
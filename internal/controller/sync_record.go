@@ -0,0 +1,96 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Possible values of SyncRecord.Result.
+const (
+	// SyncResultSynced means the target Secret was written with new content.
+	SyncResultSynced = "synced"
+	// SyncResultUnchanged means the target Secret was already up to date.
+	SyncResultUnchanged = "unchanged"
+	// SyncResultDryRun means the changes were only planned because the OCISecret is in dry-run mode.
+	SyncResultDryRun = "dryRun"
+	// SyncResultAudited means the target Secrets were only compared with the artifact because the operator
+	// runs with --audit-only.
+	SyncResultAudited = "audited"
+	// SyncResultSuspended means nothing was synced because the OCISecret is suspended.
+	SyncResultSuspended = "suspended"
+	// SyncResultSkipped means nothing was synced because the artifact doesn't meet MinVersion or NotBefore.
+	SyncResultSkipped = "skipped"
+	// SyncResultThrottled means the sync was postponed because the registry rate limit was reached, the
+	// registry throttled the pull (429) until its Retry-After or the circuit breaker of the registry is open.
+	SyncResultThrottled = "throttled"
+	// SyncResultNotFound means the OCISecret no longer exists.
+	SyncResultNotFound = "notFound"
+	// SyncResultError means the reconcile failed; SyncRecord.Error holds the reason.
+	SyncResultError = "error"
+)
+
+// SyncRecord is the structured completion record written as a single JSON line
+// for every reconcile when OCISecretReconciler.RecordWriter is set.
+//
+// The format is stable: fields may be added in the future, but existing fields
+// are never renamed, removed or changed in meaning. Example:
+//
+//	{"time":"2025-01-01T12:00:00Z","name":"my-secret","namespace":"team-a",
+//	 "registry":"ghcr.io/org/repo","reference":"v1","digest":"sha256:...",
+//	 "result":"synced","changedKeys":["app.yaml"],"durationMs":812,"pullDurationMs":640}
+type SyncRecord struct {
+	// Time is the moment the reconcile completed (RFC 3339).
+	Time time.Time `json:"time"`
+	// Name is the name of the reconciled OCISecret.
+	Name string `json:"name"`
+	// Namespace is the namespace of the reconciled OCISecret (empty for cluster-scoped OCISecrets).
+	Namespace string `json:"namespace"`
+	// Registry is the repository the artifact is pulled from.
+	Registry string `json:"registry"`
+	// Reference is the tag or digest of the artifact.
+	Reference string `json:"reference"`
+	// Digest is the resolved manifest digest, if it could be determined.
+	Digest string `json:"digest"`
+	// Result is one of synced, unchanged, dryRun, audited, suspended, skipped, throttled, notFound or error.
+	Result string `json:"result"`
+	// Error is the error message when Result is error.
+	Error string `json:"error,omitempty"`
+	// ChangedKeys are the sorted keys of the target Secret that were (or, in dry-run mode, would be)
+	// added, changed or removed.
+	ChangedKeys []string `json:"changedKeys"`
+	// DurationMs is the total duration of the reconcile in milliseconds.
+	DurationMs int64 `json:"durationMs"`
+	// PullDurationMs is the time spent downloading the artifact in milliseconds (0 if nothing was pulled).
+	PullDurationMs int64 `json:"pullDurationMs"`
+}
+
+// writeSyncRecord encodes the record as a single JSON line to w.
+func writeSyncRecord(w io.Writer, record *SyncRecord) error {
+	if record.ChangedKeys == nil {
+		record.ChangedKeys = []string{}
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	// Write the line in one call so concurrent reconciles never interleave records
+	_, err = w.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("Sync records", func() {
+	It("should emit exactly one record per reconcile with the documented fields", func() {
+		output := &bytes.Buffer{}
+		controllerReconciler := &OCISecretReconciler{
+			Client:       k8sClient,
+			Scheme:       k8sClient.Scheme(),
+			RecordWriter: output,
+		}
+
+		_, err := controllerReconciler.Reconcile(context.Background(), reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: "does-not-exist", Namespace: "default"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+		Expect(lines).To(HaveLen(1))
+
+		record := map[string]interface{}{}
+		Expect(json.Unmarshal([]byte(lines[0]), &record)).To(Succeed())
+		Expect(record).To(HaveKey("time"))
+		Expect(record).To(HaveKey("registry"))
+		Expect(record).To(HaveKey("reference"))
+		Expect(record).To(HaveKey("digest"))
+		Expect(record).To(HaveKey("durationMs"))
+		Expect(record).To(HaveKey("pullDurationMs"))
+		Expect(record).To(HaveKeyWithValue("name", "does-not-exist"))
+		Expect(record).To(HaveKeyWithValue("namespace", "default"))
+		Expect(record).To(HaveKeyWithValue("result", SyncResultNotFound))
+		Expect(record).To(HaveKeyWithValue("changedKeys", BeEmpty()))
+		Expect(record).NotTo(HaveKey("error"))
+	})
+
+	It("should not emit records without a RecordWriter", func() {
+		controllerReconciler := &OCISecretReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+
+		_, err := controllerReconciler.Reconcile(context.Background(), reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: "does-not-exist", Namespace: "default"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
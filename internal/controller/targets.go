@@ -0,0 +1,482 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	v1core "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+	"github.com/mariusbertram/oci-resource-sync-operator/internal/utils"
+)
+
+// revisionAnnotation is the annotation of a target Secret that holds the digest of the synced artifact.
+const revisionAnnotation = "oci-sync.brtrm.de/revision"
+
+// legacyRevisionAnnotation is the revisionAnnotation of target Secrets written by earlier versions of the
+// operator. It is still read (see secretRevision) and replaced by the revisionAnnotation on the next write.
+const legacyRevisionAnnotation = "OCISecret.operator.rev"
+
+// secretRevision returns the revision of the target Secret, falling back to the legacyRevisionAnnotation,
+// so Secrets synced by earlier versions aren't downloaded again after an upgrade.
+func secretRevision(secret *v1core.Secret) (string, bool) {
+	if revision, ok := secret.Annotations[revisionAnnotation]; ok {
+		return revision, true
+	}
+	revision, ok := secret.Annotations[legacyRevisionAnnotation]
+	return revision, ok
+}
+
+// placeholderRevision is the revision of a target Secret that was created but not synced yet.
+const placeholderRevision = "00000"
+
+// managedKeysAnnotation is the annotation of a target Secret in merge mode that lists the keys written
+// from the artifact as a JSON array, so they can be deleted once their files are removed.
+const managedKeysAnnotation = "oci-sync.brtrm.de/managed-keys"
+
+// managedByLabel is the label marking the target Secrets written by the operator, its value is the
+// fieldManager.
+const managedByLabel = "app.kubernetes.io/managed-by"
+
+// ownerAnnotation is the annotation of a target Secret that holds the namespace/name of the OCISecret
+// that wrote it. Unlike the owner reference it survives the deletion of the OCISecret, so a recreated
+// OCISecret with the same name recognises its Secrets (see orphanedTarget).
+const ownerAnnotation = "oci-sync.brtrm.de/ocisecret"
+
+// mergedData returns the data of the target Secret after writing the files in the merge mode. In merge
+// mode the keys of the files are set, the keys of the managedKeysAnnotation that aren't files anymore
+// are deleted and all other keys are kept. Otherwise the files replace the data.
+func mergedData(secret *v1core.Secret, files map[string][]byte, mergeMode ocisyncv1aplha1.MergeMode) map[string][]byte {
+	if mergeMode != ocisyncv1aplha1.MergeModeMerge {
+		return files
+	}
+
+	data := make(map[string][]byte, len(secret.Data)+len(files))
+	for key, value := range secret.Data {
+		data[key] = value
+	}
+	for _, key := range managedKeys(secret) {
+		delete(data, key)
+	}
+	for key, value := range files {
+		data[key] = value
+	}
+	return data
+}
+
+// managedKeys returns the keys of the target Secret that were written from the artifact by the last sync.
+func managedKeys(secret *v1core.Secret) []string {
+	if value, ok := secret.Annotations[managedKeysAnnotation]; ok {
+		var managed []string
+		if err := json.Unmarshal([]byte(value), &managed); err == nil {
+			return managed
+		}
+		// A broken annotation doesn't tell which keys were written, so all are kept
+		return nil
+	}
+	if revision, ok := secretRevision(secret); ok && revision != placeholderRevision {
+		// The last sync replaced the data (replace mode doesn't track the keys), so all keys came from the artifact
+		managed := make([]string, 0, len(secret.Data))
+		for key := range secret.Data {
+			managed = append(managed, key)
+		}
+		return managed
+	}
+	// The Secret was never synced, e.g. it is being adopted, so no key came from the artifact
+	return nil
+}
+
+// errSecretTooLarge is returned when the data of a target Secret would exceed the size limit of Secrets.
+var errSecretTooLarge = errors.New("the data exceeds the size limit of a Secret")
+
+// secretDataSize returns the size of the data as counted against v1core.MaxSecretSize: the total size
+// of the values.
+func secretDataSize(data map[string][]byte) int {
+	size := 0
+	for _, value := range data {
+		size += len(value)
+	}
+	return size
+}
+
+// checkSecretSize returns errSecretTooLarge (wrapped) if the API server would reject a Secret with the
+// data, because its values exceed v1core.MaxSecretSize in total.
+func checkSecretSize(data map[string][]byte) error {
+	if size := secretDataSize(data); size > v1core.MaxSecretSize {
+		return fmt.Errorf("%w: %d bytes, at most %d bytes are allowed, select fewer files with Sync.Files",
+			errSecretTooLarge, size, v1core.MaxSecretSize)
+	}
+	return nil
+}
+
+// removedKeys returns the keys of the target Secret, sorted, that are deleted when the files are
+// written in the merge mode, see mergedData.
+func removedKeys(secret *v1core.Secret, files map[string][]byte, mergeMode ocisyncv1aplha1.MergeMode) []string {
+	data := mergedData(secret, files, mergeMode)
+	var removed []string
+	for key := range secret.Data {
+		if _, ok := data[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+	sort.Strings(removed)
+	return removed
+}
+
+// propagatedAnnotations returns the annotations of the artifact's manifest whose keys match one of the
+// patterns, see utils.MatchesAny. The annotations the operator maintains itself are never propagated.
+func propagatedAnnotations(annotations map[string]string, patterns []string) map[string]string {
+	propagated := map[string]string{}
+	for key, value := range annotations {
+		if key == revisionAnnotation || key == legacyRevisionAnnotation || key == managedKeysAnnotation ||
+			key == ownerAnnotation || key == syncedAtAnnotation {
+			continue
+		}
+		if utils.MatchesAny(key, patterns) {
+			propagated[key] = value
+		}
+	}
+	return propagated
+}
+
+// appliedSecret returns the target Secret the operator applies for the files of the artifact with the
+// digest: the files, the labels and annotations of Spec.TargetSecretMetadata, the propagated annotations,
+// the revision annotation, the keys written from the artifact in merge mode and the owner reference to
+// the OCISecret, which also adopts an existing Secret. Later labels and annotations take precedence, so
+// the ones the operator relies on can't be overridden.
+func appliedSecret(secret *v1core.Secret, ocisecret *ocisyncv1aplha1.OCISecret, files map[string][]byte,
+	annotations map[string]string, digest string) *v1core.Secret {
+	applied := newTargetSecret(ocisecret, secret.Name, secret.Namespace)
+	if metadata := ocisecret.Spec.TargetSecretMetadata; metadata != nil {
+		for key, value := range metadata.Labels {
+			applied.Labels[key] = value
+		}
+		for key, value := range metadata.Annotations {
+			applied.Annotations[key] = value
+		}
+	}
+	for key, value := range annotations {
+		applied.Annotations[key] = value
+	}
+	applied.Labels[managedByLabel] = fieldManager
+	applied.Annotations[ownerAnnotation] = ocisecret.Namespace + "/" + ocisecret.Name
+	applied.Annotations[revisionAnnotation] = digest
+	applied.Data = files
+	if ocisecret.Spec.MergeMode == ocisyncv1aplha1.MergeModeMerge {
+		keys := make([]string, 0, len(files))
+		for key := range files {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		managed, _ := json.Marshal(keys)
+		applied.Annotations[managedKeysAnnotation] = string(managed)
+	}
+	return applied
+}
+
+// applyTargetSecret writes the files and annotations of the artifact with the digest into the target
+// Secret with server-side apply, so the operator only owns the keys and annotations it writes and changes
+// of other fields, e.g. by other controllers, neither conflict nor get lost. Annotations it applied before
+// but no longer applies are removed by the apply. Keys to be deleted (see mergedData), a stale
+// managed-keys annotation and the legacyRevisionAnnotation are removed with a merge patch first, because
+// apply doesn't remove fields that other field managers own. The owner references of a deleted OCISecret
+// with the same name are removed by the patch as well, since a Secret can't have two controllers. The
+// Secret is created if it doesn't exist (empty ResourceVersion). The write is traced as the span
+// "update-secret".
+func (r *OCISecretReconciler) applyTargetSecret(ctx context.Context, secret *v1core.Secret,
+	ocisecret *ocisyncv1aplha1.OCISecret, files map[string][]byte, annotations map[string]string, digest string) error {
+	ctx, span := tracer.Start(ctx, "update-secret", trace.WithAttributes(
+		attribute.String("namespace", secret.Namespace), attribute.String("name", secret.Name),
+		attribute.Int("keys", len(files)), attribute.Int("bytes", secretDataSize(files))))
+	err := r.writeTargetSecret(ctx, secret, ocisecret, files, annotations, digest)
+	endSpan(span, err)
+	return err
+}
+
+// writeTargetSecret writes the target Secret, see applyTargetSecret.
+func (r *OCISecretReconciler) writeTargetSecret(ctx context.Context, secret *v1core.Secret,
+	ocisecret *ocisyncv1aplha1.OCISecret, files map[string][]byte, annotations map[string]string, digest string) error {
+	removed := removedKeys(secret, files, ocisecret.Spec.MergeMode)
+	_, staleManagedKeys := secret.Annotations[managedKeysAnnotation]
+	staleManagedKeys = staleManagedKeys && ocisecret.Spec.MergeMode != ocisyncv1aplha1.MergeModeMerge
+	owners := currentOwners(secret, ocisecret)
+	staleOwners := len(owners) != len(secret.OwnerReferences)
+	_, legacyRevision := secret.Annotations[legacyRevisionAnnotation]
+	if secret.ResourceVersion != "" && (len(removed) > 0 || staleManagedKeys || staleOwners || legacyRevision) {
+		original := secret.DeepCopy()
+		for _, key := range removed {
+			delete(secret.Data, key)
+		}
+		delete(secret.Annotations, managedKeysAnnotation)
+		delete(secret.Annotations, legacyRevisionAnnotation)
+		secret.OwnerReferences = owners
+		if err := r.Patch(ctx, secret, client.MergeFrom(original)); err != nil {
+			return err
+		}
+	}
+	applied := appliedSecret(secret, ocisecret, files, annotations, digest)
+	if err := r.Patch(ctx, applied, client.Apply, client.FieldOwner(fieldManager), client.ForceOwnership); err != nil {
+		return err
+	}
+	applied.DeepCopyInto(secret)
+	return nil
+}
+
+// fieldManager is the field manager of the fields of the target Secrets the operator applies.
+const fieldManager = "oci-resource-sync-operator"
+
+// newTargetSecret returns an empty target Secret owned by the OCISecret, so the Secret is deleted
+// together with the OCISecret, unless the OCISecret doesn't set owner references (see setsOwnerReference).
+// It carries the managed-by label and the owner annotation, which identify it after the OCISecret is
+// recreated. The type information is set for server-side apply.
+func newTargetSecret(ocisecret *ocisyncv1aplha1.OCISecret, name, namespace string) *v1core.Secret {
+	secret := &v1core.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				managedByLabel: fieldManager,
+			},
+			Annotations: map[string]string{
+				revisionAnnotation: placeholderRevision,
+				ownerAnnotation:    ocisecret.Namespace + "/" + ocisecret.Name,
+			},
+		},
+	}
+	if setsOwnerReference(ocisecret) {
+		secret.OwnerReferences = []metav1.OwnerReference{ownerReference(ocisecret)}
+	}
+	return secret
+}
+
+// setsOwnerReference reports whether the target Secrets of the OCISecret get an owner reference to it,
+// which is the default. Otherwise the cleanupFinalizer deletes them.
+func setsOwnerReference(ocisecret *ocisyncv1aplha1.OCISecret) bool {
+	return ocisecret.Spec.SetOwnerReference == nil || *ocisecret.Spec.SetOwnerReference
+}
+
+// ownerReference returns the controller owner reference to the OCISecret set on its target Secrets.
+func ownerReference(ocisecret *ocisyncv1aplha1.OCISecret) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		// Objects read without the cache have no type information, so set it explicitly
+		APIVersion:         ocisyncv1aplha1.GroupVersion.String(),
+		Kind:               "OCISecret",
+		Name:               ocisecret.Name,
+		UID:                ocisecret.UID,
+		Controller:         pointer.Bool(true),
+		BlockOwnerDeletion: pointer.Bool(true),
+	}
+}
+
+// errTargetConflict is returned when a target Secret is a credential Secret of the OCISecret or
+// already exists without being managed by the OCISecret.
+var errTargetConflict = errors.New("the target Secret is not managed by the OCISecret")
+
+// controlledBy reports whether the OCISecret is the controller owner of the Secret. The Secrets of an
+// OCISecret that doesn't set owner references are recognised by the managed-by label and the owner
+// annotation instead, as long as no other controller owns them.
+func controlledBy(secret *v1core.Secret, ocisecret *ocisyncv1aplha1.OCISecret) bool {
+	owner := metav1.GetControllerOf(secret)
+	if owner == nil && !setsOwnerReference(ocisecret) {
+		return secret.Labels[managedByLabel] == fieldManager &&
+			secret.Annotations[ownerAnnotation] == ocisecret.Namespace+"/"+ocisecret.Name
+	}
+	return owner != nil && owner.UID == ocisecret.UID
+}
+
+// orphanedTarget reports whether the Secret was written for a previous OCISecret with the same namespace
+// and name that was deleted, e.g. by a GitOps tool deleting and recreating the OCISecret, and can be
+// adopted. Such a Secret is controlled by nobody, because the owner reference was removed when the
+// OCISecret was deleted with the orphan propagation, or by an OCISecret with the same name but another
+// UID, because the garbage collector hasn't deleted it yet. It is recognised by the managed-by label and
+// the owner annotation, or for Secrets written before those were set, by the stale owner reference in
+// the namespace of the OCISecret.
+func orphanedTarget(secret *v1core.Secret, ocisecret *ocisyncv1aplha1.OCISecret) bool {
+	owner := metav1.GetControllerOf(secret)
+	if owner != nil && (owner.Kind != "OCISecret" || owner.Name != ocisecret.Name || owner.UID == ocisecret.UID) {
+		return false
+	}
+	if secret.Labels[managedByLabel] == fieldManager &&
+		secret.Annotations[ownerAnnotation] == ocisecret.Namespace+"/"+ocisecret.Name {
+		return true
+	}
+	return owner != nil && secret.Namespace == ocisecret.Namespace
+}
+
+// currentOwners returns the owner references of the Secret without the ones to a previous OCISecret
+// with the same name.
+func currentOwners(secret *v1core.Secret, ocisecret *ocisyncv1aplha1.OCISecret) []metav1.OwnerReference {
+	owners := make([]metav1.OwnerReference, 0, len(secret.OwnerReferences))
+	for _, owner := range secret.OwnerReferences {
+		if owner.Kind == "OCISecret" && owner.Name == ocisecret.Name && owner.UID != ocisecret.UID {
+			continue
+		}
+		owners = append(owners, owner)
+	}
+	return owners
+}
+
+// checkTargetConflicts returns errTargetConflict (wrapped) if one of the target Secrets of the
+// OCISecret is also referenced for its credentials, or exists but isn't controlled by the OCISecret,
+// e.g. a hand-created Secret or the target of another OCISecret. Writing such a Secret would destroy
+// its data. With AdoptExisting, existing Secrets without a controller (but not the credential Secrets)
+// are accepted and adopted when they are written. Secrets left behind by a previous OCISecret with the
+// same name (see orphanedTarget) are always adopted. The namespaces of the references have to be
+// resolved already.
+func (r *OCISecretReconciler) checkTargetConflicts(ctx context.Context, ocisecret *ocisyncv1aplha1.OCISecret) error {
+	type namedTarget struct {
+		field string
+		name  types.NamespacedName
+	}
+	spec := ocisecret.Spec
+	targets := []namedTarget{
+		{field: "targetSecret", name: types.NamespacedName{Name: spec.TargetSecret.Name, Namespace: spec.TargetSecret.Namespace}},
+	}
+	for i, target := range spec.AdditionalTargets {
+		targets = append(targets, namedTarget{field: fmt.Sprintf("additionalTargets[%d]", i),
+			name: types.NamespacedName{Name: target.Name, Namespace: target.Namespace}})
+	}
+
+	credentials := map[types.NamespacedName]string{}
+	if spec.ArtefactPullSecret.Name != "" {
+		credentials[types.NamespacedName{Name: spec.ArtefactPullSecret.Name, Namespace: spec.ArtefactPullSecret.Namespace}] = "ArtefactPullSecret"
+	}
+	if ref := spec.BasicAuthSecretRef; ref != nil {
+		credentials[types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}] = "basicAuthSecretRef"
+	}
+	if ref := spec.ClientCertSecretRef; ref != nil {
+		credentials[types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}] = "clientCertSecretRef"
+	}
+
+	for _, target := range targets {
+		if credentialField, ok := credentials[target.name]; ok {
+			return fmt.Errorf("%w: %s %s is also the %s", errTargetConflict, target.field, target.name, credentialField)
+		}
+		secret := &v1core.Secret{}
+		err := r.Get(ctx, target.name, secret)
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if controlledBy(secret, ocisecret) {
+			continue
+		}
+		if orphanedTarget(secret, ocisecret) {
+			log.FromContext(ctx).Info("Adopting the target Secret of a previous OCISecret with the same name.",
+				"field", target.field, "name", target.name.Name, "namespace", target.name.Namespace)
+			continue
+		}
+		if owner := metav1.GetControllerOf(secret); owner != nil {
+			return fmt.Errorf("%w: %s %s is managed by %s %s", errTargetConflict, target.field, target.name, owner.Kind, owner.Name)
+		}
+		if !spec.AdoptExisting {
+			return fmt.Errorf("%w: %s %s already exists, set adoptExisting to take it over", errTargetConflict,
+				target.field, target.name)
+		}
+	}
+	return nil
+}
+
+// targetData returns the files of the artifact selected for the target, stored under their renamed keys.
+func targetData(files map[string][]byte, target ocisyncv1aplha1.SecretTarget) map[string][]byte {
+	selected := files
+	if len(target.Files) > 0 {
+		selected = make(map[string][]byte, len(target.Files))
+		for _, key := range target.Files {
+			if value, ok := files[key]; ok {
+				selected[key] = value
+			}
+		}
+	}
+
+	data := make(map[string][]byte, len(selected))
+	for key, value := range selected {
+		if renamed, ok := target.Rename[key]; ok {
+			key = renamed
+		}
+		data[key] = value
+	}
+	return data
+}
+
+// additionalTargetsOutdated reports whether any additional target Secret is missing or has not been
+// synced with the artifact digest yet.
+func (r *OCISecretReconciler) additionalTargetsOutdated(ctx context.Context, ocisecret *ocisyncv1aplha1.OCISecret, digest string) (bool, error) {
+	for _, target := range ocisecret.Spec.AdditionalTargets {
+		secret := &v1core.Secret{}
+		err := r.Get(ctx, types.NamespacedName{Name: target.Name, Namespace: target.Namespace}, secret)
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		} else if err != nil {
+			return false, err
+		}
+		if revision, _ := secretRevision(secret); revision != digest {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// syncAdditionalTargets writes the files and the propagated annotations of the artifact into every
+// additional target Secret that is not synced with the digest and the current spec yet, or every one of
+// them for a resync of ForceResyncInterval, creating the Secrets that don't exist. A target whose data
+// would exceed the size limit of a Secret fails with errSecretTooLarge (wrapped) before it is written.
+func (r *OCISecretReconciler) syncAdditionalTargets(ctx context.Context, ocisecret *ocisyncv1aplha1.OCISecret,
+	files map[string][]byte, annotations map[string]string, digest string, resync bool) error {
+	logger := log.FromContext(ctx)
+
+	for _, target := range ocisecret.Spec.AdditionalTargets {
+		secret := &v1core.Secret{}
+		err := r.Get(ctx, types.NamespacedName{Name: target.Name, Namespace: target.Namespace}, secret)
+		if apierrors.IsNotFound(err) {
+			secret = newTargetSecret(ocisecret, target.Name, target.Namespace)
+		} else if err != nil {
+			logger.Error(err, "Failed to get additional target Secret.", "name", target.Name, "namespace", target.Namespace)
+			return err
+		} else if revision, _ := secretRevision(secret); revision == digest && ocisecret.Status.ObservedGeneration == ocisecret.Generation && !resync {
+			// Spec changes, e.g. of the file selection or the metadata, are applied to synced targets as well
+			continue
+		}
+
+		data := targetData(files, target)
+		if err := checkSecretSize(mergedData(secret, data, ocisecret.Spec.MergeMode)); err != nil {
+			return fmt.Errorf("additional target Secret %s/%s: %w", target.Namespace, target.Name, err)
+		}
+		// Existing Secrets without a controller are adopted, checkTargetConflicts only lets them through with AdoptExisting
+		if err := r.applyTargetSecret(ctx, secret, ocisecret, data, annotations, digest); err != nil {
+			logger.Error(err, "Failed to write additional target Secret.", "name", target.Name, "namespace", target.Namespace)
+			return err
+		}
+		logger.Info("Updated additional target Secret.", "name", target.Name, "namespace", target.Namespace)
+	}
+	return nil
+}
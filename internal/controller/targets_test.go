@@ -0,0 +1,329 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+)
+
+var _ = Describe("Additional targets", func() {
+	files := map[string][]byte{
+		"app.yaml": []byte("app"),
+		"db.yaml":  []byte("db"),
+	}
+
+	It("should select and rename the files of a target", func() {
+		data := targetData(files, ocisyncv1aplha1.SecretTarget{
+			Files:  []string{"db.yaml", "missing.yaml"},
+			Rename: map[string]string{"db.yaml": "database.yaml"},
+		})
+		Expect(data).To(Equal(map[string][]byte{"database.yaml": []byte("db")}))
+	})
+
+	It("should select all files without a file list", func() {
+		Expect(targetData(files, ocisyncv1aplha1.SecretTarget{})).To(Equal(files))
+	})
+
+	It("should keep the keys not written from the artifact in merge mode", func() {
+		secret := &v1core.Secret{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}},
+			Data:       map[string][]byte{"manual.yaml": []byte("manual")},
+		}
+		Expect(removedKeys(secret, files, ocisyncv1aplha1.MergeModeMerge)).To(BeEmpty())
+		Expect(removedKeys(secret, files, ocisyncv1aplha1.MergeModeReplace)).To(Equal([]string{"manual.yaml"}))
+
+		// A file removed from the artifact is deleted, the manual key stays
+		secret.Data = map[string][]byte{"manual.yaml": []byte("manual"), "app.yaml": []byte("app"), "db.yaml": []byte("db")}
+		secret.Annotations[managedKeysAnnotation] = `["app.yaml","db.yaml"]`
+		Expect(removedKeys(secret, map[string][]byte{"app.yaml": []byte("v2")}, ocisyncv1aplha1.MergeModeMerge)).
+			To(Equal([]string{"db.yaml"}))
+	})
+
+	It("should treat all keys of a Secret synced in replace mode as managed", func() {
+		secret := &v1core.Secret{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{revisionAnnotation: "sha256:1"}},
+			Data:       map[string][]byte{"old.yaml": []byte("old")},
+		}
+		Expect(removedKeys(secret, files, ocisyncv1aplha1.MergeModeMerge)).To(Equal([]string{"old.yaml"}))
+
+		// A Secret that was never synced keeps its keys
+		secret.Annotations[revisionAnnotation] = placeholderRevision
+		Expect(removedKeys(secret, files, ocisyncv1aplha1.MergeModeMerge)).To(BeEmpty())
+	})
+
+	It("should apply the files, the revision and the owner reference", func() {
+		ocisecret := newTestOCISecret("applied")
+		secret := &v1core.Secret{ObjectMeta: metav1.ObjectMeta{Name: "target", Namespace: "default"}}
+
+		applied := appliedSecret(secret, ocisecret, files, nil, "sha256:1")
+		Expect(applied.APIVersion).To(Equal("v1"))
+		Expect(applied.Kind).To(Equal("Secret"))
+		Expect(applied.Name).To(Equal("target"))
+		Expect(applied.Data).To(Equal(files))
+		Expect(applied.Annotations).To(HaveKeyWithValue(revisionAnnotation, "sha256:1"))
+		Expect(applied.Annotations).NotTo(HaveKey(managedKeysAnnotation))
+		Expect(applied.Annotations).To(HaveKeyWithValue(ownerAnnotation, "default/applied"))
+		Expect(applied.Labels).To(HaveKeyWithValue(managedByLabel, fieldManager))
+		Expect(applied.OwnerReferences).To(HaveLen(1))
+
+		ocisecret.Spec.MergeMode = ocisyncv1aplha1.MergeModeMerge
+		applied = appliedSecret(secret, ocisecret, files, nil, "sha256:1")
+		Expect(applied.Annotations).To(HaveKeyWithValue(managedKeysAnnotation, `["app.yaml","db.yaml"]`))
+	})
+
+	It("should only propagate the selected manifest annotations", func() {
+		annotations := map[string]string{
+			"org.opencontainers.image.version":  "1.2.3",
+			"org.opencontainers.image.revision": "abc123",
+			"com.example.build":                 "42",
+			revisionAnnotation:                  "sha256:0",
+		}
+		Expect(propagatedAnnotations(annotations, nil)).To(BeEmpty())
+		Expect(propagatedAnnotations(annotations, []string{"org.opencontainers.image.*", revisionAnnotation})).To(Equal(
+			map[string]string{"org.opencontainers.image.version": "1.2.3", "org.opencontainers.image.revision": "abc123"}))
+
+		applied := appliedSecret(&v1core.Secret{}, newTestOCISecret("annotations"), files,
+			map[string]string{"org.opencontainers.image.version": "1.2.3"}, "sha256:1")
+		Expect(applied.Annotations).To(HaveKeyWithValue("org.opencontainers.image.version", "1.2.3"))
+		Expect(applied.Annotations).To(HaveKeyWithValue(revisionAnnotation, "sha256:1"))
+	})
+
+	It("should apply the target Secret metadata without overriding the operator's annotations", func() {
+		ocisecret := newTestOCISecret("metadata")
+		ocisecret.Spec.TargetSecretMetadata = &ocisyncv1aplha1.SecretMetadata{
+			Labels:      map[string]string{"backup": "daily"},
+			Annotations: map[string]string{"team": "payments", revisionAnnotation: "sha256:0"},
+		}
+
+		applied := appliedSecret(&v1core.Secret{}, ocisecret, files, nil, "sha256:1")
+		Expect(applied.Labels).To(Equal(map[string]string{"backup": "daily", managedByLabel: fieldManager}))
+		Expect(applied.Annotations).To(HaveKeyWithValue("team", "payments"))
+		Expect(applied.Annotations).To(HaveKeyWithValue(revisionAnnotation, "sha256:1"))
+	})
+
+	It("should recognise the target Secrets of a previous OCISecret with the same name", func() {
+		ocisecret := newTestOCISecret("recreated")
+		ocisecret.UID = "current-uid"
+		previous := newTestOCISecret("recreated")
+		previous.UID = "stale-uid"
+
+		// Still owned by the deleted OCISecret
+		Expect(orphanedTarget(newTargetSecret(previous, "target", "default"), ocisecret)).To(BeTrue())
+		Expect(orphanedTarget(newTargetSecret(ocisecret, "target", "default"), ocisecret)).To(BeFalse())
+
+		// Orphaned by the deletion, only the label and the annotation are left
+		orphaned := newTargetSecret(previous, "target", "default")
+		orphaned.OwnerReferences = nil
+		Expect(orphanedTarget(orphaned, ocisecret)).To(BeTrue())
+		orphaned.Labels = nil
+		Expect(orphanedTarget(orphaned, ocisecret)).To(BeFalse())
+
+		// Written by an OCISecret with another name
+		other := newTestOCISecret("other")
+		other.UID = "other-uid"
+		Expect(orphanedTarget(newTargetSecret(other, "target", "default"), ocisecret)).To(BeFalse())
+		written := newTargetSecret(other, "target", "default")
+		written.OwnerReferences = nil
+		Expect(orphanedTarget(written, ocisecret)).To(BeFalse())
+
+		Expect(currentOwners(newTargetSecret(previous, "target", "default"), ocisecret)).To(BeEmpty())
+	})
+
+	It("should read the revision from the legacy annotation", func() {
+		secret := &v1core.Secret{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{legacyRevisionAnnotation: "sha256:0"}}}
+		Expect(secretRevision(secret)).To(Equal("sha256:0"))
+		secret.Annotations[revisionAnnotation] = "sha256:1"
+		Expect(secretRevision(secret)).To(Equal("sha256:1"))
+		_, ok := secretRevision(&v1core.Secret{})
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should refuse data beyond the size limit of a Secret", func() {
+		Expect(checkSecretSize(files)).To(Succeed())
+		Expect(checkSecretSize(map[string][]byte{"a": make([]byte, v1core.MaxSecretSize)})).To(Succeed())
+		Expect(checkSecretSize(map[string][]byte{
+			"a": make([]byte, v1core.MaxSecretSize), "b": []byte("b"),
+		})).To(MatchError(errSecretTooLarge))
+	})
+
+	Context("When syncing the target Secrets", func() {
+		ctx := context.Background()
+		var ocisecret *ocisyncv1aplha1.OCISecret
+		var controllerReconciler *OCISecretReconciler
+		targetName := types.NamespacedName{Name: "additional-target", Namespace: "default"}
+
+		BeforeEach(func() {
+			ocisecret = newTestOCISecret("additional-targets")
+			ocisecret.Spec.AdditionalTargets = []ocisyncv1aplha1.SecretTarget{
+				{Name: targetName.Name, Namespace: targetName.Namespace, Files: []string{"app.yaml"}},
+			}
+			Expect(k8sClient.Create(ctx, ocisecret)).To(Succeed())
+			controllerReconciler = &OCISecretReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		})
+
+		AfterEach(func() {
+			Expect(k8sClient.Delete(ctx, ocisecret)).To(Succeed())
+			secret := &v1core.Secret{}
+			if k8sClient.Get(ctx, targetName, secret) == nil {
+				Expect(k8sClient.Delete(ctx, secret)).To(Succeed())
+			}
+		})
+
+		It("should create missing targets and track the digest per target", func() {
+			outdated, err := controllerReconciler.additionalTargetsOutdated(ctx, ocisecret, "sha256:1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outdated).To(BeTrue())
+
+			Expect(controllerReconciler.syncAdditionalTargets(ctx, ocisecret, files, nil, "sha256:1", false)).To(Succeed())
+
+			secret := &v1core.Secret{}
+			Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+			Expect(secret.Data).To(Equal(map[string][]byte{"app.yaml": []byte("app")}))
+			Expect(secret.Annotations).To(HaveKeyWithValue(revisionAnnotation, "sha256:1"))
+
+			outdated, err = controllerReconciler.additionalTargetsOutdated(ctx, ocisecret, "sha256:1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outdated).To(BeFalse())
+
+			outdated, err = controllerReconciler.additionalTargetsOutdated(ctx, ocisecret, "sha256:2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outdated).To(BeTrue())
+		})
+
+		It("should rewrite synced targets for a resync", func() {
+			Expect(controllerReconciler.syncAdditionalTargets(ctx, ocisecret, files, nil, "sha256:1", false)).To(Succeed())
+			secret := &v1core.Secret{}
+			Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+			resourceVersion := secret.ResourceVersion
+
+			annotations := map[string]string{syncedAtAnnotation: "2025-03-01T12:00:00Z"}
+			Expect(controllerReconciler.syncAdditionalTargets(ctx, ocisecret, files, annotations, "sha256:1", false)).To(Succeed())
+			Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+			Expect(secret.ResourceVersion).To(Equal(resourceVersion))
+
+			Expect(controllerReconciler.syncAdditionalTargets(ctx, ocisecret, files, annotations, "sha256:1", true)).To(Succeed())
+			Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+			Expect(secret.ResourceVersion).NotTo(Equal(resourceVersion))
+			Expect(secret.Annotations).To(HaveKeyWithValue(syncedAtAnnotation, "2025-03-01T12:00:00Z"))
+		})
+
+		It("should only accept target Secrets the OCISecret controls", func() {
+			Expect(controllerReconciler.syncAdditionalTargets(ctx, ocisecret, files, nil, "sha256:1", false)).To(Succeed())
+			Expect(controllerReconciler.checkTargetConflicts(ctx, ocisecret)).To(Succeed())
+
+			// A hand-created Secret with the same name must not be overwritten
+			secret := &v1core.Secret{}
+			Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+			secret.OwnerReferences = nil
+			secret.Labels = nil
+			Expect(k8sClient.Update(ctx, secret)).To(Succeed())
+			Expect(controllerReconciler.checkTargetConflicts(ctx, ocisecret)).To(MatchError(errTargetConflict))
+		})
+
+		It("should adopt unmanaged Secrets with AdoptExisting", func() {
+			Expect(k8sClient.Create(ctx, &v1core.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: targetName.Name, Namespace: targetName.Namespace},
+				Data:       map[string][]byte{"manual.yaml": []byte("manual")},
+			})).To(Succeed())
+			Expect(controllerReconciler.checkTargetConflicts(ctx, ocisecret)).To(MatchError(errTargetConflict))
+
+			ocisecret.Spec.AdoptExisting = true
+			Expect(controllerReconciler.checkTargetConflicts(ctx, ocisecret)).To(Succeed())
+			Expect(controllerReconciler.syncAdditionalTargets(ctx, ocisecret, files, nil, "sha256:1", false)).To(Succeed())
+
+			secret := &v1core.Secret{}
+			Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+			Expect(secret.Data).To(Equal(map[string][]byte{"app.yaml": []byte("app")}))
+			Expect(controlledBy(secret, ocisecret)).To(BeTrue())
+		})
+
+		It("should adopt the Secrets of a recreated OCISecret with a stale owner UID", func() {
+			// The OCISecret was deleted and recreated before the garbage collector deleted its target
+			previous := newTestOCISecret(ocisecret.Name)
+			previous.UID = "stale-uid"
+			stale := newTargetSecret(previous, targetName.Name, targetName.Namespace)
+			stale.Data = map[string][]byte{"app.yaml": []byte("previous")}
+			Expect(k8sClient.Create(ctx, stale)).To(Succeed())
+
+			Expect(controllerReconciler.checkTargetConflicts(ctx, ocisecret)).To(Succeed())
+			Expect(controllerReconciler.syncAdditionalTargets(ctx, ocisecret, files, nil, "sha256:1", false)).To(Succeed())
+
+			secret := &v1core.Secret{}
+			Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+			Expect(secret.Data).To(Equal(map[string][]byte{"app.yaml": []byte("app")}))
+			Expect(secret.OwnerReferences).To(HaveLen(1))
+			Expect(controlledBy(secret, ocisecret)).To(BeTrue())
+		})
+
+		It("should adopt the orphaned Secrets of a recreated OCISecret", func() {
+			previous := newTestOCISecret(ocisecret.Name)
+			previous.UID = "stale-uid"
+			orphaned := newTargetSecret(previous, targetName.Name, targetName.Namespace)
+			orphaned.OwnerReferences = nil
+			Expect(k8sClient.Create(ctx, orphaned)).To(Succeed())
+
+			Expect(controllerReconciler.checkTargetConflicts(ctx, ocisecret)).To(Succeed())
+			Expect(controllerReconciler.syncAdditionalTargets(ctx, ocisecret, files, nil, "sha256:1", false)).To(Succeed())
+
+			secret := &v1core.Secret{}
+			Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+			Expect(controlledBy(secret, ocisecret)).To(BeTrue())
+		})
+
+		It("should migrate the revision annotation of earlier versions", func() {
+			legacy := newTargetSecret(ocisecret, targetName.Name, targetName.Namespace)
+			delete(legacy.Annotations, revisionAnnotation)
+			legacy.Annotations[legacyRevisionAnnotation] = "sha256:1"
+			legacy.Data = map[string][]byte{"app.yaml": []byte("app"), "old.yaml": []byte("old")}
+			Expect(k8sClient.Create(ctx, legacy)).To(Succeed())
+
+			outdated, err := controllerReconciler.additionalTargetsOutdated(ctx, ocisecret, "sha256:1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outdated).To(BeFalse())
+
+			// All keys of the legacy revision came from the artifact and are replaced
+			Expect(controllerReconciler.syncAdditionalTargets(ctx, ocisecret, files, nil, "sha256:2", false)).To(Succeed())
+			secret := &v1core.Secret{}
+			Expect(k8sClient.Get(ctx, targetName, secret)).To(Succeed())
+			Expect(secret.Data).To(Equal(map[string][]byte{"app.yaml": []byte("app")}))
+			Expect(secret.Annotations).To(HaveKeyWithValue(revisionAnnotation, "sha256:2"))
+			Expect(secret.Annotations).NotTo(HaveKey(legacyRevisionAnnotation))
+		})
+
+		It("should not adopt Secrets managed by another OCISecret", func() {
+			other := newTestOCISecret("other-ocisecret")
+			other.UID = "other-uid"
+			Expect(k8sClient.Create(ctx, newTargetSecret(other, targetName.Name, targetName.Namespace))).To(Succeed())
+
+			ocisecret.Spec.AdoptExisting = true
+			Expect(controllerReconciler.checkTargetConflicts(ctx, ocisecret)).To(MatchError(errTargetConflict))
+		})
+
+		It("should refuse a target Secret that holds the credentials", func() {
+			ocisecret.Spec.ArtefactPullSecret = v1core.SecretReference{Name: targetName.Name, Namespace: targetName.Namespace}
+			Expect(controllerReconciler.checkTargetConflicts(ctx, ocisecret)).To(MatchError(errTargetConflict))
+		})
+	})
+})
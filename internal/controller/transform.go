@@ -0,0 +1,204 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	v1core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+	"github.com/mariusbertram/oci-resource-sync-operator/internal/orasclient"
+	"github.com/mariusbertram/oci-resource-sync-operator/internal/utils"
+)
+
+// errInvalidTransform is returned when the content of a file can't be transformed.
+var errInvalidTransform = errors.New("invalid file transform")
+
+// applyTransforms transforms the content of the files in place. Transforms of keys that are not in
+// files are ignored.
+func applyTransforms(files map[string][]byte, transforms map[string]ocisyncv1aplha1.FileTransform) error {
+	for key, transform := range transforms {
+		content, ok := files[key]
+		if !ok {
+			continue
+		}
+		switch transform {
+		case ocisyncv1aplha1.FileTransformNone, "":
+		case ocisyncv1aplha1.FileTransformBase64Encode:
+			files[key] = []byte(base64.StdEncoding.EncodeToString(content))
+		case ocisyncv1aplha1.FileTransformBase64Decode:
+			decoded, err := base64.StdEncoding.DecodeString(string(content))
+			if err != nil {
+				return fmt.Errorf("%w: %s is not valid base64: %v", errInvalidTransform, key, err)
+			}
+			files[key] = decoded
+		default:
+			return fmt.Errorf("%w: unknown transform %q for %s", errInvalidTransform, transform, key)
+		}
+	}
+	return nil
+}
+
+// transformStep is a step of the Sync.Transforms pipeline. It returns the transformed files, which may
+// be the files it was given, changed in place.
+type transformStep func(files map[string][]byte) (map[string][]byte, error)
+
+// runTransforms applies the steps of the Sync.Transforms pipeline in order to the files of the content.
+// The paths and permission bits of renamed files are moved to their new keys. Failing steps return
+// errInvalidTransform (wrapped).
+func runTransforms(content orasclient.Filemap, steps []ocisyncv1aplha1.TransformStep) (orasclient.Filemap, error) {
+	for i, step := range steps {
+		var transform transformStep
+		switch step.Type {
+		case ocisyncv1aplha1.TransformStepDecode:
+			transform = decodeStep(step.Files)
+		case ocisyncv1aplha1.TransformStepDecompress:
+			transform = decompressStep(step.Files)
+		case ocisyncv1aplha1.TransformStepRename:
+			rename, err := renameKey(step.From, step.To)
+			if err != nil {
+				return orasclient.Filemap{}, fmt.Errorf("%w: step %d: %v", errInvalidTransform, i, err)
+			}
+			transform = renameStep(step.Files, rename)
+			content.Paths = renameKeys(content.Paths, step.Files, rename)
+			content.Modes = renameKeys(content.Modes, step.Files, rename)
+		case ocisyncv1aplha1.TransformStepFilter:
+			transform = filterStep(step.Files, step.Exclude)
+		default:
+			return orasclient.Filemap{}, fmt.Errorf("%w: step %d has the unknown type %q", errInvalidTransform, i, step.Type)
+		}
+		files, err := transform(content.Files)
+		if err != nil {
+			return orasclient.Filemap{}, fmt.Errorf("%w: step %d (%s): %v", errInvalidTransform, i, step.Type, err)
+		}
+		content.Files = files
+	}
+	return content, nil
+}
+
+// stepSelects reports whether the Files of a step select the key; empty patterns select all keys.
+func stepSelects(key string, patterns []string) bool {
+	return len(patterns) == 0 || utils.MatchesAny(key, patterns)
+}
+
+// decodeStep returns a step that decodes the base64 encoded content of the selected files.
+func decodeStep(patterns []string) transformStep {
+	return func(files map[string][]byte) (map[string][]byte, error) {
+		for key, content := range files {
+			if !stepSelects(key, patterns) {
+				continue
+			}
+			decoded, err := base64.StdEncoding.DecodeString(string(content))
+			if err != nil {
+				return nil, fmt.Errorf("%s is not valid base64: %v", key, err)
+			}
+			files[key] = decoded
+		}
+		return files, nil
+	}
+}
+
+// decompressStep returns a step that decompresses the gzip and zstd compressed content of the selected
+// files. The decompressed content of a file may not exceed v1core.MaxSecretSize, it wouldn't fit into the
+// target Secret anyway.
+func decompressStep(patterns []string) transformStep {
+	return func(files map[string][]byte) (map[string][]byte, error) {
+		for key, content := range files {
+			if !stepSelects(key, patterns) {
+				continue
+			}
+			decompressed, err := orasclient.Decompress(content, v1core.MaxSecretSize)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			files[key] = decompressed
+		}
+		return files, nil
+	}
+}
+
+// renameKey returns the function replacing the matches of the regular expression from in a key with to.
+func renameKey(from, to string) (func(string) string, error) {
+	if from == "" {
+		return nil, errors.New("rename steps need a regular expression in from")
+	}
+	pattern, err := regexp.Compile(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression %q: %v", from, err)
+	}
+	return func(key string) string {
+		return pattern.ReplaceAllString(key, to)
+	}, nil
+}
+
+// renameStep returns a step that renames the selected files. Keys that aren't valid Secret keys and files
+// renamed to the same key as another file fail the step.
+func renameStep(patterns []string, rename func(string) string) transformStep {
+	return func(files map[string][]byte) (map[string][]byte, error) {
+		renamed := make(map[string][]byte, len(files))
+		renamedFrom := make(map[string]string, len(files))
+		for key, content := range files {
+			newKey := key
+			if stepSelects(key, patterns) {
+				newKey = rename(key)
+			}
+			if errs := validation.IsConfigMapKey(newKey); len(errs) > 0 {
+				return nil, fmt.Errorf("%q renamed from %s is not a valid Secret key: %s", newKey, key, strings.Join(errs, ", "))
+			}
+			if other, ok := renamedFrom[newKey]; ok {
+				return nil, fmt.Errorf("%s and %s are both stored under the key %s", min(key, other), max(key, other), newKey)
+			}
+			renamedFrom[newKey] = key
+			renamed[newKey] = content
+		}
+		return renamed, nil
+	}
+}
+
+// renameKeys returns the map with the selected keys renamed like renameStep does, for the paths and
+// permission bits of the files. Nil maps stay nil.
+func renameKeys[V any](values map[string]V, patterns []string, rename func(string) string) map[string]V {
+	if values == nil {
+		return nil
+	}
+	renamed := make(map[string]V, len(values))
+	for key, value := range values {
+		if stepSelects(key, patterns) {
+			key = rename(key)
+		}
+		renamed[key] = value
+	}
+	return renamed
+}
+
+// filterStep returns a step that keeps only the selected files, or drops them with exclude.
+func filterStep(patterns []string, exclude bool) transformStep {
+	return func(files map[string][]byte) (map[string][]byte, error) {
+		if exclude {
+			utils.FilterMapExcludeInPlace(files, patterns)
+		} else if len(patterns) > 0 {
+			utils.FilterMapInPlace(files, patterns)
+		}
+		return files, nil
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/fs"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+	"github.com/mariusbertram/oci-resource-sync-operator/internal/orasclient"
+)
+
+var _ = Describe("File transforms", func() {
+	It("should encode, decode and keep files", func() {
+		files := map[string][]byte{
+			"encode.txt": []byte("hello"),
+			"decode.txt": []byte("aGVsbG8="),
+			"keep.txt":   []byte("hello"),
+		}
+		Expect(applyTransforms(files, map[string]ocisyncv1aplha1.FileTransform{
+			"encode.txt":  ocisyncv1aplha1.FileTransformBase64Encode,
+			"decode.txt":  ocisyncv1aplha1.FileTransformBase64Decode,
+			"keep.txt":    ocisyncv1aplha1.FileTransformNone,
+			"missing.txt": ocisyncv1aplha1.FileTransformBase64Decode,
+		})).To(Succeed())
+
+		Expect(files).To(Equal(map[string][]byte{
+			"encode.txt": []byte("aGVsbG8="),
+			"decode.txt": []byte("hello"),
+			"keep.txt":   []byte("hello"),
+		}))
+	})
+
+	It("should reject invalid base64", func() {
+		files := map[string][]byte{"decode.txt": []byte("not base64!")}
+		Expect(applyTransforms(files, map[string]ocisyncv1aplha1.FileTransform{
+			"decode.txt": ocisyncv1aplha1.FileTransformBase64Decode,
+		})).To(MatchError(errInvalidTransform))
+	})
+
+	It("should apply the transform steps in order", func() {
+		var compressed bytes.Buffer
+		writer := gzip.NewWriter(&compressed)
+		_, err := writer.Write([]byte("aGVsbG8="))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(writer.Close()).To(Succeed())
+
+		content := orasclient.Filemap{
+			Files: map[string][]byte{
+				"dist.app.b64.gz": compressed.Bytes(),
+				"dist.readme.md":  []byte("readme"),
+				"other.txt":       []byte("other"),
+			},
+			Paths: map[string]string{"dist.app.b64.gz": "dist/app.b64.gz"},
+			Modes: map[string]fs.FileMode{"dist.app.b64.gz": 0o600},
+		}
+		content, err = runTransforms(content, []ocisyncv1aplha1.TransformStep{
+			{Type: ocisyncv1aplha1.TransformStepFilter, Files: []string{"dist.*"}},
+			{Type: ocisyncv1aplha1.TransformStepDecompress, Files: []string{"*.gz"}},
+			{Type: ocisyncv1aplha1.TransformStepDecode, Files: []string{"*.b64.gz"}},
+			{Type: ocisyncv1aplha1.TransformStepRename, From: `^dist\.(.*)\.b64\.gz$`, To: "${1}.txt"},
+			{Type: ocisyncv1aplha1.TransformStepFilter, Files: []string{"*.md"}, Exclude: true},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(content.Files).To(Equal(map[string][]byte{"app.txt": []byte("hello")}))
+		Expect(content.Paths).To(Equal(map[string]string{"app.txt": "dist/app.b64.gz"}))
+		Expect(content.Modes).To(Equal(map[string]fs.FileMode{"app.txt": 0o600}))
+	})
+
+	It("should reject failing transform steps", func() {
+		steps := map[string]ocisyncv1aplha1.TransformStep{
+			"invalid base64":     {Type: ocisyncv1aplha1.TransformStepDecode},
+			"invalid expression": {Type: ocisyncv1aplha1.TransformStepRename, From: "("},
+			"invalid key":        {Type: ocisyncv1aplha1.TransformStepRename, From: "^", To: "/"},
+			"colliding keys":     {Type: ocisyncv1aplha1.TransformStepRename, From: `\.txt$`},
+			"unknown type":       {Type: "encrypt"},
+		}
+		for name, step := range steps {
+			content := orasclient.Filemap{Files: map[string][]byte{"a.txt": []byte("!"), "a": []byte("!")}}
+			_, err := runTransforms(content, []ocisyncv1aplha1.TransformStep{step})
+			Expect(err).To(MatchError(errInvalidTransform), name)
+		}
+	})
+})
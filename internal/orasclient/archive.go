@@ -0,0 +1,77 @@
+package orasclient
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// ErrUnsafeArchiveEntry is returned when an archive contains an entry that would be extracted
+// outside of the archive's directory.
+var ErrUnsafeArchiveEntry = errors.New("archive contains an unsafe path")
+
+// tarMagicOffset is the offset of the "ustar" magic in the header of a tar archive.
+const tarMagicOffset = 257
+
+// defaultExpansionLimit is the number of bytes the files may have once archives are extracted without
+// PullOptions.MaxArtifactSize, the maximum size of a Secret. It keeps a small archive bomb from exhausting
+// the memory of the operator.
+const defaultExpansionLimit int64 = 1 << 20
+
+// extractArchive adds the regular files of the tar or tar+gzip archive to the collector, relative to the
+// directory of the archive, instead of the archive itself. It reports false if the content is no archive.
+// The extracted files count towards the size limit instead of the archive, or towards defaultExpansionLimit
+// without a limit.
+func extractArchive(collector *fileCollector, archivePath string, content []byte) (bool, error) {
+	var reader io.Reader = bytes.NewReader(content)
+	if bytes.HasPrefix(content, gzipMagic) {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return false, nil
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+	buffered := bufio.NewReaderSize(reader, tarMagicOffset+5)
+	if magic, err := buffered.Peek(tarMagicOffset + 5); err != nil || string(magic[tarMagicOffset:]) != "ustar" {
+		return false, nil
+	}
+
+	collector.totalSize -= int64(len(content))
+	archiveDir := path.Dir(archivePath)
+	tarReader := tar.NewReader(buffered)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return true, nil
+		} else if err != nil {
+			return true, fmt.Errorf("failed to extract %s: %w", archivePath, err)
+		}
+		// Directories are implied by the file paths, links are never followed
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return true, fmt.Errorf("%w: %s in %s", ErrUnsafeArchiveEntry, header.Name, archivePath)
+		}
+		// Reserve the announced size first, the tar reader never returns more than that
+		if err := collector.reserveExpanded(header.Size); err != nil {
+			return true, err
+		}
+		entryContent, err := io.ReadAll(tarReader)
+		if err != nil {
+			return true, fmt.Errorf("failed to extract %s from %s: %w", header.Name, archivePath, err)
+		}
+		if err := collector.add(path.Join(archiveDir, name), entryContent, header.FileInfo().Mode()); err != nil {
+			return true, err
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package orasclient
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestArchive returns a tar archive of the files (by entry name), gzip compressed if requested.
+func newTestArchive(t *testing.T, files map[string]string, compress bool) []byte {
+	t.Helper()
+	var archive bytes.Buffer
+	var gzipWriter *gzip.Writer
+	tarWriter := tar.NewWriter(&archive)
+	if compress {
+		gzipWriter = gzip.NewWriter(&archive)
+		tarWriter = tar.NewWriter(gzipWriter)
+	}
+	for name, content := range files {
+		header := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tarWriter.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if gzipWriter != nil {
+		if err := gzipWriter.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return archive.Bytes()
+}
+
+// writeTestArchive writes the archive as the file name into a new temporary directory.
+func writeTestArchive(t *testing.T, name string, archive []byte) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, name), archive, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestReadFilesExtractArchives(t *testing.T) {
+	entries := map[string]string{"app.yaml": "app", "./db.yaml": "db", "conf/nested.yaml": "nested"}
+
+	for _, compress := range []bool{false, true} {
+		archive := newTestArchive(t, entries, compress)
+		dir := writeTestArchive(t, "bundle.tar.gz", archive)
+
+		files, _, err := readFiles(dir, PullOptions{ExtractArchives: true})
+		if err != nil {
+			t.Fatalf("compress=%v: %v", compress, err)
+		}
+		if len(files) != 2 || string(files["app.yaml"]) != "app" || string(files["db.yaml"]) != "db" {
+			t.Errorf("compress=%v: unexpected files %v", compress, files)
+		}
+
+		files, _, err = readFiles(dir, PullOptions{ExtractArchives: true, PathSeparator: "__"})
+		if err != nil {
+			t.Fatalf("compress=%v: %v", compress, err)
+		}
+		if string(files["conf__nested.yaml"]) != "nested" {
+			t.Errorf("compress=%v: nested entry missing in %v", compress, files)
+		}
+
+		files, _, err = readFiles(dir, PullOptions{})
+		if err != nil {
+			t.Fatalf("compress=%v: %v", compress, err)
+		}
+		if !bytes.Equal(files["bundle.tar.gz"], archive) {
+			t.Errorf("compress=%v: archive should be kept without ExtractArchives", compress)
+		}
+	}
+}
+
+func TestReadFilesExtractArchivesIsSafe(t *testing.T) {
+	t.Run("path traversal is rejected", func(t *testing.T) {
+		dir := writeTestArchive(t, "bundle.tar", newTestArchive(t, map[string]string{"../escape.yaml": "x"}, true))
+		_, _, err := readFiles(dir, PullOptions{ExtractArchives: true})
+		if !errors.Is(err, ErrUnsafeArchiveEntry) {
+			t.Errorf("expected ErrUnsafeArchiveEntry, got %v", err)
+		}
+	})
+
+	t.Run("size limit applies to the extracted files", func(t *testing.T) {
+		// Highly compressible content stays far below the limit until it is extracted
+		large := string(bytes.Repeat([]byte("a"), 64*1024))
+		archive := newTestArchive(t, map[string]string{"large.txt": large}, true)
+		dir := writeTestArchive(t, "bundle.tar.gz", archive)
+		_, _, err := readFiles(dir, PullOptions{ExtractArchives: true, MaxArtifactSize: 32 * 1024})
+		if !errors.Is(err, ErrArtifactTooLarge) {
+			t.Errorf("expected ErrArtifactTooLarge, got %v", err)
+		}
+	})
+
+	t.Run("extracted files are limited without a size limit", func(t *testing.T) {
+		large := string(bytes.Repeat([]byte("a"), int(defaultExpansionLimit)+1))
+		archive := newTestArchive(t, map[string]string{"large.txt": large}, true)
+		dir := writeTestArchive(t, "bundle.tar.gz", archive)
+		_, _, err := readFiles(dir, PullOptions{ExtractArchives: true})
+		if !errors.Is(err, ErrArtifactTooLarge) {
+			t.Errorf("expected ErrArtifactTooLarge, got %v", err)
+		}
+	})
+
+	t.Run("plain files are not extracted", func(t *testing.T) {
+		dir := writeTestArchive(t, "app.yaml", []byte("key: value"))
+		files, _, err := readFiles(dir, PullOptions{ExtractArchives: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(files["app.yaml"]) != "key: value" {
+			t.Errorf("unexpected files %v", files)
+		}
+	})
+}
@@ -0,0 +1,37 @@
+package orasclient
+
+import (
+	"errors"
+	"net/http"
+
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+)
+
+// scopedClient requests additional scopes with the bearer tokens of every request. Some registries
+// only grant a pull when the token has scopes beyond the repository:<name>:pull scope ORAS derives
+// from the request, e.g. a scope on a base repository or a registry-wide scope.
+type scopedClient struct {
+	remote.Client
+	scopes []string
+}
+
+func (c *scopedClient) Do(req *http.Request) (*http.Response, error) {
+	// auth.Client looks the scopes up by the host of the request
+	ctx := auth.AppendScopesForHost(req.Context(), req.Host, c.scopes...)
+	return c.Client.Do(req.WithContext(ctx))
+}
+
+// IsAuthError reports whether the error is a registry response rejecting the credentials of the
+// request (401 Unauthorized or 403 Forbidden) or is classified as ErrAuth.
+func IsAuthError(err error) bool {
+	if errors.Is(err, ErrAuth) {
+		return true
+	}
+	var errResp *errcode.ErrorResponse
+	if !errors.As(err, &errResp) {
+		return false
+	}
+	return errResp.StatusCode == http.StatusUnauthorized || errResp.StatusCode == http.StatusForbidden
+}
@@ -0,0 +1,134 @@
+package orasclient
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+)
+
+func TestIsAuthError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil},
+		{name: "other error", err: errors.New("connection refused")},
+		{name: "not found", err: &errcode.ErrorResponse{StatusCode: http.StatusNotFound}},
+		{name: "unauthorized", err: &errcode.ErrorResponse{StatusCode: http.StatusUnauthorized}, want: true},
+		{name: "forbidden", err: &errcode.ErrorResponse{StatusCode: http.StatusForbidden}, want: true},
+		{
+			name: "wrapped unauthorized",
+			err:  fmt.Errorf("failed to fetch manifest: %w", &errcode.ErrorResponse{StatusCode: http.StatusUnauthorized}),
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsAuthError(tt.err); got != tt.want {
+				t.Errorf("IsAuthError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetDigestBasicAuth(t *testing.T) {
+	manifest := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json"}`)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "secret" {
+			w.Header().Set("Www-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+		w.Header().Set("Docker-Content-Digest", digest.FromBytes(manifest).String())
+		_, _ = w.Write(manifest)
+	}))
+	defer server.Close()
+
+	registry := strings.TrimPrefix(server.URL, "https://") + "/configs"
+	opts := ClientOptions{
+		CACertificates: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}),
+		Retry:          RetryPolicy{MaxRetries: 0, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+	}
+
+	t.Run("username and password are sent", func(t *testing.T) {
+		withCredentials := opts
+		withCredentials.Username = "user"
+		withCredentials.Password = "secret"
+		got, err := GetDigest(context.Background(), registry, "v1", withCredentials)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != digest.FromBytes(manifest).String() {
+			t.Errorf("got digest %s, want %s", got, digest.FromBytes(manifest))
+		}
+	})
+
+	t.Run("wrong password is an auth error", func(t *testing.T) {
+		withCredentials := opts
+		withCredentials.Username = "user"
+		withCredentials.Password = "wrong"
+		if _, err := GetDigest(context.Background(), registry, "v1", withCredentials); !IsAuthError(err) {
+			t.Errorf("expected an auth error, got %v", err)
+		}
+	})
+}
+
+func TestGetDigestScopes(t *testing.T) {
+	manifest := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json"}`)
+	const extraScope = "repository:base/configs:pull"
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The token server only grants a token if the extra scope is requested
+		if r.URL.Path == "/token" {
+			scopes := strings.Join(r.URL.Query()["scope"], " ")
+			if !strings.Contains(scopes, extraScope) {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			_, _ = w.Write([]byte(`{"token":"scoped"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer scoped" {
+			w.Header().Set("Www-Authenticate",
+				fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:configs:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+		w.Header().Set("Docker-Content-Digest", digest.FromBytes(manifest).String())
+		_, _ = w.Write(manifest)
+	}))
+	defer server.Close()
+
+	registry := strings.TrimPrefix(server.URL, "https://") + "/configs"
+	opts := ClientOptions{
+		CACertificates: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}),
+		Retry:          RetryPolicy{MaxRetries: 0, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+	}
+
+	if _, err := GetDigest(context.Background(), registry, "v1", opts); err == nil {
+		t.Error("expected the token request without the extra scope to fail")
+	}
+
+	opts.Scopes = []string{extraScope}
+	got, err := GetDigest(context.Background(), registry, "v1", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != digest.FromBytes(manifest).String() {
+		t.Errorf("got digest %s, want %s", got, digest.FromBytes(manifest))
+	}
+}
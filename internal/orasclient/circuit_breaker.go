@@ -0,0 +1,185 @@
+package orasclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ErrCircuitOpen is matched by the *CircuitOpenError returned by a CircuitBreakerClient.
+var ErrCircuitOpen = errors.New("registry circuit breaker open")
+
+// CircuitOpenError is returned by a CircuitBreakerClient instead of accessing a registry host that
+// kept failing. The call should be retried after RetryAfter.
+type CircuitOpenError struct {
+	// Host is the registry host, e.g. "docker.io"
+	Host string
+	// RetryAfter is the time until the circuit breaker lets a probe request through
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s for %s, retry in %s", ErrCircuitOpen, e.Host, e.RetryAfter.Round(time.Millisecond))
+}
+
+// Is makes errors.Is(err, ErrCircuitOpen) match the CircuitOpenError.
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// States of the circuit breaker of a registry host, as reported by the CircuitBreakerState metric.
+const (
+	// CircuitClosed lets all operations through.
+	CircuitClosed = 0
+	// CircuitHalfOpen lets a single probe operation through after the cooldown.
+	CircuitHalfOpen = 1
+	// CircuitOpen fails all operations without accessing the registry.
+	CircuitOpen = 2
+)
+
+// CircuitBreakerState reports the state of the circuit breaker of every registry host a
+// CircuitBreakerClient accessed: CircuitClosed, CircuitHalfOpen or CircuitOpen. It has to be
+// registered by the caller.
+var CircuitBreakerState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "ocisecret_registry_circuit_breaker_state",
+	Help: "State of the circuit breaker of a registry host: 0 closed, 1 half-open, 2 open.",
+}, []string{"host"})
+
+// circuit is the circuit breaker of a registry host.
+type circuit struct {
+	// failures is the number of consecutive transient failures
+	failures int
+	// openUntil is the end of the cooldown while the circuit is open
+	openUntil time.Time
+	// probing is set while the probe operation of a half-open circuit runs
+	probing bool
+}
+
+// CircuitBreakerClient is an ArtifactClient that stops accessing a registry host after threshold
+// consecutive operations failed with ErrTransient, e.g. while the registry is down. Operations then
+// fail with a *CircuitOpenError for the cooldown, so polling many OCISecrets neither adds load to the
+// struggling registry nor floods the log. After the cooldown a single probe operation is let through:
+// the circuit closes once it succeeds and opens for another cooldown if it fails. Other errors, e.g.
+// ErrAuth or ErrNotFound, prove the registry responds and close the circuit as well.
+type CircuitBreakerClient struct {
+	client    ArtifactClient
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+var _ ArtifactClient = &CircuitBreakerClient{}
+
+// NewCircuitBreakerClient returns a CircuitBreakerClient that opens the circuit of a registry host
+// after threshold consecutive transient failures for the cooldown. A threshold of zero or less
+// disables the circuit breaker, the client is returned unchanged.
+func NewCircuitBreakerClient(client ArtifactClient, threshold int, cooldown time.Duration) ArtifactClient {
+	if threshold <= 0 {
+		return client
+	}
+	return &CircuitBreakerClient{
+		client:    client,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+		circuits:  map[string]*circuit{},
+	}
+}
+
+// allow returns a *CircuitOpenError if the circuit of the registry host is open or its probe
+// operation is running, and marks the operation as the probe of a half-open circuit otherwise.
+func (c *CircuitBreakerClient) allow(registry string) error {
+	host := registryHost(registry)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state, ok := c.circuits[host]
+	if !ok {
+		state = &circuit{}
+		c.circuits[host] = state
+	}
+	if state.failures < c.threshold {
+		return nil
+	}
+	now := c.now()
+	if now.Before(state.openUntil) {
+		return &CircuitOpenError{Host: host, RetryAfter: state.openUntil.Sub(now)}
+	}
+	if state.probing {
+		// Wait for the running probe instead of piling onto the registry
+		return &CircuitOpenError{Host: host, RetryAfter: c.cooldown}
+	}
+	state.probing = true
+	CircuitBreakerState.WithLabelValues(host).Set(CircuitHalfOpen)
+	return nil
+}
+
+// record updates the circuit of the registry host with the result of an operation. Cancelled
+// operations say nothing about the registry and only end a running probe.
+func (c *CircuitBreakerClient) record(registry string, err error) {
+	host := registryHost(registry)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state := c.circuits[host]
+	probe := state.probing
+	state.probing = false
+	switch {
+	case errors.Is(err, context.Canceled):
+		if probe {
+			CircuitBreakerState.WithLabelValues(host).Set(CircuitOpen)
+		}
+	case errors.Is(err, ErrTransient):
+		state.failures++
+		if state.failures >= c.threshold {
+			state.openUntil = c.now().Add(c.cooldown)
+			CircuitBreakerState.WithLabelValues(host).Set(CircuitOpen)
+		}
+	default:
+		state.failures = 0
+		CircuitBreakerState.WithLabelValues(host).Set(CircuitClosed)
+	}
+}
+
+func (c *CircuitBreakerClient) GetDigest(ctx context.Context, registry string, tag string, opts ClientOptions) (string, error) {
+	if err := c.allow(registry); err != nil {
+		return "", err
+	}
+	digest, err := c.client.GetDigest(ctx, registry, tag, opts)
+	c.record(registry, err)
+	return digest, err
+}
+
+func (c *CircuitBreakerClient) GetFiles(ctx context.Context, registry string, tag string, opts ClientOptions, pullOptions PullOptions) (Filemap, error) {
+	if err := c.allow(registry); err != nil {
+		return Filemap{}, err
+	}
+	files, err := c.client.GetFiles(ctx, registry, tag, opts, pullOptions)
+	c.record(registry, err)
+	return files, err
+}
+
+func (c *CircuitBreakerClient) GetReferrerFiles(ctx context.Context, registry string, subject digest.Digest, artifactTypes []string,
+	maxSize int64, opts ClientOptions) (map[string][]byte, error) {
+	if err := c.allow(registry); err != nil {
+		return nil, err
+	}
+	files, err := c.client.GetReferrerFiles(ctx, registry, subject, artifactTypes, maxSize, opts)
+	c.record(registry, err)
+	return files, err
+}
+
+func (c *CircuitBreakerClient) VerifySignature(ctx context.Context, registry string, subject digest.Digest, publicKeyPEM []byte, opts ClientOptions) error {
+	if err := c.allow(registry); err != nil {
+		return err
+	}
+	err := c.client.VerifySignature(ctx, registry, subject, publicKeyPEM, opts)
+	c.record(registry, err)
+	return err
+}
@@ -0,0 +1,91 @@
+package orasclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCircuitBreakerClient(t *testing.T) {
+	upstream := &digestClient{err: ErrTransient}
+	now := time.Now()
+	client := NewCircuitBreakerClient(upstream, 2, time.Minute).(*CircuitBreakerClient)
+	client.now = func() time.Time { return now }
+	get := func(registry string) error {
+		_, err := client.GetDigest(context.Background(), registry, "v1", ClientOptions{})
+		return err
+	}
+
+	for range 2 {
+		if err := get("registry.example.com/org/configs"); !errors.Is(err, ErrTransient) {
+			t.Fatalf("expected the registry error, got %v", err)
+		}
+	}
+	err := get("registry.example.com/org/other")
+	var circuitErr *CircuitOpenError
+	if !errors.As(err, &circuitErr) || !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected a CircuitOpenError, got %v", err)
+	}
+	if circuitErr.Host != "registry.example.com" || circuitErr.RetryAfter != time.Minute {
+		t.Errorf("unexpected CircuitOpenError %+v", circuitErr)
+	}
+	if upstream.calls != 2 {
+		t.Errorf("expected 2 registry lookups, got %d", upstream.calls)
+	}
+	if state := testutil.ToFloat64(CircuitBreakerState.WithLabelValues("registry.example.com")); state != CircuitOpen {
+		t.Errorf("expected the open state to be reported, got %v", state)
+	}
+
+	// Other hosts have their own circuit
+	if err := get("ghcr.io/org/configs"); !errors.Is(err, ErrTransient) {
+		t.Errorf("expected ghcr.io to be accessed, got %v", err)
+	}
+
+	// A failed probe opens the circuit for another cooldown
+	now = now.Add(time.Minute)
+	if err := get("registry.example.com/org/configs"); !errors.Is(err, ErrTransient) {
+		t.Fatalf("expected the probe to access the registry, got %v", err)
+	}
+	if err := get("registry.example.com/org/configs"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected the circuit to open again, got %v", err)
+	}
+
+	// A successful probe closes the circuit
+	now = now.Add(time.Minute)
+	upstream.err = nil
+	for range 2 {
+		if err := get("registry.example.com/org/configs"); err != nil {
+			t.Fatalf("expected the circuit to close, got %v", err)
+		}
+	}
+	if state := testutil.ToFloat64(CircuitBreakerState.WithLabelValues("registry.example.com")); state != CircuitClosed {
+		t.Errorf("expected the closed state to be reported, got %v", state)
+	}
+}
+
+func TestCircuitBreakerClientResponses(t *testing.T) {
+	upstream := &digestClient{err: ErrTransient}
+	client := NewCircuitBreakerClient(upstream, 2, time.Minute)
+	registry := "registry.example.com/org/configs"
+
+	// A response of the registry, even a failed one, resets the consecutive failures
+	for _, err := range []error{ErrTransient, ErrNotFound, ErrTransient, context.Canceled, ErrTransient} {
+		upstream.err = err
+		if _, got := client.GetDigest(context.Background(), registry, "v1", ClientOptions{}); !errors.Is(got, err) {
+			t.Fatalf("expected %v, got %v", err, got)
+		}
+	}
+	if _, err := client.GetDigest(context.Background(), registry, "v1", ClientOptions{}); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected the circuit to be open, got %v", err)
+	}
+}
+
+func TestNewCircuitBreakerClientDisabled(t *testing.T) {
+	upstream := &digestClient{}
+	if client := NewCircuitBreakerClient(upstream, 0, time.Minute); client != upstream {
+		t.Errorf("expected the client to be returned unchanged, got %T", client)
+	}
+}
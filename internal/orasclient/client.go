@@ -0,0 +1,44 @@
+package orasclient
+
+import (
+	"context"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// ArtifactClient retrieves artifacts and their metadata from OCI registries.
+// The controller depends on this interface so tests can replace the registry access. Cancelling the
+// context of a call aborts its requests to the registry.
+type ArtifactClient interface {
+	// GetDigest returns the digest of the artifact with the tag, see GetDigest.
+	GetDigest(ctx context.Context, registry string, tag string, opts ClientOptions) (string, error)
+	// GetFiles downloads the artifact with the tag, see GetFiles.
+	GetFiles(ctx context.Context, registry string, tag string, opts ClientOptions, pullOptions PullOptions) (Filemap, error)
+	// GetReferrerFiles downloads the referrers of the subject, see GetReferrerFiles.
+	GetReferrerFiles(ctx context.Context, registry string, subject digest.Digest, artifactTypes []string, maxSize int64,
+		opts ClientOptions) (map[string][]byte, error)
+	// VerifySignature verifies the signature of the subject, see VerifySignature.
+	VerifySignature(ctx context.Context, registry string, subject digest.Digest, publicKeyPEM []byte, opts ClientOptions) error
+}
+
+// OrasClient is the ArtifactClient that accesses registries with the ORAS library.
+type OrasClient struct{}
+
+var _ ArtifactClient = OrasClient{}
+
+func (OrasClient) GetDigest(ctx context.Context, registry string, tag string, opts ClientOptions) (string, error) {
+	return GetDigest(ctx, registry, tag, opts)
+}
+
+func (OrasClient) GetFiles(ctx context.Context, registry string, tag string, opts ClientOptions, pullOptions PullOptions) (Filemap, error) {
+	return GetFiles(ctx, registry, tag, opts, pullOptions)
+}
+
+func (OrasClient) GetReferrerFiles(ctx context.Context, registry string, subject digest.Digest, artifactTypes []string,
+	maxSize int64, opts ClientOptions) (map[string][]byte, error) {
+	return GetReferrerFiles(ctx, registry, subject, artifactTypes, maxSize, opts)
+}
+
+func (OrasClient) VerifySignature(ctx context.Context, registry string, subject digest.Digest, publicKeyPEM []byte, opts ClientOptions) error {
+	return VerifySignature(ctx, registry, subject, publicKeyPEM, opts)
+}
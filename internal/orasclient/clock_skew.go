@@ -0,0 +1,142 @@
+package orasclient
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// DefaultClockSkewTolerance is how long a request is delayed and retried when the registry rejects a
+// freshly issued bearer token that only becomes valid within this duration on the local clock.
+const DefaultClockSkewTolerance = 5 * time.Second
+
+// clockSkewTransport diagnoses bearer tokens that are rejected by the registry right after they were
+// issued. Token issuers stamp tokens with their own clock, so a node clock that lags behind makes a
+// fresh token look "not yet valid" and one that runs ahead makes it look expired. When the token
+// claims indicate such a skew a PossibleClockSkew warning is logged, and if the token becomes valid
+// within the tolerance the request is retried once after waiting for it.
+type clockSkewTransport struct {
+	base      http.RoundTripper
+	logger    logr.Logger
+	tolerance time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	lastToken string
+}
+
+// tokenClaims are the time-bound claims of a JWT bearer token (seconds since the epoch).
+type tokenClaims struct {
+	IssuedAt  float64 `json:"iat"`
+	NotBefore float64 `json:"nbf"`
+	ExpiresAt float64 `json:"exp"`
+}
+
+func (t *clockSkewTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	// Only a token used for the first time was fetched immediately before this request
+	if !ok || !t.firstUse(token) {
+		return resp, nil
+	}
+	claims, ok := parseTokenClaims(token)
+	if !ok {
+		return resp, nil
+	}
+	skew, ok := claims.skew(t.now())
+	if !ok {
+		return resp, nil
+	}
+
+	t.logger.Info("PossibleClockSkew: the registry rejected a freshly issued bearer token, "+
+		"check the clock synchronisation of the node", "host", req.URL.Host, "skew", skew.String())
+	// A token that expired on arrival can't be fixed by waiting
+	if skew <= 0 || skew > t.tolerance {
+		return resp, nil
+	}
+	retryReq, ok := rewindRequest(req)
+	if !ok {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	timer := time.NewTimer(skew)
+	defer timer.Stop()
+	select {
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	case <-timer.C:
+	}
+	return t.base.RoundTrip(retryReq)
+}
+
+// firstUse reports whether the token differs from the one of the previous rejected request.
+func (t *clockSkewTransport) firstUse(token string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if token == t.lastToken {
+		return false
+	}
+	t.lastToken = token
+	return true
+}
+
+// parseTokenClaims decodes the claims of a JWT without verifying it; opaque tokens are not parsed.
+func parseTokenClaims(token string) (tokenClaims, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return tokenClaims{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return tokenClaims{}, false
+	}
+	var claims tokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return tokenClaims{}, false
+	}
+	return claims, true
+}
+
+// skew returns how far the issuer clock appears to be ahead of now: positive if the token is not
+// valid yet, negative if it has already expired. ok is false if the claims look valid.
+func (c tokenClaims) skew(now time.Time) (time.Duration, bool) {
+	validFrom := max(c.IssuedAt, c.NotBefore)
+	if validFrom > 0 {
+		if start := time.Unix(0, int64(validFrom*float64(time.Second))); start.After(now) {
+			return start.Sub(now), true
+		}
+	}
+	if c.ExpiresAt > 0 {
+		if end := time.Unix(0, int64(c.ExpiresAt*float64(time.Second))); !end.After(now) {
+			return end.Sub(now), true
+		}
+	}
+	return 0, false
+}
+
+// rewindRequest returns a copy of the request whose body can be sent again.
+func rewindRequest(req *http.Request) (*http.Request, bool) {
+	retryReq := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return retryReq, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	retryReq.Body = body
+	return retryReq, true
+}
@@ -0,0 +1,110 @@
+package orasclient
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+)
+
+// newTestToken returns an unsigned JWT that is valid from notBefore until expiresAt.
+func newTestToken(notBefore, expiresAt time.Time) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	claims := fmt.Sprintf(`{"iat":%d,"nbf":%d,"exp":%d}`, notBefore.Unix(), notBefore.Unix(), expiresAt.Unix())
+	return encode([]byte(`{"alg":"none"}`)) + "." + encode([]byte(claims)) + ".sig"
+}
+
+func TestClockSkewTransport(t *testing.T) {
+	// The registry rejects tokens until their not-before time is reached on its clock
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := parseTokenClaims(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		if !ok || float64(time.Now().Unix()) < claims.NotBefore || float64(time.Now().Unix()) >= claims.ExpiresAt {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		token      string
+		tolerance  time.Duration
+		wantStatus int
+		wantLog    bool
+	}{
+		{
+			name:       "token issued slightly in the future is retried",
+			token:      newTestToken(time.Now().Add(time.Second), time.Now().Add(time.Hour)),
+			tolerance:  3 * time.Second,
+			wantStatus: http.StatusOK,
+			wantLog:    true,
+		},
+		{
+			name:       "token issued beyond the tolerance is not retried",
+			token:      newTestToken(time.Now().Add(time.Hour), time.Now().Add(2*time.Hour)),
+			tolerance:  3 * time.Second,
+			wantStatus: http.StatusUnauthorized,
+			wantLog:    true,
+		},
+		{
+			name:       "token expired on arrival is reported",
+			token:      newTestToken(time.Now().Add(-time.Hour), time.Now().Add(-time.Minute)),
+			tolerance:  3 * time.Second,
+			wantStatus: http.StatusUnauthorized,
+			wantLog:    true,
+		},
+		{
+			name:       "opaque token is not diagnosed",
+			token:      "opaque-token",
+			tolerance:  3 * time.Second,
+			wantStatus: http.StatusUnauthorized,
+			wantLog:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var logs []string
+			logger := funcr.New(func(prefix, args string) {
+				mu.Lock()
+				defer mu.Unlock()
+				logs = append(logs, args)
+			}, funcr.Options{})
+
+			client := &http.Client{Transport: &clockSkewTransport{
+				base:      http.DefaultTransport,
+				logger:    logger,
+				tolerance: tt.tolerance,
+				now:       time.Now,
+			}}
+			req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("got status %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			gotLog := len(logs) == 1 && strings.Contains(logs[0], "PossibleClockSkew")
+			if gotLog != tt.wantLog {
+				t.Errorf("PossibleClockSkew logged = %v, want %v (logs: %v)", gotLog, tt.wantLog, logs)
+			}
+		})
+	}
+}
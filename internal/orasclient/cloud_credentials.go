@@ -0,0 +1,296 @@
+package orasclient
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+// CredentialProvider obtains short-lived registry credentials from a cloud provider, e.g. for
+// Amazon ECR whose tokens expire after twelve hours. CreateClient asks the provider for a fresh
+// credential for every client, so the credentials are refreshed on each reconcile.
+type CredentialProvider interface {
+	// Credential returns the credential for the registry host (e.g. "123456789012.dkr.ecr.eu-west-1.amazonaws.com").
+	Credential(ctx context.Context, registry string) (auth.Credential, error)
+}
+
+// Names of the cloud credential providers accepted by NewCredentialProvider.
+const (
+	// CredentialProviderAWS obtains Amazon ECR authorization tokens with the default AWS credential
+	// chain (e.g. IAM roles for service accounts or the instance profile).
+	CredentialProviderAWS = "aws"
+	// CredentialProviderGCP obtains OAuth2 access tokens for Google Artifact Registry and GCR from the
+	// metadata server (e.g. GKE workload identity).
+	CredentialProviderGCP = "gcp"
+	// CredentialProviderAzure exchanges an Entra ID token of the workload identity or the managed
+	// identity for an Azure Container Registry refresh token.
+	CredentialProviderAzure = "azure"
+	// CredentialProviderAuto uses the provider of the cloud the registry host belongs to (see
+	// cloudOfRegistry) and accesses registries of other hosts anonymously, so the ambient identity of
+	// the operator is used without naming the cloud.
+	CredentialProviderAuto = "auto"
+)
+
+// ErrUnknownCredentialProvider is returned by NewCredentialProvider for an unsupported provider name.
+var ErrUnknownCredentialProvider = errors.New("unknown credential provider")
+
+// NewCredentialProvider returns the cloud credential provider with the name (see CredentialProviderAWS,
+// CredentialProviderGCP, CredentialProviderAzure and CredentialProviderAuto), or
+// ErrUnknownCredentialProvider (wrapped).
+func NewCredentialProvider(name string) (CredentialProvider, error) {
+	switch name {
+	case CredentialProviderAWS:
+		return ecrCredentialProvider{}, nil
+	case CredentialProviderGCP:
+		return &gcpCredentialProvider{httpClient: http.DefaultClient, tokenURL: gcpTokenURL}, nil
+	case CredentialProviderAzure:
+		return &azureCredentialProvider{httpClient: http.DefaultClient, imdsTokenURL: azureIMDSTokenURL, exchangeScheme: "https"}, nil
+	case CredentialProviderAuto:
+		providers := make(map[string]CredentialProvider, 3)
+		for _, cloud := range []string{CredentialProviderAWS, CredentialProviderGCP, CredentialProviderAzure} {
+			providers[cloud], _ = NewCredentialProvider(cloud)
+		}
+		return autoCredentialProvider{providers: providers}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCredentialProvider, name)
+	}
+}
+
+// autoCredentialProvider delegates to the provider of the cloud the registry host belongs to. Registries
+// of no supported cloud get no credentials and are accessed anonymously.
+type autoCredentialProvider struct {
+	// providers maps the names of the clouds to their providers
+	providers map[string]CredentialProvider
+}
+
+func (p autoCredentialProvider) Credential(ctx context.Context, registry string) (auth.Credential, error) {
+	provider, ok := p.providers[cloudOfRegistry(registry)]
+	if !ok {
+		return auth.EmptyCredential, nil
+	}
+	return provider.Credential(ctx, registry)
+}
+
+// cloudOfRegistry returns the name of the credential provider of the cloud hosting the registry:
+// CredentialProviderAWS for Amazon ECR, CredentialProviderGCP for GCR (gcr.io, *.gcr.io) and Artifact
+// Registry (*-docker.pkg.dev) and CredentialProviderAzure for ACR (*.azurecr.io, .cn and .us). It
+// returns "" for other registries.
+func cloudOfRegistry(registry string) string {
+	host, _, _ := strings.Cut(registry, ":")
+	switch {
+	case isECRRegistry(registry):
+		return CredentialProviderAWS
+	case host == "gcr.io" || strings.HasSuffix(host, ".gcr.io") || strings.HasSuffix(host, "-docker.pkg.dev"):
+		return CredentialProviderGCP
+	case strings.HasSuffix(host, ".azurecr.io") || strings.HasSuffix(host, ".azurecr.cn") ||
+		strings.HasSuffix(host, ".azurecr.us"):
+		return CredentialProviderAzure
+	default:
+		return ""
+	}
+}
+
+// isECRRegistry reports whether the registry host is an Amazon ECR registry (see ecrRegion).
+func isECRRegistry(registry string) bool {
+	_, err := ecrRegion(registry)
+	return err == nil
+}
+
+// ecrCredentialProvider requests authorization tokens from the Amazon ECR API.
+type ecrCredentialProvider struct{}
+
+func (ecrCredentialProvider) Credential(ctx context.Context, registry string) (auth.Credential, error) {
+	region, err := ecrRegion(registry)
+	if err != nil {
+		return auth.EmptyCredential, err
+	}
+	awsConfig, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return auth.EmptyCredential, fmt.Errorf("failed to load the AWS configuration: %w", err)
+	}
+	output, err := ecr.NewFromConfig(awsConfig).GetAuthorizationToken(ctx, &ecr.GetAuthorizationTokenInput{})
+	if err != nil {
+		return auth.EmptyCredential, fmt.Errorf("failed to get an ECR authorization token: %w", err)
+	}
+	if len(output.AuthorizationData) == 0 || output.AuthorizationData[0].AuthorizationToken == nil {
+		return auth.EmptyCredential, errors.New("ECR returned no authorization token")
+	}
+	return decodeECRToken(*output.AuthorizationData[0].AuthorizationToken)
+}
+
+// ecrRegion returns the region of an ECR registry host such as
+// 123456789012.dkr.ecr.eu-west-1.amazonaws.com (or .amazonaws.com.cn).
+func ecrRegion(registry string) (string, error) {
+	host, _, _ := strings.Cut(registry, ":")
+	labels := strings.Split(host, ".")
+	if len(labels) < 6 || labels[1] != "dkr" || !strings.HasPrefix(labels[2], "ecr") || labels[4] != "amazonaws" {
+		return "", fmt.Errorf("%s is not an Amazon ECR registry", registry)
+	}
+	return labels[3], nil
+}
+
+// decodeECRToken decodes an ECR authorization token, the base64 encoded "AWS:<password>".
+func decodeECRToken(token string) (auth.Credential, error) {
+	decoded, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return auth.EmptyCredential, fmt.Errorf("invalid ECR authorization token: %w", err)
+	}
+	username, password, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return auth.EmptyCredential, errors.New("invalid ECR authorization token: no password")
+	}
+	return auth.Credential{Username: username, Password: password}, nil
+}
+
+// gcpTokenURL is the metadata server endpoint returning access tokens of the default service account.
+const gcpTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// gcpCredentialProvider requests access tokens from the GCP metadata server. Registries that aren't
+// hosted by Google (see cloudOfRegistry) get no credentials, so the access token is never sent to them.
+type gcpCredentialProvider struct {
+	httpClient *http.Client
+	tokenURL   string
+}
+
+func (p *gcpCredentialProvider) Credential(ctx context.Context, registry string) (auth.Credential, error) {
+	if cloudOfRegistry(registry) != CredentialProviderGCP {
+		return auth.EmptyCredential, nil
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, p.tokenURL, nil)
+	if err != nil {
+		return auth.EmptyCredential, err
+	}
+	request.Header.Set("Metadata-Flavor", "Google")
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := doTokenRequest(p.httpClient, request, &token); err != nil {
+		return auth.EmptyCredential, fmt.Errorf("failed to get a GCP access token: %w", err)
+	}
+	// Google registries accept access tokens as the password of this fixed user
+	return auth.Credential{Username: "oauth2accesstoken", Password: token.AccessToken}, nil
+}
+
+// Endpoints and parameters of the Azure token requests.
+const (
+	azureIMDSTokenURL       = "http://169.254.169.254/metadata/identity/oauth2/token"
+	azureDefaultAuthority   = "https://login.microsoftonline.com/"
+	azureManagementScope    = "https://management.azure.com/"
+	azureClientAssertionJWT = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+)
+
+// azureCredentialProvider exchanges an Entra ID access token for an ACR refresh token. The access
+// token is requested with the workload identity if AZURE_FEDERATED_TOKEN_FILE is set (AKS workload
+// identity), otherwise from the managed identity of the instance metadata service. Registries that aren't
+// Azure Container Registries (see cloudOfRegistry) get no credentials, so no Entra ID token is sent to them.
+type azureCredentialProvider struct {
+	httpClient   *http.Client
+	imdsTokenURL string
+	// exchangeScheme is the scheme of the ACR token exchange endpoint
+	exchangeScheme string
+}
+
+func (p *azureCredentialProvider) Credential(ctx context.Context, registry string) (auth.Credential, error) {
+	if cloudOfRegistry(registry) != CredentialProviderAzure {
+		return auth.EmptyCredential, nil
+	}
+	accessToken, err := p.accessToken(ctx)
+	if err != nil {
+		return auth.EmptyCredential, fmt.Errorf("failed to get an Entra ID access token: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type":   {"access_token"},
+		"service":      {registry},
+		"access_token": {accessToken},
+	}
+	exchangeURL := url.URL{Scheme: p.exchangeScheme, Host: registry, Path: "/oauth2/exchange"}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, exchangeURL.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return auth.EmptyCredential, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var token struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := doTokenRequest(p.httpClient, request, &token); err != nil {
+		return auth.EmptyCredential, fmt.Errorf("failed to exchange the Entra ID token with %s: %w", registry, err)
+	}
+	return auth.Credential{RefreshToken: token.RefreshToken}, nil
+}
+
+// accessToken requests an Entra ID access token for the Azure management API.
+func (p *azureCredentialProvider) accessToken(ctx context.Context) (string, error) {
+	var request *http.Request
+	if tokenFile := os.Getenv("AZURE_FEDERATED_TOKEN_FILE"); tokenFile != "" {
+		assertion, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return "", err
+		}
+		authority := os.Getenv("AZURE_AUTHORITY_HOST")
+		if authority == "" {
+			authority = azureDefaultAuthority
+		}
+		form := url.Values{
+			"grant_type":            {"client_credentials"},
+			"client_id":             {os.Getenv("AZURE_CLIENT_ID")},
+			"client_assertion":      {strings.TrimSpace(string(assertion))},
+			"client_assertion_type": {azureClientAssertionJWT},
+			"scope":                 {azureManagementScope + ".default"},
+		}
+		tokenURL := strings.TrimSuffix(authority, "/") + "/" + os.Getenv("AZURE_TENANT_ID") + "/oauth2/v2.0/token"
+		request, err = http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+		if err != nil {
+			return "", err
+		}
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		query := url.Values{"api-version": {"2018-02-01"}, "resource": {azureManagementScope}}
+		if clientID := os.Getenv("AZURE_CLIENT_ID"); clientID != "" {
+			query.Set("client_id", clientID)
+		}
+		var err error
+		request, err = http.NewRequestWithContext(ctx, http.MethodGet, p.imdsTokenURL+"?"+query.Encode(), nil)
+		if err != nil {
+			return "", err
+		}
+		request.Header.Set("Metadata", "true")
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := doTokenRequest(p.httpClient, request, &token); err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+// doTokenRequest sends the request and decodes the JSON response into token.
+func doTokenRequest(httpClient *http.Client, request *http.Request, token any) error {
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: response status code %d: %s", request.Method, request.URL.Redacted(),
+			response.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, token)
+}
@@ -0,0 +1,194 @@
+package orasclient
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+func TestNewCredentialProvider(t *testing.T) {
+	for _, name := range []string{CredentialProviderAWS, CredentialProviderGCP, CredentialProviderAzure, CredentialProviderAuto} {
+		if _, err := NewCredentialProvider(name); err != nil {
+			t.Errorf("provider %s: %v", name, err)
+		}
+	}
+	if _, err := NewCredentialProvider("oracle"); !errors.Is(err, ErrUnknownCredentialProvider) {
+		t.Errorf("expected ErrUnknownCredentialProvider, got %v", err)
+	}
+}
+
+func TestECRRegion(t *testing.T) {
+	tests := []struct {
+		registry string
+		want     string
+		wantErr  bool
+	}{
+		{registry: "123456789012.dkr.ecr.eu-west-1.amazonaws.com", want: "eu-west-1"},
+		{registry: "123456789012.dkr.ecr.cn-north-1.amazonaws.com.cn", want: "cn-north-1"},
+		{registry: "123456789012.dkr.ecr-fips.us-east-1.amazonaws.com:443", want: "us-east-1"},
+		{registry: "registry.example.com", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ecrRegion(tt.registry)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ecrRegion(%s) = %q, %v; want %q", tt.registry, got, err, tt.want)
+		}
+	}
+}
+
+func TestCloudOfRegistry(t *testing.T) {
+	tests := map[string]string{
+		"123456789012.dkr.ecr.eu-west-1.amazonaws.com": CredentialProviderAWS,
+		"gcr.io":                      CredentialProviderGCP,
+		"eu.gcr.io":                   CredentialProviderGCP,
+		"europe-west1-docker.pkg.dev": CredentialProviderGCP,
+		"example.azurecr.io":          CredentialProviderAzure,
+		"example.azurecr.cn:443":      CredentialProviderAzure,
+		"registry.example.com":        "",
+		"gcr.io.example.com":          "",
+		"azurecr.io":                  "",
+		"docker.io":                   "",
+	}
+	for registry, want := range tests {
+		if got := cloudOfRegistry(registry); got != want {
+			t.Errorf("cloudOfRegistry(%s) = %q, want %q", registry, got, want)
+		}
+	}
+}
+
+// staticCredentialProvider returns the same credential for every registry.
+type staticCredentialProvider auth.Credential
+
+func (p staticCredentialProvider) Credential(context.Context, string) (auth.Credential, error) {
+	return auth.Credential(p), nil
+}
+
+func TestAutoCredentialProvider(t *testing.T) {
+	provider := autoCredentialProvider{providers: map[string]CredentialProvider{
+		CredentialProviderGCP:   staticCredentialProvider{Password: "gcp"},
+		CredentialProviderAzure: staticCredentialProvider{RefreshToken: "azure"},
+	}}
+	credential, err := provider.Credential(context.Background(), "europe-west1-docker.pkg.dev")
+	if err != nil || credential.Password != "gcp" {
+		t.Errorf("expected the GCP credential, got %+v, %v", credential, err)
+	}
+	credential, err = provider.Credential(context.Background(), "example.azurecr.io")
+	if err != nil || credential.RefreshToken != "azure" {
+		t.Errorf("expected the Azure credential, got %+v, %v", credential, err)
+	}
+	// Registries of other hosts are accessed anonymously
+	credential, err = provider.Credential(context.Background(), "registry.example.com")
+	if err != nil || credential != auth.EmptyCredential {
+		t.Errorf("expected no credential, got %+v, %v", credential, err)
+	}
+}
+
+func TestDecodeECRToken(t *testing.T) {
+	credential, err := decodeECRToken(base64.StdEncoding.EncodeToString([]byte("AWS:pass:word")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if credential != (auth.Credential{Username: "AWS", Password: "pass:word"}) {
+		t.Errorf("unexpected credential %+v", credential)
+	}
+	if _, err := decodeECRToken("not base64!"); err == nil {
+		t.Error("expected an error for an invalid token")
+	}
+}
+
+func TestGCPCredentialProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"gcp-token","expires_in":3599,"token_type":"Bearer"}`))
+	}))
+	defer server.Close()
+
+	provider := &gcpCredentialProvider{httpClient: server.Client(), tokenURL: server.URL}
+	credential, err := provider.Credential(context.Background(), "europe-docker.pkg.dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if credential != (auth.Credential{Username: "oauth2accesstoken", Password: "gcp-token"}) {
+		t.Errorf("unexpected credential %+v", credential)
+	}
+
+	// The access token is never handed to registries of other hosts
+	for _, registry := range []string{"registry.example.com", "gcr.io.example.com", "example.azurecr.io"} {
+		credential, err = provider.Credential(context.Background(), registry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if credential != auth.EmptyCredential {
+			t.Errorf("expected no credential for %s, got %+v", registry, credential)
+		}
+	}
+}
+
+func TestAzureCredentialProvider(t *testing.T) {
+	t.Setenv("AZURE_FEDERATED_TOKEN_FILE", "")
+	t.Setenv("AZURE_CLIENT_ID", "")
+
+	var exchanges int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/metadata/identity/oauth2/token":
+			if r.Header.Get("Metadata") != "true" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"access_token":"entra-token"}`))
+		case "/oauth2/exchange":
+			exchanges++
+			if err := r.ParseForm(); err != nil || r.PostForm.Get("access_token") != "entra-token" ||
+				r.PostForm.Get("service") != "example.azurecr.io" || r.Host != "example.azurecr.io" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"refresh_token":"acr-refresh-token"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	// Every host, including the registry, is served by the test server
+	transport := server.Client().Transport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+	}
+	provider := &azureCredentialProvider{
+		httpClient:     &http.Client{Transport: transport},
+		imdsTokenURL:   server.URL + "/metadata/identity/oauth2/token",
+		exchangeScheme: "http",
+	}
+	credential, err := provider.Credential(context.Background(), "example.azurecr.io")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if credential != (auth.Credential{RefreshToken: "acr-refresh-token"}) {
+		t.Errorf("unexpected credential %+v", credential)
+	}
+
+	// The Entra ID token is never exchanged with registries of other hosts
+	for _, registry := range []string{"registry.example.com", "azurecr.io.example.com", "europe-docker.pkg.dev"} {
+		credential, err = provider.Credential(context.Background(), registry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if credential != auth.EmptyCredential {
+			t.Errorf("expected no credential for %s, got %+v", registry, credential)
+		}
+	}
+	if exchanges != 1 {
+		t.Errorf("expected a single token exchange, got %d", exchanges)
+	}
+}
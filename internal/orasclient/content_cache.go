@@ -0,0 +1,173 @@
+package orasclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// ContentCache is an ArtifactClient that keeps the files downloaded by GetFiles for a short time, so
+// that many OCISecrets syncing the same artifact, e.g. into different namespaces, download it only
+// once. Entries are kept per registry, digest, credentials and pull options: files downloaded with one
+// set of credentials are never returned for another. The cache is bounded by the number of entries and
+// the total size of the files; the least recently used entries are evicted first. When the tag of an
+// artifact moves to another digest, the entries of the previous digest are dropped. Failed downloads
+// aren't cached. Downloads with ClientOptions.NoCache go to the registry and refresh the cached files.
+type ContentCache struct {
+	ArtifactClient
+	ttl        time.Duration
+	maxEntries int
+	maxSize    int64
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[contentCacheKey]*contentCacheEntry
+	size    int64
+	// tags holds the last digest downloaded per registry and tag
+	tags map[[2]string]digest.Digest
+}
+
+type contentCacheKey struct {
+	registry    string
+	digest      digest.Digest
+	credentials [sha256.Size]byte
+	pullOptions [sha256.Size]byte
+}
+
+type contentCacheEntry struct {
+	content  Filemap
+	size     int64
+	expires  time.Time
+	lastUsed time.Time
+}
+
+// NewContentCache returns a ContentCache keeping the files downloaded by the client for the ttl, with
+// at most maxEntries entries and maxSize bytes of files. A ttl, maxEntries or maxSize of zero or less
+// disables the cache, the client is returned unchanged.
+//
+// The digest of a tag is resolved with the GetDigest of the client before each download, so the client
+// should be a DigestCache to avoid an additional manifest request.
+func NewContentCache(client ArtifactClient, ttl time.Duration, maxEntries int, maxSize int64) ArtifactClient {
+	if ttl <= 0 || maxEntries <= 0 || maxSize <= 0 {
+		return client
+	}
+	return &ContentCache{
+		ArtifactClient: client,
+		ttl:            ttl,
+		maxEntries:     maxEntries,
+		maxSize:        maxSize,
+		now:            time.Now,
+		entries:        map[contentCacheKey]*contentCacheEntry{},
+		tags:           map[[2]string]digest.Digest{},
+	}
+}
+
+func (c *ContentCache) GetFiles(ctx context.Context, registry string, tag string, opts ClientOptions, pullOptions PullOptions) (Filemap, error) {
+	credentials, options := credentialsHash(opts), pullOptionsHash(pullOptions)
+	now := c.now()
+
+	// A failed lookup isn't reported here, the download reports the error of the registry
+	if current, err := c.ArtifactClient.GetDigest(ctx, registry, tag, opts); err == nil && !opts.NoCache {
+		key := contentCacheKey{registry: registry, digest: digest.Digest(current), credentials: credentials, pullOptions: options}
+		c.mu.Lock()
+		entry, ok := c.entries[key]
+		if ok && now.Before(entry.expires) {
+			entry.lastUsed = now
+			content := copyFilemap(entry.content)
+			c.mu.Unlock()
+			return content, nil
+		}
+		c.mu.Unlock()
+	}
+
+	content, err := c.ArtifactClient.GetFiles(ctx, registry, tag, opts, pullOptions)
+	if err != nil {
+		return Filemap{}, err
+	}
+
+	key := contentCacheKey{registry: registry, digest: content.Digest, credentials: credentials, pullOptions: options}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invalidate(registry, tag, content.Digest)
+	c.remove(key)
+	var size int64
+	for _, data := range content.Files {
+		size += int64(len(data))
+	}
+	// Artifacts that don't fit at all aren't cached, rather than evicting everything else
+	if size > c.maxSize {
+		return content, nil
+	}
+	c.evict(now, size)
+	c.entries[key] = &contentCacheEntry{content: copyFilemap(content), size: size, expires: now.Add(c.ttl), lastUsed: now}
+	c.size += size
+	return content, nil
+}
+
+// invalidate drops the entries of the digest previously downloaded for the tag if the tag moved to
+// another digest. c.mu must be held.
+func (c *ContentCache) invalidate(registry, tag string, current digest.Digest) {
+	previous, ok := c.tags[[2]string{registry, tag}]
+	c.tags[[2]string{registry, tag}] = current
+	if !ok || previous == current {
+		return
+	}
+	for key := range c.entries {
+		if key.registry == registry && key.digest == previous {
+			c.remove(key)
+		}
+	}
+}
+
+// evict drops the expired entries and then the least recently used ones until an entry with the size
+// fits into the cache. c.mu must be held.
+func (c *ContentCache) evict(now time.Time, size int64) {
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			c.remove(key)
+		}
+	}
+	for len(c.entries) > 0 && (len(c.entries) >= c.maxEntries || c.size+size > c.maxSize) {
+		var oldest contentCacheKey
+		var oldestUsed time.Time
+		first := true
+		for key, entry := range c.entries {
+			if first || entry.lastUsed.Before(oldestUsed) {
+				oldest, oldestUsed, first = key, entry.lastUsed, false
+			}
+		}
+		c.remove(oldest)
+	}
+}
+
+// remove drops the entry with the key if present. c.mu must be held.
+func (c *ContentCache) remove(key contentCacheKey) {
+	if entry, ok := c.entries[key]; ok {
+		c.size -= entry.size
+		delete(c.entries, key)
+	}
+}
+
+// copyFilemap copies the maps of the content, so callers filtering the files in place don't change the
+// cached entry. The file contents are shared and must not be modified.
+func copyFilemap(content Filemap) Filemap {
+	return Filemap{
+		Digest:      content.Digest,
+		Files:       maps.Clone(content.Files),
+		Paths:       maps.Clone(content.Paths),
+		Modes:       maps.Clone(content.Modes),
+		Annotations: maps.Clone(content.Annotations),
+	}
+}
+
+// pullOptionsHash identifies the pull options, which determine the downloaded files.
+func pullOptionsHash(pullOptions PullOptions) [sha256.Size]byte {
+	// PullOptions only holds plain values, so it can always be marshalled
+	encoded, _ := json.Marshal(pullOptions)
+	return sha256.Sum256(encoded)
+}
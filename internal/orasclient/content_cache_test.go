@@ -0,0 +1,166 @@
+package orasclient
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// filesClient returns the configured digest and files and counts the GetFiles calls.
+type filesClient struct {
+	OrasClient
+	digest string
+	files  map[string][]byte
+	modes  map[string]fs.FileMode
+	err    error
+	calls  int
+}
+
+func (c *filesClient) GetDigest(context.Context, string, string, ClientOptions) (string, error) {
+	return c.digest, nil
+}
+
+func (c *filesClient) GetFiles(context.Context, string, string, ClientOptions, PullOptions) (Filemap, error) {
+	c.calls++
+	if c.err != nil {
+		return Filemap{}, c.err
+	}
+	files := map[string][]byte{}
+	for name, data := range c.files {
+		files[name] = data
+	}
+	return Filemap{Digest: digest.Digest(c.digest), Files: files, Modes: maps.Clone(c.modes)}, nil
+}
+
+func TestContentCache(t *testing.T) {
+	registry, tag := "registry.example.com/configs", "v1"
+	upstream := &filesClient{digest: "sha256:first", files: map[string][]byte{"app.yaml": []byte("first")}}
+	now := time.Now()
+	cache := NewContentCache(upstream, time.Minute, 10, 1024).(*ContentCache)
+	cache.now = func() time.Time { return now }
+
+	for range 3 {
+		content, err := cache.GetFiles(context.Background(), registry, tag, ClientOptions{}, PullOptions{})
+		if err != nil || string(content.Files["app.yaml"]) != "first" || content.Digest != "sha256:first" {
+			t.Fatalf("GetFiles = %v, %v", content, err)
+		}
+		// Filtering the returned files must not change the cached entry
+		delete(content.Files, "app.yaml")
+	}
+	if upstream.calls != 1 {
+		t.Errorf("expected 1 download, got %d", upstream.calls)
+	}
+
+	// Other credentials and pull options don't share the cached files
+	if _, err := cache.GetFiles(context.Background(), registry, tag, ClientOptions{Username: "user", Password: "secret"}, PullOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.GetFiles(context.Background(), registry, tag, ClientOptions{}, PullOptions{ExtractArchives: true}); err != nil {
+		t.Fatal(err)
+	}
+	if upstream.calls != 3 {
+		t.Errorf("expected 3 downloads, got %d", upstream.calls)
+	}
+
+	// NoCache downloads again
+	if _, err := cache.GetFiles(context.Background(), registry, tag, ClientOptions{NoCache: true}, PullOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if upstream.calls != 4 {
+		t.Errorf("expected a download with NoCache, got %d downloads", upstream.calls)
+	}
+
+	// A new digest of the tag is downloaded and drops the files of the previous digest
+	upstream.digest, upstream.files = "sha256:second", map[string][]byte{"app.yaml": []byte("second")}
+	content, err := cache.GetFiles(context.Background(), registry, tag, ClientOptions{}, PullOptions{})
+	if err != nil || string(content.Files["app.yaml"]) != "second" {
+		t.Fatalf("GetFiles = %v, %v", content, err)
+	}
+	for key := range cache.entries {
+		if key.digest == "sha256:first" {
+			t.Errorf("expected the entries of the previous digest to be dropped, found %v", key)
+		}
+	}
+
+	// Expired entries are downloaded again
+	calls := upstream.calls
+	now = now.Add(time.Minute)
+	if _, err := cache.GetFiles(context.Background(), registry, tag, ClientOptions{}, PullOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if upstream.calls != calls+1 {
+		t.Errorf("expected a download after the TTL, got %d downloads", upstream.calls-calls)
+	}
+}
+
+func TestContentCacheModes(t *testing.T) {
+	upstream := &filesClient{digest: "sha256:first", files: map[string][]byte{"run.sh": []byte("run")},
+		modes: map[string]fs.FileMode{"run.sh": 0o755}}
+	cache := NewContentCache(upstream, time.Minute, 10, 1024)
+
+	for range 2 {
+		content, err := cache.GetFiles(context.Background(), "registry.example.com/configs", "v1", ClientOptions{}, PullOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !maps.Equal(content.Modes, upstream.modes) {
+			t.Errorf("expected the modes %v, got %v", upstream.modes, content.Modes)
+		}
+		// Changing the returned modes must not change the cached entry
+		content.Modes["run.sh"] = 0o600
+	}
+	if upstream.calls != 1 {
+		t.Errorf("expected the second GetFiles to be cached, got %d downloads", upstream.calls)
+	}
+}
+
+func TestContentCacheBounds(t *testing.T) {
+	upstream := &filesClient{files: map[string][]byte{"app.yaml": []byte("0123456789")}}
+	now := time.Now()
+	cache := NewContentCache(upstream, time.Minute, 2, 25).(*ContentCache)
+	cache.now = func() time.Time { return now }
+
+	for _, d := range []string{"sha256:a", "sha256:b", "sha256:c"} {
+		upstream.digest = d
+		now = now.Add(time.Second)
+		if _, err := cache.GetFiles(context.Background(), "registry.example.com/"+d, "v1", ClientOptions{}, PullOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(cache.entries) != 2 || cache.size != 20 {
+		t.Errorf("expected 2 entries of 20 bytes, got %d entries of %d bytes", len(cache.entries), cache.size)
+	}
+	for key := range cache.entries {
+		if key.digest == "sha256:a" {
+			t.Error("expected the least recently used entry to be evicted")
+		}
+	}
+
+	// Artifacts larger than the cache aren't cached
+	upstream.digest, upstream.files = "sha256:large", map[string][]byte{"large.bin": make([]byte, 30)}
+	if _, err := cache.GetFiles(context.Background(), "registry.example.com/large", "v1", ClientOptions{}, PullOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(cache.entries) != 2 || cache.size != 20 {
+		t.Errorf("expected the large artifact not to be cached, got %d entries of %d bytes", len(cache.entries), cache.size)
+	}
+}
+
+func TestContentCacheErrors(t *testing.T) {
+	upstream := &filesClient{digest: "sha256:first", err: errors.New("registry unavailable")}
+	cache := NewContentCache(upstream, time.Minute, 10, 1024)
+
+	for range 2 {
+		if _, err := cache.GetFiles(context.Background(), "registry.example.com/configs", "v1", ClientOptions{}, PullOptions{}); err == nil {
+			t.Fatal("expected an error")
+		}
+	}
+	if upstream.calls != 2 {
+		t.Errorf("expected failed downloads not to be cached, got %d downloads", upstream.calls)
+	}
+}
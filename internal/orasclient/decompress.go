@@ -0,0 +1,51 @@
+package orasclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Magic bytes at the start of compressed data.
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Decompress returns the decompressed content of gzip or zstd compressed data and the data itself
+// if it isn't compressed. The decompressed content may not exceed limit bytes, otherwise
+// ErrArtifactTooLarge (wrapped) is returned.
+func Decompress(data []byte, limit int64) ([]byte, error) {
+	var reader io.Reader
+	switch {
+	case bytes.HasPrefix(data, gzipMagic):
+		gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip data: %w", err)
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	case bytes.HasPrefix(data, zstdMagic):
+		zstdReader, err := zstd.NewReader(bytes.NewReader(data), zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, fmt.Errorf("invalid zstd data: %w", err)
+		}
+		defer zstdReader.Close()
+		reader = zstdReader
+	default:
+		return data, nil
+	}
+
+	// Read one byte more than allowed to notice oversized content without reading all of it
+	content, err := io.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress: %w", err)
+	}
+	if int64(len(content)) > limit {
+		return nil, fmt.Errorf("%w: more than %d bytes after decompression", ErrArtifactTooLarge, limit)
+	}
+	return content, nil
+}
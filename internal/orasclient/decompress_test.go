@@ -0,0 +1,85 @@
+package orasclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func gzipBytes(t *testing.T, content []byte) []byte {
+	t.Helper()
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return compressed.Bytes()
+}
+
+func zstdBytes(t *testing.T, content []byte) []byte {
+	t.Helper()
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer encoder.Close()
+	return encoder.EncodeAll(content, nil)
+}
+
+func TestReadFilesDecompress(t *testing.T) {
+	config := []byte("key: value\n")
+	dir := writeTestTree(t, map[string]string{
+		"gzip.yaml":  string(gzipBytes(t, config)),
+		"zstd.yaml":  string(zstdBytes(t, config)),
+		"plain.yaml": string(config),
+	})
+
+	t.Run("all compressed files are decompressed", func(t *testing.T) {
+		files, _, err := readFiles(dir, PullOptions{Decompress: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for key, content := range files {
+			if !bytes.Equal(content, config) {
+				t.Errorf("file %s: got %q, want %q", key, content, config)
+			}
+		}
+	})
+
+	t.Run("only the selected files are decompressed", func(t *testing.T) {
+		files, _, err := readFiles(dir, PullOptions{Decompress: true, DecompressFiles: []string{"zstd.yaml"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(files["zstd.yaml"], config) {
+			t.Errorf("zstd.yaml was not decompressed")
+		}
+		if bytes.Equal(files["gzip.yaml"], config) {
+			t.Errorf("gzip.yaml should not be decompressed")
+		}
+	})
+
+	t.Run("size limit applies to the decompressed files", func(t *testing.T) {
+		large := bytes.Repeat([]byte("a"), 64*1024)
+		largeDir := writeTestTree(t, map[string]string{"large.txt": string(gzipBytes(t, large))})
+		_, _, err := readFiles(largeDir, PullOptions{Decompress: true, MaxArtifactSize: 32 * 1024})
+		if !errors.Is(err, ErrArtifactTooLarge) {
+			t.Errorf("expected ErrArtifactTooLarge, got %v", err)
+		}
+	})
+
+	t.Run("decompressed files are limited without a size limit", func(t *testing.T) {
+		large := bytes.Repeat([]byte("a"), int(defaultExpansionLimit)+1)
+		largeDir := writeTestTree(t, map[string]string{"large.txt": string(zstdBytes(t, large))})
+		_, _, err := readFiles(largeDir, PullOptions{Decompress: true})
+		if !errors.Is(err, ErrArtifactTooLarge) {
+			t.Errorf("expected ErrArtifactTooLarge, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package orasclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DigestCache is an ArtifactClient that remembers the digests returned by GetDigest for a short
+// time, so that many OCISecrets polling the same tag don't each send a manifest request to the
+// registry. Entries are kept per registry, tag and credentials: a digest resolved with one set of
+// credentials is never returned for another. Failed lookups aren't cached. Lookups with
+// ClientOptions.NoCache go to the registry and refresh the cached digest.
+type DigestCache struct {
+	ArtifactClient
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[digestCacheKey]digestCacheEntry
+}
+
+type digestCacheKey struct {
+	registry    string
+	tag         string
+	credentials [sha256.Size]byte
+}
+
+type digestCacheEntry struct {
+	digest  string
+	expires time.Time
+}
+
+// NewDigestCache returns a DigestCache keeping the digests resolved by the client for the ttl.
+// A ttl of zero or less disables the cache, the client is returned unchanged.
+func NewDigestCache(client ArtifactClient, ttl time.Duration) ArtifactClient {
+	if ttl <= 0 {
+		return client
+	}
+	return &DigestCache{
+		ArtifactClient: client,
+		ttl:            ttl,
+		now:            time.Now,
+		entries:        map[digestCacheKey]digestCacheEntry{},
+	}
+}
+
+func (c *DigestCache) GetDigest(ctx context.Context, registry string, tag string, opts ClientOptions) (string, error) {
+	key := digestCacheKey{registry: registry, tag: tag, credentials: credentialsHash(opts)}
+	now := c.now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expires) && !opts.NoCache {
+		return entry.digest, nil
+	}
+
+	digest, err := c.ArtifactClient.GetDigest(ctx, registry, tag, opts)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Drop expired entries so tags that are no longer polled don't accumulate
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = digestCacheEntry{digest: digest, expires: now.Add(c.ttl)}
+	return digest, nil
+}
+
+// credentialsHash identifies the credentials of the options without keeping them in memory.
+func credentialsHash(opts ClientOptions) [sha256.Size]byte {
+	h := sha256.New()
+	// Length prefixes keep the fields apart
+	for _, field := range [][]byte{opts.Credentials, []byte(opts.Username), []byte(opts.Password)} {
+		_, _ = fmt.Fprintf(h, "%d:", len(field))
+		_, _ = h.Write(field)
+	}
+	if opts.CredentialProvider != nil {
+		_, _ = fmt.Fprintf(h, "provider:%T", opts.CredentialProvider)
+	}
+	// Scopes change what the registry grants
+	for _, scope := range opts.Scopes {
+		_, _ = fmt.Fprintf(h, "scope:%d:%s", len(scope), scope)
+	}
+	if opts.ClientCertificate != nil {
+		for _, certificate := range opts.ClientCertificate.Certificate {
+			_, _ = fmt.Fprintf(h, "cert:%d:", len(certificate))
+			_, _ = h.Write(certificate)
+		}
+	}
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
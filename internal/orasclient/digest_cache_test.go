@@ -0,0 +1,90 @@
+package orasclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// digestClient returns the configured digest and counts the GetDigest calls.
+type digestClient struct {
+	OrasClient
+	digest string
+	err    error
+	calls  int
+}
+
+func (c *digestClient) GetDigest(context.Context, string, string, ClientOptions) (string, error) {
+	c.calls++
+	return c.digest, c.err
+}
+
+func TestDigestCache(t *testing.T) {
+	registry, tag := "registry.example.com", "artifact:v1"
+	upstream := &digestClient{digest: "sha256:first"}
+	now := time.Now()
+	cache := NewDigestCache(upstream, time.Minute).(*DigestCache)
+	cache.now = func() time.Time { return now }
+
+	for range 3 {
+		digest, err := cache.GetDigest(context.Background(), registry, tag, ClientOptions{})
+		if err != nil || digest != "sha256:first" {
+			t.Fatalf("GetDigest = %q, %v", digest, err)
+		}
+	}
+	if upstream.calls != 1 {
+		t.Errorf("expected 1 registry lookup, got %d", upstream.calls)
+	}
+
+	// Other credentials don't share the cached digest
+	upstream.digest = "sha256:second"
+	if digest, _ := cache.GetDigest(context.Background(), registry, tag, ClientOptions{Username: "user", Password: "secret"}); digest != "sha256:second" {
+		t.Errorf("expected the digest resolved with the credentials, got %q", digest)
+	}
+	if upstream.calls != 2 {
+		t.Errorf("expected 2 registry lookups, got %d", upstream.calls)
+	}
+
+	// NoCache skips and refreshes the cached digest
+	upstream.digest = "sha256:third"
+	if digest, _ := cache.GetDigest(context.Background(), registry, tag, ClientOptions{NoCache: true}); digest != "sha256:third" {
+		t.Errorf("expected the digest of the registry with NoCache, got %q", digest)
+	}
+	if digest, _ := cache.GetDigest(context.Background(), registry, tag, ClientOptions{}); digest != "sha256:third" {
+		t.Errorf("expected the refreshed digest, got %q", digest)
+	}
+	upstream.digest = "sha256:second"
+
+	// Expired entries are resolved again
+	now = now.Add(time.Minute)
+	if digest, _ := cache.GetDigest(context.Background(), registry, tag, ClientOptions{}); digest != "sha256:second" {
+		t.Errorf("expected the new digest after the TTL, got %q", digest)
+	}
+	if upstream.calls != 4 {
+		t.Errorf("expected 4 registry lookups, got %d", upstream.calls)
+	}
+}
+
+func TestDigestCacheErrors(t *testing.T) {
+	upstream := &digestClient{err: errors.New("registry unavailable")}
+	cache := NewDigestCache(upstream, time.Minute)
+
+	for range 2 {
+		if _, err := cache.GetDigest(context.Background(), "registry.example.com", "artifact:v1", ClientOptions{}); err == nil {
+			t.Fatal("expected an error")
+		}
+	}
+	if upstream.calls != 2 {
+		t.Errorf("expected failed lookups not to be cached, got %d lookups", upstream.calls)
+	}
+}
+
+func TestCredentialsHash(t *testing.T) {
+	if credentialsHash(ClientOptions{Username: "ab", Password: "c"}) == credentialsHash(ClientOptions{Username: "a", Password: "bc"}) {
+		t.Error("expected different credentials to have different hashes")
+	}
+	if credentialsHash(ClientOptions{Credentials: []byte("x")}) != credentialsHash(ClientOptions{Credentials: []byte("x")}) {
+		t.Error("expected equal credentials to have equal hashes")
+	}
+}
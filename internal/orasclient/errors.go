@@ -0,0 +1,73 @@
+package orasclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+)
+
+// Categories of registry errors. The functions accessing registries wrap the errors of the registry
+// requests with one of them, so callers can decide with errors.Is whether retrying makes sense. The
+// original error stays in the chain, e.g. for errors.As with *errcode.ErrorResponse.
+var (
+	// ErrAuth means the registry rejected the credentials (401 Unauthorized or 403 Forbidden);
+	// retrying won't help until the credentials change.
+	ErrAuth = errors.New("registry authentication failed")
+	// ErrNotFound means the repository, the tag or a blob doesn't exist.
+	ErrNotFound = errors.New("not found in registry")
+	// ErrTransient means the request failed for a reason that is likely to go away, e.g. a timeout,
+	// a refused or reset connection, 429 Too Many Requests or a 5xx response.
+	ErrTransient = errors.New("transient registry error")
+)
+
+// classifyError wraps the error of a registry request with ErrAuth, ErrNotFound or ErrTransient.
+// Errors that fit none of them or are already classified are returned unchanged.
+func classifyError(err error) error {
+	if err == nil || errors.Is(err, ErrAuth) || errors.Is(err, ErrNotFound) || errors.Is(err, ErrTransient) {
+		return err
+	}
+	if category := errorCategory(err); category != nil {
+		return fmt.Errorf("%w: %w", category, err)
+	}
+	return err
+}
+
+// errorCategory returns the category of the error, or nil if it has none.
+func errorCategory(err error) error {
+	var errResp *errcode.ErrorResponse
+	if errors.As(err, &errResp) {
+		switch {
+		case errResp.StatusCode == http.StatusUnauthorized || errResp.StatusCode == http.StatusForbidden:
+			return ErrAuth
+		case errResp.StatusCode == http.StatusNotFound:
+			return ErrNotFound
+		case errResp.StatusCode == http.StatusTooManyRequests || errResp.StatusCode == http.StatusRequestTimeout ||
+			errResp.StatusCode >= http.StatusInternalServerError:
+			return ErrTransient
+		}
+		return nil
+	}
+	if errors.Is(err, errdef.ErrNotFound) {
+		return ErrNotFound
+	}
+
+	var netErr net.Error
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrTransient
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return ErrTransient
+	case errors.As(err, &opErr), errors.As(err, &dnsErr):
+		// Refused or reset connections and failed lookups, but not e.g. certificate errors
+		return ErrTransient
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package orasclient
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "unauthorized", err: &errcode.ErrorResponse{StatusCode: http.StatusUnauthorized}, want: ErrAuth},
+		{name: "forbidden", err: &errcode.ErrorResponse{StatusCode: http.StatusForbidden}, want: ErrAuth},
+		{name: "not found response", err: &errcode.ErrorResponse{StatusCode: http.StatusNotFound}, want: ErrNotFound},
+		{name: "not found", err: fmt.Errorf("v1: %w", errdef.ErrNotFound), want: ErrNotFound},
+		{name: "too many requests", err: &errcode.ErrorResponse{StatusCode: http.StatusTooManyRequests}, want: ErrTransient},
+		{name: "unavailable", err: &errcode.ErrorResponse{StatusCode: http.StatusServiceUnavailable}, want: ErrTransient},
+		{name: "connection refused", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, want: ErrTransient},
+		{name: "bad request", err: &errcode.ErrorResponse{StatusCode: http.StatusBadRequest}},
+		{name: "other error", err: errors.New("invalid manifest")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifyError(tt.err)
+			for _, category := range []error{ErrAuth, ErrNotFound, ErrTransient} {
+				if errors.Is(got, category) != (category == tt.want) {
+					t.Errorf("classifyError(%v) = %v, want category %v", tt.err, got, tt.want)
+				}
+			}
+			if !errors.Is(got, tt.err) {
+				t.Errorf("classifyError(%v) = %v, lost the original error", tt.err, got)
+			}
+		})
+	}
+}
+
+func TestGetDigestClassifiesErrors(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/unavailable") {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	registry := strings.TrimPrefix(server.URL, "https://") + "/configs"
+	opts := ClientOptions{
+		CACertificates: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}),
+		Retry:          RetryPolicy{MaxRetries: 0, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+	}
+
+	if _, err := GetDigest(context.Background(), registry, "missing", opts); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := GetDigest(context.Background(), registry, "unavailable", opts); !errors.Is(err, ErrTransient) {
+		t.Errorf("expected ErrTransient, got %v", err)
+	}
+}
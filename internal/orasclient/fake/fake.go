@@ -0,0 +1,215 @@
+// Package fake provides an in-memory orasclient.ArtifactClient for tests and local development.
+// It serves predetermined artifacts without a registry and lets tests change them between
+// reconciles or inject errors.
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/opencontainers/go-digest"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+
+	"github.com/mariusbertram/oci-resource-sync-operator/internal/orasclient"
+)
+
+// Client is an orasclient.ArtifactClient that serves artifacts from memory.
+// Artifacts are addressed by registry and tag, e.g. ("registry.example.com/configs", "v1").
+// The zero value is not usable, create clients with NewClient.
+type Client struct {
+	mu        sync.Mutex
+	artifacts map[string]orasclient.Filemap
+	referrers map[digest.Digest]map[string][]byte
+	errors    map[string]error
+	rejected  map[string]bool
+	verifyErr error
+	pulls     map[string]int
+}
+
+var _ orasclient.ArtifactClient = &Client{}
+
+// NewClient returns a Client without any artifacts.
+func NewClient() *Client {
+	return &Client{
+		artifacts: map[string]orasclient.Filemap{},
+		referrers: map[digest.Digest]map[string][]byte{},
+		errors:    map[string]error{},
+		rejected:  map[string]bool{},
+		pulls:     map[string]int{},
+	}
+}
+
+// artifactKey returns the key of the artifact in the maps of the Client.
+func artifactKey(registry, tag string) string {
+	return registry + ":" + tag
+}
+
+// SetArtifact serves the files as the artifact with the tag and returns its digest. The digest is
+// derived from the files, so setting different files simulates a new version of the artifact.
+func (c *Client) SetArtifact(registry, tag string, files map[string][]byte) digest.Digest {
+	// json.Marshal sorts the keys, so equal files always produce the same digest
+	content, err := json.Marshal(files)
+	if err != nil {
+		panic(err)
+	}
+	artifactDigest := digest.FromBytes(content)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.artifacts[artifactKey(registry, tag)] = orasclient.Filemap{Digest: artifactDigest, Files: copyFiles(files)}
+	return artifactDigest
+}
+
+// SetAnnotations serves the annotations as the manifest annotations of the artifact with the tag, e.g. to
+// check PullOptions.Gate. The artifact has to be set first.
+func (c *Client) SetAnnotations(registry, tag string, annotations map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	artifact := c.artifacts[artifactKey(registry, tag)]
+	artifact.Annotations = maps.Clone(annotations)
+	c.artifacts[artifactKey(registry, tag)] = artifact
+}
+
+// SetReferrerFiles serves the files as the referrers of the subject.
+func (c *Client) SetReferrerFiles(subject digest.Digest, files map[string][]byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.referrers[subject] = copyFiles(files)
+}
+
+// SetError makes every request for the artifact with the tag fail with err; nil removes the error.
+func (c *Client) SetError(registry, tag string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == nil {
+		delete(c.errors, artifactKey(registry, tag))
+		return
+	}
+	c.errors[artifactKey(registry, tag)] = err
+}
+
+// RejectCredentials makes every request for the artifact with the tag that carries credentials
+// fail with 401 Unauthorized, like a public registry rejecting stale credentials. Anonymous
+// requests still succeed.
+func (c *Client) RejectCredentials(registry, tag string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rejected[artifactKey(registry, tag)] = true
+}
+
+// SetVerifyError makes VerifySignature fail with err; nil accepts every signature.
+func (c *Client) SetVerifyError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.verifyErr = err
+}
+
+// Pulls returns how often the files of the artifact with the tag were downloaded.
+func (c *Client) Pulls(registry, tag string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pulls[artifactKey(registry, tag)]
+}
+
+// artifact returns the artifact with the tag or the error configured for it.
+func (c *Client) artifact(registry, tag string, opts orasclient.ClientOptions) (orasclient.Filemap, error) {
+	key := artifactKey(registry, tag)
+	if err, ok := c.errors[key]; ok {
+		return orasclient.Filemap{}, err
+	}
+	if c.rejected[key] && (len(opts.Credentials) > 0 || opts.Username != "") {
+		host, repository, _ := strings.Cut(registry, "/")
+		return orasclient.Filemap{}, fmt.Errorf("%w: %w", orasclient.ErrAuth, &errcode.ErrorResponse{
+			Method:     http.MethodGet,
+			URL:        &url.URL{Scheme: "https", Host: host, Path: "/v2/" + repository + "/manifests/" + tag},
+			StatusCode: http.StatusUnauthorized,
+		})
+	}
+	artifact, ok := c.artifacts[key]
+	if !ok {
+		return orasclient.Filemap{}, fmt.Errorf("%w: %s: %w", orasclient.ErrNotFound, key, errdef.ErrNotFound)
+	}
+	return artifact, nil
+}
+
+func (c *Client) GetDigest(ctx context.Context, registry string, tag string, opts orasclient.ClientOptions) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	artifact, err := c.artifact(registry, tag, opts)
+	if err != nil {
+		return "", err
+	}
+	return string(artifact.Digest), nil
+}
+
+func (c *Client) GetFiles(ctx context.Context, registry string, tag string, opts orasclient.ClientOptions,
+	pullOptions orasclient.PullOptions) (orasclient.Filemap, error) {
+	// A cancelled download fails like one against a registry
+	if err := ctx.Err(); err != nil {
+		return orasclient.Filemap{}, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	artifact, err := c.artifact(registry, tag, opts)
+	if err != nil {
+		return orasclient.Filemap{}, err
+	}
+	if err := pullOptions.Gate.Check(artifact.Annotations); err != nil {
+		return orasclient.Filemap{}, err
+	}
+	c.pulls[artifactKey(registry, tag)]++
+
+	// Every file is served as a layer titled with its key
+	files := copyFiles(artifact.Files)
+	if len(pullOptions.LayerTitles) > 0 {
+		files = make(map[string][]byte, len(pullOptions.LayerTitles))
+		for _, title := range pullOptions.LayerTitles {
+			content, ok := artifact.Files[title]
+			if !ok {
+				return orasclient.Filemap{}, fmt.Errorf("%w: %s", orasclient.ErrLayerNotFound, title)
+			}
+			files[title] = append([]byte(nil), content...)
+		}
+	}
+
+	var size int64
+	for _, content := range files {
+		size += int64(len(content))
+	}
+	if pullOptions.MaxArtifactSize > 0 && size > pullOptions.MaxArtifactSize {
+		return orasclient.Filemap{}, fmt.Errorf("%w: %d bytes, %d allowed", orasclient.ErrArtifactTooLarge, size, pullOptions.MaxArtifactSize)
+	}
+	return orasclient.Filemap{Digest: artifact.Digest, Files: files, Annotations: maps.Clone(artifact.Annotations)}, nil
+}
+
+func (c *Client) GetReferrerFiles(_ context.Context, _ string, subject digest.Digest, _ []string,
+	_ int64, _ orasclient.ClientOptions) (map[string][]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return copyFiles(c.referrers[subject]), nil
+}
+
+func (c *Client) VerifySignature(_ context.Context, _ string, _ digest.Digest, _ []byte, _ orasclient.ClientOptions) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.verifyErr
+}
+
+// copyFiles returns a copy of the files so callers can't modify the served artifacts.
+func copyFiles(files map[string][]byte) map[string][]byte {
+	copied := make(map[string][]byte, len(files))
+	for key, content := range files {
+		copied[key] = append([]byte(nil), content...)
+	}
+	return copied
+}
@@ -0,0 +1,114 @@
+package orasclient
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/file"
+)
+
+// DefaultMemoryThreshold is the default of PullOptions.MemoryThreshold. Secrets hold at most 1MiB, so
+// most artifacts synced into Secrets stay below it.
+const DefaultMemoryThreshold = 8 << 20
+
+// fetchedFileMode is the permission bits of the files fetched into memory, the mode the file store
+// creates its files with.
+const fetchedFileMode = 0o644
+
+// inMemory reports whether the layers of the manifest, which announce size bytes, are fetched into memory
+// with fetchManifest instead of being downloaded to a temporary directory. Layers the file store would
+// unpack into a directory always go through the temporary directory.
+func inMemory(manifest ocispec.Manifest, size int64, pullOptions PullOptions) bool {
+	if pullOptions.MemoryThreshold <= 0 || size > pullOptions.MemoryThreshold {
+		return false
+	}
+	for _, layer := range manifest.Layers {
+		if layer.Annotations[file.AnnotationUnpack] == "true" {
+			return false
+		}
+	}
+	return true
+}
+
+// fetchManifest fetches the selected layers of an artifact manifest (see downloadManifest) straight into
+// memory and collects them like collectFiles would collect the files the file store writes: every layer
+// with an org.opencontainers.image.title annotation is a file at that path, layers without one are
+// skipped. The blobs are verified against their digests while they are read. The progress is logged and
+// counted like the downloads of downloadManifest.
+func fetchManifest(ctx context.Context, target oras.ReadOnlyTarget, manifest ocispec.Manifest,
+	manifestDescriptor ocispec.Descriptor, size int64, pullOptions PullOptions) (*fileCollector, error) {
+	layers, err := selectLayers(manifest, pullOptions.LayerTitles, pullOptions.MediaTypes)
+	if err != nil {
+		return nil, err
+	}
+	titled := make(map[string]ocispec.Descriptor, len(layers))
+	for _, layer := range layers {
+		title := layer.Annotations[ocispec.AnnotationTitle]
+		if title == "" {
+			continue
+		}
+		// The file store refuses to write outside of its directory as well
+		if !filepath.IsLocal(filepath.FromSlash(title)) {
+			return nil, fmt.Errorf("the layer title %s points outside of the artifact", title)
+		}
+		if _, ok := titled[path.Clean(title)]; ok {
+			return nil, fmt.Errorf("%w: %s", file.ErrDuplicateName, title)
+		}
+		titled[path.Clean(title)] = layer
+	}
+	titles := make([]string, 0, len(titled))
+	for title := range titled {
+		titles = append(titles, title)
+	}
+	sort.Strings(titles)
+
+	collector := newFileCollector(pullOptions)
+	progress := newCopyProgress(ctx, size)
+	ctx, span := tracer.Start(ctx, "copy", trace.WithAttributes(
+		attribute.String("digest", manifestDescriptor.Digest.String()), attribute.Bool("memory", true)))
+	err = func() error {
+		for _, title := range titles {
+			layer := titled[title]
+			if collector.skips(title) {
+				continue
+			}
+			if err := collector.reserve(layer.Size); err != nil {
+				return err
+			}
+			if err := progress.preCopy(ctx, layer); err != nil {
+				return err
+			}
+			blob, err := content.FetchAll(ctx, target, layer)
+			if err != nil {
+				return err
+			}
+			if err := progress.postCopy(ctx, layer); err != nil {
+				return err
+			}
+			if pullOptions.ExtractArchives {
+				if extracted, err := extractArchive(collector, title, blob); err != nil {
+					return err
+				} else if extracted {
+					continue
+				}
+			}
+			if err := collector.add(title, blob, fetchedFileMode); err != nil {
+				return err
+			}
+		}
+		return nil
+	}()
+	endSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+	return collector, nil
+}
@@ -0,0 +1,75 @@
+package orasclient
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content/file"
+)
+
+func TestInMemory(t *testing.T) {
+	manifest := ocispec.Manifest{Layers: []ocispec.Descriptor{
+		{Annotations: map[string]string{ocispec.AnnotationTitle: "app.yaml"}},
+	}}
+	unpacked := ocispec.Manifest{Layers: []ocispec.Descriptor{
+		{Annotations: map[string]string{ocispec.AnnotationTitle: "conf", file.AnnotationUnpack: "true"}},
+	}}
+	tests := []struct {
+		name      string
+		manifest  ocispec.Manifest
+		size      int64
+		threshold int64
+		want      bool
+	}{
+		{name: "below threshold", manifest: manifest, size: 100, threshold: 1000, want: true},
+		{name: "at threshold", manifest: manifest, size: 1000, threshold: 1000, want: true},
+		{name: "above threshold", manifest: manifest, size: 1001, threshold: 1000, want: false},
+		{name: "disabled", manifest: manifest, size: 100, threshold: 0, want: false},
+		{name: "directory layer", manifest: unpacked, size: 100, threshold: 1000, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inMemory(tt.manifest, tt.size, PullOptions{MemoryThreshold: tt.threshold}); got != tt.want {
+				t.Errorf("inMemory() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPullFilesInMemory(t *testing.T) {
+	ctx := context.Background()
+	store := pushTestArtifact(t, map[string]string{
+		"app.yaml":         "app",
+		"conf/db.yaml":     "db",
+		"conf/nested/x.sh": "x",
+	})
+
+	tests := []struct {
+		name        string
+		pullOptions PullOptions
+	}{
+		{name: "top level files", pullOptions: PullOptions{}},
+		{name: "path separator", pullOptions: PullOptions{PathSeparator: "__"}},
+		{name: "strip prefix", pullOptions: PullOptions{StripPrefix: "conf/"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The files fetched into memory are the same as the files read from the temporary directory
+			want, err := pullFiles(ctx, store, "v1", tt.pullOptions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.pullOptions.MemoryThreshold = DefaultMemoryThreshold
+			got, err := pullFiles(ctx, store, "v1", tt.pullOptions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The modes of the downloaded files depend on the umask
+			if got.Digest != want.Digest || !reflect.DeepEqual(got.Files, want.Files) || !reflect.DeepEqual(got.Paths, want.Paths) {
+				t.Errorf("pullFiles() in memory = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package orasclient
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/blang/semver/v4"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// ErrArtifactGated is returned when the artifact doesn't meet the PullOptions.Gate, e.g. because its
+// version is below the minimum version.
+var ErrArtifactGated = errors.New("artifact doesn't meet the sync gate")
+
+// ErrInvalidGate is returned when the minimum version of the PullOptions.Gate isn't a semantic version.
+var ErrInvalidGate = errors.New("invalid sync gate")
+
+// Gate is a floor an artifact has to meet to be pulled, checked against the annotations of its manifest (or
+// image index) before any layer is downloaded. The zero value lets every artifact through.
+type Gate struct {
+	// MinVersion is the lowest semantic version (a leading "v" is allowed) in the
+	// org.opencontainers.image.version annotation that passes; empty disables the check.
+	MinVersion string
+	// NotBefore is the earliest time in the org.opencontainers.image.created annotation (RFC 3339) that
+	// passes; the zero time disables the check.
+	NotBefore time.Time
+}
+
+// Check returns ErrArtifactGated (wrapped) if the annotations don't meet the gate, including artifacts
+// without the annotation or with a value that can't be parsed, and ErrInvalidGate (wrapped) if the gate
+// itself is invalid.
+func (g Gate) Check(annotations map[string]string) error {
+	if g.MinVersion != "" {
+		minVersion, err := semver.ParseTolerant(g.MinVersion)
+		if err != nil {
+			return fmt.Errorf("%w: minimum version %q: %v", ErrInvalidGate, g.MinVersion, err)
+		}
+		value, ok := annotations[ocispec.AnnotationVersion]
+		if !ok {
+			return fmt.Errorf("%w: no %s annotation, %s required", ErrArtifactGated, ocispec.AnnotationVersion, g.MinVersion)
+		}
+		version, err := semver.ParseTolerant(value)
+		if err != nil {
+			return fmt.Errorf("%w: version %q is not a semantic version: %v", ErrArtifactGated, value, err)
+		}
+		if version.LT(minVersion) {
+			return fmt.Errorf("%w: version %s is below the minimum version %s", ErrArtifactGated, value, g.MinVersion)
+		}
+	}
+	if !g.NotBefore.IsZero() {
+		value, ok := annotations[ocispec.AnnotationCreated]
+		if !ok {
+			return fmt.Errorf("%w: no %s annotation, created at or after %s required", ErrArtifactGated,
+				ocispec.AnnotationCreated, g.NotBefore.UTC().Format(time.RFC3339))
+		}
+		created, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return fmt.Errorf("%w: creation time %q is not an RFC 3339 time: %v", ErrArtifactGated, value, err)
+		}
+		if created.Before(g.NotBefore) {
+			return fmt.Errorf("%w: created at %s, before %s", ErrArtifactGated, value, g.NotBefore.UTC().Format(time.RFC3339))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package orasclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestGateCheck(t *testing.T) {
+	notBefore := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		gate        Gate
+		annotations map[string]string
+		wantErr     error
+	}{
+		{name: "no gate"},
+		{name: "newer version", gate: Gate{MinVersion: "1.4.0"},
+			annotations: map[string]string{ocispec.AnnotationVersion: "1.10.0"}},
+		{name: "same version with v prefix", gate: Gate{MinVersion: "v1.4"},
+			annotations: map[string]string{ocispec.AnnotationVersion: "v1.4.0"}},
+		{name: "older version", gate: Gate{MinVersion: "1.4.0"},
+			annotations: map[string]string{ocispec.AnnotationVersion: "1.3.9"}, wantErr: ErrArtifactGated},
+		{name: "pre-release of the minimum version", gate: Gate{MinVersion: "1.4.0"},
+			annotations: map[string]string{ocispec.AnnotationVersion: "1.4.0-rc.1"}, wantErr: ErrArtifactGated},
+		{name: "missing version", gate: Gate{MinVersion: "1.4.0"}, wantErr: ErrArtifactGated},
+		{name: "version that isn't semver", gate: Gate{MinVersion: "1.4.0"},
+			annotations: map[string]string{ocispec.AnnotationVersion: "latest"}, wantErr: ErrArtifactGated},
+		{name: "invalid minimum version", gate: Gate{MinVersion: "one"},
+			annotations: map[string]string{ocispec.AnnotationVersion: "1.0.0"}, wantErr: ErrInvalidGate},
+		{name: "created after", gate: Gate{NotBefore: notBefore},
+			annotations: map[string]string{ocispec.AnnotationCreated: "2026-03-02T10:00:00Z"}},
+		{name: "created at", gate: Gate{NotBefore: notBefore},
+			annotations: map[string]string{ocispec.AnnotationCreated: "2026-03-01T01:00:00+01:00"}},
+		{name: "created before", gate: Gate{NotBefore: notBefore},
+			annotations: map[string]string{ocispec.AnnotationCreated: "2026-02-28T23:59:59Z"}, wantErr: ErrArtifactGated},
+		{name: "missing creation time", gate: Gate{NotBefore: notBefore}, wantErr: ErrArtifactGated},
+		{name: "invalid creation time", gate: Gate{NotBefore: notBefore},
+			annotations: map[string]string{ocispec.AnnotationCreated: "yesterday"}, wantErr: ErrArtifactGated},
+		{name: "both met", gate: Gate{MinVersion: "2.0.0", NotBefore: notBefore},
+			annotations: map[string]string{ocispec.AnnotationVersion: "2.0.0", ocispec.AnnotationCreated: "2026-03-02T00:00:00Z"}},
+		{name: "only the version met", gate: Gate{MinVersion: "2.0.0", NotBefore: notBefore},
+			annotations: map[string]string{ocispec.AnnotationVersion: "2.0.0", ocispec.AnnotationCreated: "2025-03-02T00:00:00Z"},
+			wantErr:     ErrArtifactGated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.gate.Check(tt.annotations)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Check() = %v, want nil", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Check() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPullFilesGate(t *testing.T) {
+	ctx := context.Background()
+	// PackManifest annotates the manifest with the time it was created
+	store := pushTestArtifact(t, map[string]string{"app.yaml": "app"})
+
+	if _, err := pullFiles(ctx, store, "v1", PullOptions{Gate: Gate{NotBefore: time.Now().Add(-time.Hour)}}); err != nil {
+		t.Errorf("expected an artifact created after NotBefore to be pulled, got %v", err)
+	}
+	_, err := pullFiles(ctx, store, "v1", PullOptions{Gate: Gate{NotBefore: time.Now().Add(time.Hour)}})
+	if !errors.Is(err, ErrArtifactGated) {
+		t.Errorf("expected ErrArtifactGated for an artifact created before NotBefore, got %v", err)
+	}
+	_, err = pullFiles(ctx, store, "v1", PullOptions{Gate: Gate{MinVersion: "1.0.0"}})
+	if !errors.Is(err, ErrArtifactGated) {
+		t.Errorf("expected ErrArtifactGated for an artifact without a version, got %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package orasclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+)
+
+// ErrPlatformNotFound is returned when an image index has no manifest for PullOptions.Platform.
+var ErrPlatformNotFound = errors.New("image index has no manifest for the platform")
+
+// ErrConflictingFiles is returned when different files of an artifact would be stored under the same key, e.g.
+// files with the same name in the manifests of an image index.
+var ErrConflictingFiles = errors.New("different files would be stored under the same key")
+
+// artifactManifest is an image manifest of an artifact together with its descriptor.
+type artifactManifest struct {
+	descriptor ocispec.Descriptor
+	manifest   ocispec.Manifest
+}
+
+// ParsePlatform parses a platform in the form os/architecture[/variant], e.g. linux/arm64/v8.
+func ParsePlatform(platform string) (ocispec.Platform, error) {
+	parts := strings.Split(platform, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" || (len(parts) == 3 && parts[2] == "") {
+		return ocispec.Platform{}, fmt.Errorf("invalid platform %q, expected os/architecture[/variant]", platform)
+	}
+	parsed := ocispec.Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		parsed.Variant = parts[2]
+	}
+	return parsed, nil
+}
+
+// platformMatches reports whether the platform of an index entry matches the wanted platform. The
+// variant is only compared if the wanted platform has one.
+func platformMatches(platform *ocispec.Platform, wanted ocispec.Platform) bool {
+	if platform == nil || platform.OS != wanted.OS || platform.Architecture != wanted.Architecture {
+		return false
+	}
+	return wanted.Variant == "" || platform.Variant == wanted.Variant
+}
+
+// isIndex reports whether the media type is an OCI image index or a Docker manifest list.
+func isIndex(mediaType string) bool {
+	return mediaType == ocispec.MediaTypeImageIndex || mediaType == dockerManifestListMediaType
+}
+
+// resolveManifests returns the descriptor the reference points to, its annotations and the manifests of
+// the artifact.
+// A reference to an image manifest resolves to that manifest, the platform is ignored. A reference to
+// an image index resolves to the manifests of the index for the platform (os/architecture[/variant]),
+// or to all of its manifests if the platform is empty. Nested indexes are rejected with
+// ErrUnsupportedManifest (wrapped), an index without a manifest for the platform with
+// ErrPlatformNotFound (wrapped).
+func resolveManifests(ctx context.Context, target oras.ReadOnlyTarget, reference string,
+	platform string) (ocispec.Descriptor, map[string]string, []artifactManifest, error) {
+	rootDescriptor, manifest, rootContent, err := fetchManifest(ctx, target, reference)
+	if err != nil {
+		return rootDescriptor, nil, nil, err
+	}
+	if !isIndex(rootDescriptor.MediaType) {
+		return rootDescriptor, manifest.Annotations, []artifactManifest{{descriptor: rootDescriptor, manifest: manifest}}, nil
+	}
+
+	var index ocispec.Index
+	if err := json.Unmarshal(rootContent, &index); err != nil {
+		return rootDescriptor, nil, nil, fmt.Errorf("failed to parse image index of %s: %w", reference, err)
+	}
+	var wanted ocispec.Platform
+	if platform != "" {
+		if wanted, err = ParsePlatform(platform); err != nil {
+			return rootDescriptor, nil, nil, err
+		}
+	}
+
+	var manifests []artifactManifest
+	for _, entry := range index.Manifests {
+		if isIndex(entry.MediaType) {
+			return rootDescriptor, nil, nil, fmt.Errorf("%w: the image index %s contains the nested index %s",
+				ErrUnsupportedManifest, reference, entry.Digest)
+		}
+		if platform != "" && !platformMatches(entry.Platform, wanted) {
+			continue
+		}
+		manifestContent, err := content.FetchAll(ctx, target, entry)
+		if err != nil {
+			return rootDescriptor, nil, nil, fmt.Errorf("failed to fetch manifest %s of %s: %w", entry.Digest, reference, err)
+		}
+		manifest, err := parseManifest(entry, manifestContent)
+		if err != nil {
+			return rootDescriptor, nil, nil, err
+		}
+		manifests = append(manifests, artifactManifest{descriptor: entry, manifest: manifest})
+	}
+	if len(manifests) == 0 {
+		if platform != "" {
+			return rootDescriptor, nil, nil, fmt.Errorf("%w: %s", ErrPlatformNotFound, platform)
+		}
+		return rootDescriptor, nil, nil, fmt.Errorf("%w: the image index %s has no manifests", ErrUnsupportedManifest, reference)
+	}
+	return rootDescriptor, index.Annotations, manifests, nil
+}
+
+// mergeFiles adds the files (and their paths, if any, and permission bits) of a manifest of an image index
+// to the files of the artifact. Files with the same name must have the same content, otherwise ErrConflictingFiles
+// (wrapped) is returned.
+func mergeFiles(files map[string][]byte, paths map[string]string, modes map[string]fs.FileMode,
+	manifestFiles *fileCollector) error {
+	for key, value := range manifestFiles.files {
+		if existing, ok := files[key]; ok && !bytes.Equal(existing, value) {
+			return fmt.Errorf("%w: %s, select a platform", ErrConflictingFiles, key)
+		}
+		files[key] = value
+		modes[key] = manifestFiles.modes[key]
+		if path, ok := manifestFiles.paths[key]; ok {
+			paths[key] = path
+		}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package orasclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+// pushTestIndex pushes an artifact per platform with the files as titled layers and an image index
+// of them tagged "v1" to a new memory store.
+func pushTestIndex(t *testing.T, platforms map[string]map[string]string) *memory.Store {
+	t.Helper()
+	ctx := context.Background()
+	store := memory.New()
+	index := ocispec.Index{MediaType: ocispec.MediaTypeImageIndex}
+	index.SchemaVersion = 2
+	for platform, files := range platforms {
+		var layers []ocispec.Descriptor
+		for name, fileContent := range files {
+			layer := content.NewDescriptorFromBytes("text/plain", []byte(fileContent))
+			layer.Annotations = map[string]string{ocispec.AnnotationTitle: name}
+			// Platforms may share files
+			if exists, err := store.Exists(ctx, layer); err != nil {
+				t.Fatal(err)
+			} else if !exists {
+				if err := store.Push(ctx, layer, bytes.NewReader([]byte(fileContent))); err != nil {
+					t.Fatal(err)
+				}
+			}
+			layers = append(layers, layer)
+		}
+		manifestDescriptor, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, "application/vnd.example",
+			oras.PackManifestOptions{Layers: layers})
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := ParsePlatform(platform)
+		if err != nil {
+			t.Fatal(err)
+		}
+		manifestDescriptor.Platform = &parsed
+		index.Manifests = append(index.Manifests, manifestDescriptor)
+	}
+
+	indexContent, err := json.Marshal(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexDescriptor := content.NewDescriptorFromBytes(ocispec.MediaTypeImageIndex, indexContent)
+	if err := store.Push(ctx, indexDescriptor, bytes.NewReader(indexContent)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Tag(ctx, indexDescriptor, "v1"); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestParsePlatform(t *testing.T) {
+	tests := []struct {
+		platform string
+		want     ocispec.Platform
+		wantErr  bool
+	}{
+		{platform: "linux/amd64", want: ocispec.Platform{OS: "linux", Architecture: "amd64"}},
+		{platform: "linux/arm64/v8", want: ocispec.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}},
+		{platform: "linux", wantErr: true},
+		{platform: "linux//v8", wantErr: true},
+		{platform: "linux/arm64/v8/extra", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.platform, func(t *testing.T) {
+			got, err := ParsePlatform(tt.platform)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePlatform() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPullFiles(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("single manifest ignores the platform", func(t *testing.T) {
+		store := pushTestArtifact(t, map[string]string{"app.yaml": "app"})
+		filemap, err := pullFiles(ctx, store, "v1", PullOptions{Platform: "linux/arm64"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(filemap.Files, map[string][]byte{"app.yaml": []byte("app")}) {
+			t.Errorf("unexpected files %v", filemap.Files)
+		}
+	})
+
+	t.Run("index selects the manifest of the platform", func(t *testing.T) {
+		store := pushTestIndex(t, map[string]map[string]string{
+			"linux/amd64": {"app.yaml": "amd64"},
+			"linux/arm64": {"app.yaml": "arm64"},
+		})
+		filemap, err := pullFiles(ctx, store, "v1", PullOptions{Platform: "linux/arm64"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(filemap.Files, map[string][]byte{"app.yaml": []byte("arm64")}) {
+			t.Errorf("unexpected files %v", filemap.Files)
+		}
+		indexDescriptor, err := store.Resolve(ctx, "v1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if filemap.Digest != indexDescriptor.Digest {
+			t.Errorf("expected the digest of the index %s, got %s", indexDescriptor.Digest, filemap.Digest)
+		}
+	})
+
+	t.Run("index without platform merges the files of all manifests", func(t *testing.T) {
+		store := pushTestIndex(t, map[string]map[string]string{
+			"linux/amd64": {"app.yaml": "app", "amd64.yaml": "amd64"},
+			"linux/arm64": {"app.yaml": "app", "arm64.yaml": "arm64"},
+		})
+		filemap, err := pullFiles(ctx, store, "v1", PullOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[string][]byte{"app.yaml": []byte("app"), "amd64.yaml": []byte("amd64"), "arm64.yaml": []byte("arm64")}
+		if !reflect.DeepEqual(filemap.Files, want) {
+			t.Errorf("unexpected files %v", filemap.Files)
+		}
+	})
+
+	t.Run("index without platform rejects conflicting files", func(t *testing.T) {
+		store := pushTestIndex(t, map[string]map[string]string{
+			"linux/amd64": {"app.yaml": "amd64"},
+			"linux/arm64": {"app.yaml": "arm64"},
+		})
+		if _, err := pullFiles(ctx, store, "v1", PullOptions{}); !errors.Is(err, ErrConflictingFiles) {
+			t.Errorf("expected ErrConflictingFiles, got %v", err)
+		}
+	})
+
+	t.Run("index without a manifest for the platform", func(t *testing.T) {
+		store := pushTestIndex(t, map[string]map[string]string{"linux/amd64": {"app.yaml": "amd64"}})
+		if _, err := pullFiles(ctx, store, "v1", PullOptions{Platform: "linux/s390x"}); !errors.Is(err, ErrPlatformNotFound) {
+			t.Errorf("expected ErrPlatformNotFound, got %v", err)
+		}
+	})
+
+	t.Run("size limit applies to all manifests together", func(t *testing.T) {
+		store := pushTestIndex(t, map[string]map[string]string{
+			"linux/amd64": {"amd64.yaml": "amd64"},
+			"linux/arm64": {"arm64.yaml": "arm64"},
+		})
+		_, _, manifests, err := resolveManifests(ctx, store, "v1", "linux/amd64")
+		if err != nil {
+			t.Fatal(err)
+		}
+		size, err := checkManifest(manifests[0].descriptor, manifests[0].manifest, PullOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := pullFiles(ctx, store, "v1", PullOptions{Platform: "linux/amd64", MaxArtifactSize: size}); err != nil {
+			t.Errorf("expected a single platform to fit, got %v", err)
+		}
+		if _, err := pullFiles(ctx, store, "v1", PullOptions{MaxArtifactSize: size}); !errors.Is(err, ErrArtifactTooLarge) {
+			t.Errorf("expected ErrArtifactTooLarge, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,309 @@
+package orasclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// PathManifest records the original path of every file stored in a Secret, so consumers can rebuild
+// the directory tree of the artifact from the Secret keys regardless of the separator used.
+type PathManifest struct {
+	// Paths maps each Secret key to the slash separated path of the file in the artifact
+	Paths map[string]string `json:"paths"`
+}
+
+// FileKey returns the Secret key of the file at the slash separated path, with every path
+// separator replaced by separator.
+func FileKey(filePath string, separator string) string {
+	return strings.ReplaceAll(filePath, "/", separator)
+}
+
+// EncodePathManifest returns the JSON encoded PathManifest for the key to path mapping.
+func EncodePathManifest(paths map[string]string) ([]byte, error) {
+	return json.Marshal(PathManifest{Paths: paths})
+}
+
+// RebuildTree returns the files of the Secret data by their original path, as recorded in the
+// encoded PathManifest. Keys that are not listed in the manifest are ignored.
+func RebuildTree(data map[string][]byte, manifest []byte) (map[string][]byte, error) {
+	var pathManifest PathManifest
+	if err := json.Unmarshal(manifest, &pathManifest); err != nil {
+		return nil, fmt.Errorf("invalid path manifest: %w", err)
+	}
+	tree := make(map[string][]byte, len(pathManifest.Paths))
+	for key, filePath := range pathManifest.Paths {
+		content, ok := data[key]
+		if !ok {
+			return nil, fmt.Errorf("path manifest lists key %s which is missing", key)
+		}
+		tree[filePath] = content
+	}
+	return tree, nil
+}
+
+// fileCollector gathers the files of an artifact by their key while enforcing the size and file count limits.
+type fileCollector struct {
+	maxSize int64
+	// maxFiles is the maximum number of files; 0 means unlimited
+	maxFiles  int
+	separator string
+	// stripPrefix is removed from the paths below it, e.g. "dist/"; empty keeps the paths unchanged
+	stripPrefix string
+	totalSize   int64
+	files       map[string][]byte
+	paths       map[string]string
+	// modes maps every key to the permission bits of its file
+	modes map[string]fs.FileMode
+	// origins maps every key to the path of its file to detect paths stored under the same key;
+	// nil if paths can't collide
+	origins map[string]string
+	// decompress enables the decompression of the files with the keys in decompressKeys, or of all
+	// files if decompressKeys is empty
+	decompress     bool
+	decompressKeys map[string]struct{}
+}
+
+// reserve accounts for size more bytes and fails with ErrArtifactTooLarge (wrapped) above the limit.
+func (c *fileCollector) reserve(size int64) error {
+	if c.maxSize > 0 && c.totalSize+size > c.maxSize {
+		return fmt.Errorf("%w: more than %d bytes", ErrArtifactTooLarge, c.maxSize)
+	}
+	c.totalSize += size
+	return nil
+}
+
+// expansionLimit is the maximum size of the files including the content extracted from archives and
+// decompressed files: the size limit, or defaultExpansionLimit without one.
+func (c *fileCollector) expansionLimit() int64 {
+	if c.maxSize > 0 {
+		return c.maxSize
+	}
+	return defaultExpansionLimit
+}
+
+// reserveExpanded is reserve for extracted content, which is limited by expansionLimit even without a
+// size limit.
+func (c *fileCollector) reserveExpanded(size int64) error {
+	if limit := c.expansionLimit(); c.totalSize+size > limit {
+		return fmt.Errorf("%w: more than %d bytes after extraction", ErrArtifactTooLarge, limit)
+	}
+	c.totalSize += size
+	return nil
+}
+
+// skips reports whether the file at the slash separated path is left out because it isn't at the top
+// level after removing the strip prefix and there is no separator to key it by.
+func (c *fileCollector) skips(filePath string) bool {
+	return c.separator == "" && strings.Contains(strings.TrimPrefix(filePath, c.stripPrefix), "/")
+}
+
+// add stores the content and the permission bits of the file at the slash separated path, after
+// removing the strip prefix. Without a separator only files at the top level are stored.
+func (c *fileCollector) add(filePath string, content []byte, mode fs.FileMode) error {
+	if c.skips(filePath) {
+		return nil
+	}
+	key := filePath
+	if c.stripPrefix != "" {
+		key = strings.TrimPrefix(filePath, c.stripPrefix)
+	}
+	if c.separator != "" {
+		key = FileKey(key, c.separator)
+		c.paths[key] = filePath
+	}
+	if c.origins != nil {
+		// Two paths with the same key could not be told apart anymore
+		if other, ok := c.origins[key]; ok {
+			return fmt.Errorf("%w: files %s and %s are both stored under the key %s", ErrConflictingFiles, other, filePath, key)
+		}
+		c.origins[key] = filePath
+	}
+
+	if _, selected := c.decompressKeys[key]; c.decompress && (len(c.decompressKeys) == 0 || selected) {
+		// The decompressed content counts towards the size limit instead of the compressed content
+		c.totalSize -= int64(len(content))
+		limit := max(c.expansionLimit()-c.totalSize, 0)
+		decompressed, err := Decompress(content, limit)
+		if err != nil {
+			return fmt.Errorf("file %s: %w", filePath, err)
+		}
+		content = decompressed
+		c.totalSize += int64(len(content))
+	}
+	if _, exists := c.files[key]; !exists && c.maxFiles > 0 && len(c.files) >= c.maxFiles {
+		return fmt.Errorf("%w: more than %d files", ErrTooManyFiles, c.maxFiles)
+	}
+	c.files[key] = content
+	c.modes[key] = mode.Perm()
+	return nil
+}
+
+// readFiles reads the files below dirPath. Without pullOptions.PathSeparator only the files directly
+// in dirPath are read and keyed by their name; otherwise subdirectories are read as well and every
+// file is keyed by its relative path joined with the separator. With pullOptions.StripPrefix the
+// prefix is removed from the paths first, so the files directly below it count as top level files.
+// With pullOptions.ExtractArchives, tar and tar+gzip files are replaced by the files they contain.
+// With pullOptions.Decompress, gzip and zstd compressed files are decompressed. The second map holds
+// the relative path of every key when a separator is used.
+func readFiles(dirPath string, pullOptions PullOptions) (map[string][]byte, map[string]string, error) {
+	collector, err := collectFiles(dirPath, pullOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+	return collector.files, collector.paths, nil
+}
+
+// newFileCollector returns an empty fileCollector applying the limits and key layout of the pull options.
+func newFileCollector(pullOptions PullOptions) *fileCollector {
+	collector := &fileCollector{
+		maxSize:    pullOptions.MaxArtifactSize,
+		maxFiles:   pullOptions.MaxFileCount,
+		separator:  pullOptions.PathSeparator,
+		files:      make(map[string][]byte),
+		modes:      make(map[string]fs.FileMode),
+		decompress: pullOptions.Decompress,
+	}
+	if len(pullOptions.DecompressFiles) > 0 {
+		collector.decompressKeys = make(map[string]struct{}, len(pullOptions.DecompressFiles))
+		for _, key := range pullOptions.DecompressFiles {
+			collector.decompressKeys[key] = struct{}{}
+		}
+	}
+	if prefix := strings.Trim(pullOptions.StripPrefix, "/"); prefix != "" {
+		collector.stripPrefix = prefix + "/"
+	}
+	if collector.separator != "" {
+		collector.paths = make(map[string]string)
+	}
+	if collector.separator != "" || collector.stripPrefix != "" {
+		collector.origins = make(map[string]string)
+	}
+	return collector
+}
+
+// collectFiles reads the files below dirPath like readFiles and returns the collector holding their
+// contents, paths and permission bits.
+func collectFiles(dirPath string, pullOptions PullOptions) (*fileCollector, error) {
+	collector := newFileCollector(pullOptions)
+
+	// The files are listed first and read concurrently afterwards, see readConcurrently
+	var pending []pendingFile
+	err := filepath.WalkDir(dirPath, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", filePath, err)
+		}
+		if entry.IsDir() {
+			// Skip subdirectories unless they are mapped to keys or lead to the strip prefix
+			if filePath == dirPath || collector.separator != "" {
+				return nil
+			}
+			relPath, err := filepath.Rel(dirPath, filePath)
+			if err != nil {
+				return err
+			}
+			if !strings.HasPrefix(collector.stripPrefix, filepath.ToSlash(relPath)+"/") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Check the size before reading so an oversized file is never loaded into memory
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("failed to read the file %s: %w", entry.Name(), err)
+		}
+		if err := collector.reserve(info.Size()); err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(dirPath, filePath)
+		if err != nil {
+			return err
+		}
+		pending = append(pending, pendingFile{path: filePath, relPath: filepath.ToSlash(relPath), info: info})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := readConcurrently(pending, maxReadWorkers)
+	if err != nil {
+		return nil, err
+	}
+	// Store the files in the order of the walk, so conflicts and limits are reported deterministically
+	for i, file := range pending {
+		if pullOptions.ExtractArchives {
+			if extracted, err := extractArchive(collector, file.relPath, contents[i]); err != nil {
+				return nil, err
+			} else if extracted {
+				continue
+			}
+		}
+		if err := collector.add(file.relPath, contents[i], file.info.Mode()); err != nil {
+			return nil, err
+		}
+	}
+	return collector, nil
+}
+
+// maxReadWorkers is the number of files of an artifact that are read at the same time.
+const maxReadWorkers = 8
+
+// pendingFile is a file of the artifact that was found by the walk of collectFiles but not read yet.
+type pendingFile struct {
+	// path is the path of the file on disk
+	path string
+	// relPath is the slash separated path of the file in the artifact
+	relPath string
+	info    fs.FileInfo
+}
+
+// readConcurrently reads the files with up to workers workers and returns their contents in the
+// order of the files. Every worker writes to the indexes of its own files only, so no locking is needed.
+// Once a read fails, the remaining files aren't read anymore and the error of the first failed file in
+// their order is returned.
+func readConcurrently(files []pendingFile, workers int) ([][]byte, error) {
+	contents := make([][]byte, len(files))
+	errs := make([]error, len(files))
+	indexes := make(chan int)
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	for range min(workers, len(files)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if failed.Load() {
+					continue
+				}
+				content, err := os.ReadFile(files[i].path)
+				if err != nil {
+					errs[i] = fmt.Errorf("failed to read the file %s: %w", files[i].info.Name(), err)
+					failed.Store(true)
+					continue
+				}
+				contents[i] = content
+			}
+		}()
+	}
+	for i := range files {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	if failed.Load() {
+		for _, err := range errs {
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return contents, nil
+}
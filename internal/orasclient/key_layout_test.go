@@ -0,0 +1,243 @@
+package orasclient
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeTestTree creates the files (by slash separated path) below a temporary directory.
+func writeTestTree(t testing.TB, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for filePath, content := range files {
+		fullPath := filepath.Join(dir, filepath.FromSlash(filePath))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestReadFilesKeyLayout(t *testing.T) {
+	tree := map[string]string{
+		"app.yaml":                 "top level",
+		"conf/db.yaml":             "nested",
+		"conf/tls/ca.pem":          "deeply nested",
+		"certs/with__separator.pm": "separator in the file name",
+	}
+	dir := writeTestTree(t, tree)
+
+	t.Run("without separator only top level files are read", func(t *testing.T) {
+		files, paths, err := readFiles(dir, PullOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != 1 || string(files["app.yaml"]) != "top level" {
+			t.Errorf("unexpected files %v", files)
+		}
+		if paths != nil {
+			t.Errorf("unexpected paths %v", paths)
+		}
+	})
+
+	t.Run("paths are encoded in the keys", func(t *testing.T) {
+		files, _, err := readFiles(dir, PullOptions{PathSeparator: "__"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range []string{"app.yaml", "conf__db.yaml", "conf__tls__ca.pem", "certs__with__separator.pm"} {
+			if _, ok := files[key]; !ok {
+				t.Errorf("missing key %s in %v", key, files)
+			}
+		}
+	})
+
+	t.Run("tree is rebuilt from the keys and the manifest", func(t *testing.T) {
+		files, paths, err := readFiles(dir, PullOptions{PathSeparator: "__"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		manifest, err := EncodePathManifest(paths)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files["paths.json"] = manifest
+
+		rebuilt, err := RebuildTree(files, manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rebuilt) != len(tree) {
+			t.Errorf("rebuilt %d files, want %d", len(rebuilt), len(tree))
+		}
+		for filePath, content := range tree {
+			if !bytes.Equal(rebuilt[filePath], []byte(content)) {
+				t.Errorf("file %s: got %q, want %q", filePath, rebuilt[filePath], content)
+			}
+		}
+	})
+
+	t.Run("colliding keys are rejected", func(t *testing.T) {
+		collisionDir := writeTestTree(t, map[string]string{"a/b": "nested", "a.b": "flat"})
+		_, _, err := readFiles(collisionDir, PullOptions{PathSeparator: "."})
+		if !errors.Is(err, ErrConflictingFiles) || !strings.Contains(err.Error(), "a.b") {
+			t.Errorf("expected ErrConflictingFiles, got %v", err)
+		}
+	})
+}
+
+func TestReadFilesStripPrefix(t *testing.T) {
+	dir := writeTestTree(t, map[string]string{
+		"dist/app.yaml":      "app",
+		"dist/conf/db.yaml":  "db",
+		"distribution/x.txt": "not below the prefix",
+		"README.md":          "readme",
+	})
+
+	t.Run("files directly below the prefix become top level files", func(t *testing.T) {
+		files, _, err := readFiles(dir, PullOptions{StripPrefix: "dist/"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[string][]byte{"app.yaml": []byte("app"), "README.md": []byte("readme")}
+		if !reflect.DeepEqual(files, want) {
+			t.Errorf("got %v, want %v", files, want)
+		}
+	})
+
+	t.Run("nested paths are stripped before they are keyed", func(t *testing.T) {
+		files, paths, err := readFiles(dir, PullOptions{StripPrefix: "dist", PathSeparator: "__"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range []string{"app.yaml", "conf__db.yaml", "distribution__x.txt", "README.md"} {
+			if _, ok := files[key]; !ok {
+				t.Errorf("missing key %s in %v", key, files)
+			}
+		}
+		if paths["conf__db.yaml"] != "dist/conf/db.yaml" {
+			t.Errorf("expected the original path to be recorded, got %v", paths)
+		}
+	})
+
+	t.Run("colliding keys are rejected", func(t *testing.T) {
+		collisionDir := writeTestTree(t, map[string]string{"dist/app.yaml": "dist", "app.yaml": "top level"})
+		_, _, err := readFiles(collisionDir, PullOptions{StripPrefix: "dist"})
+		if !errors.Is(err, ErrConflictingFiles) || !strings.Contains(err.Error(), "app.yaml") {
+			t.Errorf("expected ErrConflictingFiles, got %v", err)
+		}
+	})
+}
+
+func TestRebuildTreeMissingKey(t *testing.T) {
+	manifest, err := EncodePathManifest(map[string]string{"conf__app.yaml": "conf/app.yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RebuildTree(map[string][]byte{}, manifest); err == nil {
+		t.Error("expected an error for a key that is missing from the data")
+	}
+}
+
+func TestReadFilesMaxFileCount(t *testing.T) {
+	dir := writeTestTree(t, map[string]string{"a.yaml": "a", "b.yaml": "b", "c.yaml": "c", "conf/d.yaml": "d"})
+
+	if files, _, err := readFiles(dir, PullOptions{MaxFileCount: 3}); err != nil || len(files) != 3 {
+		t.Errorf("expected the 3 top level files within the limit, got %v, %v", files, err)
+	}
+	if _, _, err := readFiles(dir, PullOptions{MaxFileCount: 3, PathSeparator: "__"}); !errors.Is(err, ErrTooManyFiles) {
+		t.Errorf("expected ErrTooManyFiles, got %v", err)
+	}
+}
+
+func TestCollectFilesModes(t *testing.T) {
+	dir := writeTestTree(t, map[string]string{"app.yaml": "app", "conf/run.sh": "#!/bin/sh"})
+	if err := os.Chmod(filepath.Join(dir, "conf", "run.sh"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	collector, err := collectFiles(dir, PullOptions{PathSeparator: "__"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]fs.FileMode{"app.yaml": 0o644, "conf__run.sh": 0o750}
+	if !reflect.DeepEqual(collector.modes, expected) {
+		t.Errorf("expected the modes %v, got %v", expected, collector.modes)
+	}
+}
+
+func TestReadConcurrently(t *testing.T) {
+	files := map[string]string{}
+	for i := range 20 {
+		files[fmt.Sprintf("file-%02d.txt", i)] = strings.Repeat("x", i)
+	}
+	dir := writeTestTree(t, files)
+
+	collector, err := collectFiles(dir, PullOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(collector.files) != len(files) {
+		t.Fatalf("expected %d files, got %d", len(files), len(collector.files))
+	}
+	for name, content := range files {
+		if string(collector.files[name]) != content {
+			t.Errorf("unexpected content of %s: %q", name, collector.files[name])
+		}
+	}
+
+	// A file that vanished after the walk fails the read
+	var pending []pendingFile
+	for _, name := range []string{"file-01.txt", "file-02.txt"} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pending = append(pending, pendingFile{path: filepath.Join(dir, name), relPath: name, info: info})
+	}
+	if err := os.Remove(filepath.Join(dir, "file-02.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readConcurrently(pending, 4); err == nil || !strings.Contains(err.Error(), "file-02.txt") {
+		t.Errorf("expected the read error of the removed file, got %v", err)
+	}
+}
+
+// BenchmarkReadConcurrently compares reading the files of a large artifact sequentially and with the
+// worker pool of collectFiles.
+func BenchmarkReadConcurrently(b *testing.B) {
+	files := map[string]string{}
+	for i := range 64 {
+		files[fmt.Sprintf("file-%02d.bin", i)] = strings.Repeat("x", 1<<20)
+	}
+	dir := writeTestTree(b, files)
+	var pending []pendingFile
+	for name := range files {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			b.Fatal(err)
+		}
+		pending = append(pending, pendingFile{path: filepath.Join(dir, name), relPath: name, info: info})
+	}
+
+	for _, workers := range []int{1, maxReadWorkers} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			b.SetBytes(int64(len(files)) << 20)
+			for range b.N {
+				if _, err := readConcurrently(pending, workers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package orasclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+// ErrLayerNotFound is returned when PullOptions.LayerTitles names a layer the artifact doesn't contain.
+var ErrLayerNotFound = errors.New("artifact contains no layer with the title")
+
+// ErrNoMatchingMediaType is returned when no layer of an artifact manifest has a media type of
+// PullOptions.MediaTypes.
+var ErrNoMatchingMediaType = errors.New("artifact contains no layer with the media type")
+
+// layerSelected reports whether the layer has one of the titles.
+func layerSelected(layer ocispec.Descriptor, titles []string) bool {
+	title, ok := layer.Annotations[ocispec.AnnotationTitle]
+	if !ok {
+		return false
+	}
+	for _, selected := range titles {
+		if title == selected {
+			return true
+		}
+	}
+	return false
+}
+
+// selectLayers returns the layers of the manifest that are downloaded for the selected titles and
+// media types (path.Match patterns). It returns ErrLayerNotFound (wrapped) if a title matches no layer
+// and ErrNoMatchingMediaType (wrapped) if no layer is left for the media types. Without titles and
+// media types all layers are selected.
+func selectLayers(manifest ocispec.Manifest, titles []string, mediaTypes []string) ([]ocispec.Descriptor, error) {
+	layers := manifest.Layers
+	if len(titles) > 0 {
+		found := make(map[string]bool, len(titles))
+		layers = nil
+		for _, layer := range manifest.Layers {
+			if layerSelected(layer, titles) {
+				layers = append(layers, layer)
+				found[layer.Annotations[ocispec.AnnotationTitle]] = true
+			}
+		}
+		for _, title := range titles {
+			if !found[title] {
+				return nil, fmt.Errorf("%w: %s", ErrLayerNotFound, title)
+			}
+		}
+	}
+	if len(mediaTypes) > 0 {
+		var matching []ocispec.Descriptor
+		for _, layer := range layers {
+			if mediaTypeAllowed(layer.MediaType, mediaTypes) {
+				matching = append(matching, layer)
+			}
+		}
+		if len(matching) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrNoMatchingMediaType, strings.Join(mediaTypes, ", "))
+		}
+		layers = matching
+	}
+	return layers, nil
+}
+
+// findSelectedSuccessors returns a FindSuccessors function for oras.CopyGraphOptions that only
+// returns the config and the layers of an image manifest selected by the titles and media types (see
+// selectLayers), so the other layers are never downloaded.
+func findSelectedSuccessors(titles []string, mediaTypes []string) func(context.Context, content.Fetcher, ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+	return func(ctx context.Context, fetcher content.Fetcher, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+		if desc.MediaType != ocispec.MediaTypeImageManifest {
+			return content.Successors(ctx, fetcher, desc)
+		}
+		manifestContent, err := content.FetchAll(ctx, fetcher, desc)
+		if err != nil {
+			return nil, err
+		}
+		var manifest ocispec.Manifest
+		if err := json.Unmarshal(manifestContent, &manifest); err != nil {
+			return nil, err
+		}
+		layers, err := selectLayers(manifest, titles, mediaTypes)
+		if err != nil {
+			return nil, err
+		}
+		return append([]ocispec.Descriptor{manifest.Config}, layers...), nil
+	}
+}
@@ -0,0 +1,263 @@
+package orasclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/file"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+// pushTestArtifact pushes the files as titled layers of an artifact tagged "v1" to a new memory store.
+func pushTestArtifact(t *testing.T, files map[string]string) *memory.Store {
+	t.Helper()
+	ctx := context.Background()
+	store := memory.New()
+	var layers []ocispec.Descriptor
+	for name, fileContent := range files {
+		layer := content.NewDescriptorFromBytes("text/plain", []byte(fileContent))
+		layer.Annotations = map[string]string{ocispec.AnnotationTitle: name}
+		if err := store.Push(ctx, layer, bytes.NewReader([]byte(fileContent))); err != nil {
+			t.Fatal(err)
+		}
+		layers = append(layers, layer)
+	}
+	manifestDescriptor, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, "application/vnd.example",
+		oras.PackManifestOptions{Layers: layers})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Tag(ctx, manifestDescriptor, "v1"); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestSelectLayers(t *testing.T) {
+	manifest := ocispec.Manifest{Layers: []ocispec.Descriptor{
+		{Annotations: map[string]string{ocispec.AnnotationTitle: "app.yaml"}},
+		{Annotations: map[string]string{ocispec.AnnotationTitle: "db.yaml"}},
+		{},
+	}}
+
+	layers, err := selectLayers(manifest, nil, nil)
+	if err != nil || len(layers) != 3 {
+		t.Errorf("without titles all layers should be selected, got %v, %v", layers, err)
+	}
+
+	layers, err = selectLayers(manifest, []string{"db.yaml"}, nil)
+	if err != nil || len(layers) != 1 || layers[0].Annotations[ocispec.AnnotationTitle] != "db.yaml" {
+		t.Errorf("expected only db.yaml, got %v, %v", layers, err)
+	}
+
+	if _, err = selectLayers(manifest, []string{"missing.yaml"}, nil); !errors.Is(err, ErrLayerNotFound) {
+		t.Errorf("expected ErrLayerNotFound, got %v", err)
+	}
+}
+
+func TestSelectLayersMediaTypes(t *testing.T) {
+	manifest := ocispec.Manifest{Layers: []ocispec.Descriptor{
+		{MediaType: "application/vnd.example.config+yaml", Annotations: map[string]string{ocispec.AnnotationTitle: "app.yaml"}},
+		{MediaType: "application/vnd.example.data", Annotations: map[string]string{ocispec.AnnotationTitle: "data.bin"}},
+		{MediaType: "application/vnd.example.config+yaml", Annotations: map[string]string{ocispec.AnnotationTitle: "db.yaml"}},
+	}}
+
+	layers, err := selectLayers(manifest, nil, []string{"application/vnd.example.*+yaml"})
+	if err != nil || len(layers) != 2 {
+		t.Errorf("expected the yaml layers, got %v, %v", layers, err)
+	}
+
+	// The media types narrow the selected titles down
+	layers, err = selectLayers(manifest, []string{"app.yaml", "data.bin"}, []string{"application/vnd.example.data"})
+	if err != nil || len(layers) != 1 || layers[0].Annotations[ocispec.AnnotationTitle] != "data.bin" {
+		t.Errorf("expected only data.bin, got %v, %v", layers, err)
+	}
+
+	if _, err = selectLayers(manifest, nil, []string{"text/plain"}); !errors.Is(err, ErrNoMatchingMediaType) {
+		t.Errorf("expected ErrNoMatchingMediaType, got %v", err)
+	}
+}
+
+func TestCopySelectedLayers(t *testing.T) {
+	ctx := context.Background()
+	store := pushTestArtifact(t, map[string]string{"app.yaml": "app", "large.bin": "large"})
+
+	dir := t.TempDir()
+	fs, err := file.New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+
+	copyOptions := oras.DefaultCopyOptions
+	copyOptions.FindSuccessors = findSelectedSuccessors([]string{"app.yaml"}, nil)
+	if _, err := oras.Copy(ctx, store, "v1", fs, "v1", copyOptions); err != nil {
+		t.Fatal(err)
+	}
+
+	files, _, err := readFiles(dir, PullOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || string(files["app.yaml"]) != "app" {
+		t.Errorf("expected only app.yaml to be downloaded, got %v", files)
+	}
+}
+
+func TestPullFilesAnnotations(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	layer := content.NewDescriptorFromBytes("text/plain", []byte("app"))
+	layer.Annotations = map[string]string{ocispec.AnnotationTitle: "app.yaml"}
+	if err := store.Push(ctx, layer, bytes.NewReader([]byte("app"))); err != nil {
+		t.Fatal(err)
+	}
+	annotations := map[string]string{ocispec.AnnotationVersion: "1.2.3", ocispec.AnnotationRevision: "abc123"}
+	manifestDescriptor, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, "application/vnd.example",
+		oras.PackManifestOptions{Layers: []ocispec.Descriptor{layer}, ManifestAnnotations: annotations})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Tag(ctx, manifestDescriptor, "v1"); err != nil {
+		t.Fatal(err)
+	}
+
+	filemap, err := pullFiles(ctx, store, "v1", PullOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filemap.Annotations[ocispec.AnnotationVersion] != "1.2.3" || filemap.Annotations[ocispec.AnnotationRevision] != "abc123" {
+		t.Errorf("expected the manifest annotations, got %v", filemap.Annotations)
+	}
+}
+
+func TestPullFilesConfig(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	layer := content.NewDescriptorFromBytes("text/plain", []byte("app"))
+	layer.Annotations = map[string]string{ocispec.AnnotationTitle: "app.yaml"}
+	config := content.NewDescriptorFromBytes("application/vnd.example.config+json", []byte(`{"version":"1.2.3"}`))
+	for _, blob := range []struct {
+		descriptor ocispec.Descriptor
+		data       string
+	}{{layer, "app"}, {config, `{"version":"1.2.3"}`}} {
+		if err := store.Push(ctx, blob.descriptor, bytes.NewReader([]byte(blob.data))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	manifestDescriptor, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, "application/vnd.example",
+		oras.PackManifestOptions{Layers: []ocispec.Descriptor{layer}, ConfigDescriptor: &config})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Tag(ctx, manifestDescriptor, "v1"); err != nil {
+		t.Fatal(err)
+	}
+
+	filemap, err := pullFiles(ctx, store, "v1", PullOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := filemap.Files[ConfigKey]; ok {
+		t.Error("expected the config only with IncludeConfig")
+	}
+	filemap, err = pullFiles(ctx, store, "v1", PullOptions{IncludeConfig: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(filemap.Files[ConfigKey]) != `{"version":"1.2.3"}` || string(filemap.Files["app.yaml"]) != "app" {
+		t.Errorf("expected the config and the files, got %v", filemap.Files)
+	}
+
+	// The empty config of artifacts pushed without a config is skipped
+	filemap, err = pullFiles(ctx, pushTestArtifact(t, map[string]string{"app.yaml": "app"}), "v1", PullOptions{IncludeConfig: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := filemap.Files[ConfigKey]; ok {
+		t.Error("expected the empty config to be skipped")
+	}
+
+	// A file with the key of the config conflicts with it
+	err = addConfig(ctx, store, ocispec.Manifest{Config: config}, map[string][]byte{ConfigKey: []byte("file")})
+	if !errors.Is(err, ErrConflictingFiles) {
+		t.Errorf("expected ErrConflictingFiles, got %v", err)
+	}
+}
+
+func TestPullFilesCancelled(t *testing.T) {
+	store := pushTestArtifact(t, map[string]string{"app.yaml": "app"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := pullFiles(ctx, store, "v1", PullOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancelled download to fail, got %v", err)
+	}
+}
+
+func TestPullFilesReadError(t *testing.T) {
+	// a/b and a.b are stored under the same key, reading the downloaded files fails
+	store := pushTestArtifact(t, map[string]string{"a/b": "nested", "a.b": "flat"})
+	before, err := filepath.Glob("/tmp/oras*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filemap, err := pullFiles(context.Background(), store, "v1", PullOptions{PathSeparator: "."})
+	if !errors.Is(err, ErrConflictingFiles) {
+		t.Errorf("expected the read error, got %v", err)
+	}
+	// The caller gets no files it could write to the target Secret
+	if filemap.Digest != "" || filemap.Files != nil {
+		t.Errorf("expected an empty Filemap with the error, got %+v", filemap)
+	}
+	after, err := filepath.Glob("/tmp/oras*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) > len(before) {
+		t.Errorf("expected the temporary directory to be removed, got %v", after)
+	}
+}
+
+func TestPullFilesMediaTypes(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	var layers []ocispec.Descriptor
+	for _, file := range []struct{ name, mediaType, data string }{
+		{"app.yaml", "application/vnd.example.config+yaml", "app"},
+		{"data.bin", "application/vnd.example.data", "data"},
+	} {
+		layer := content.NewDescriptorFromBytes(file.mediaType, []byte(file.data))
+		layer.Annotations = map[string]string{ocispec.AnnotationTitle: file.name}
+		if err := store.Push(ctx, layer, bytes.NewReader([]byte(file.data))); err != nil {
+			t.Fatal(err)
+		}
+		layers = append(layers, layer)
+	}
+	manifestDescriptor, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, "application/vnd.example",
+		oras.PackManifestOptions{Layers: layers})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Tag(ctx, manifestDescriptor, "v1"); err != nil {
+		t.Fatal(err)
+	}
+
+	filemap, err := pullFiles(ctx, store, "v1", PullOptions{MediaTypes: []string{"application/vnd.example.config+yaml"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filemap.Files) != 1 || string(filemap.Files["app.yaml"]) != "app" {
+		t.Errorf("expected only app.yaml, got %v", filemap.Files)
+	}
+	if _, err := pullFiles(ctx, store, "v1", PullOptions{MediaTypes: []string{"text/plain"}}); !errors.Is(err, ErrNoMatchingMediaType) {
+		t.Errorf("expected ErrNoMatchingMediaType, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package orasclient
+
+import (
+	"context"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// LimitedClient is an ArtifactClient that allows at most a fixed number of concurrent registry
+// operations, so that many OCISecrets reconciling at once don't exceed registry rate limits or
+// buffer too many downloads at the same time. Further calls wait for a running one to finish, or fail
+// with the error of their context if it is cancelled first.
+type LimitedClient struct {
+	client ArtifactClient
+	slots  chan struct{}
+}
+
+var _ ArtifactClient = &LimitedClient{}
+
+// NewLimitedClient returns a LimitedClient running at most maxConcurrent operations of the client
+// at a time. A maxConcurrent below one doesn't limit the client, it is returned unchanged.
+func NewLimitedClient(client ArtifactClient, maxConcurrent int) ArtifactClient {
+	if maxConcurrent < 1 {
+		return client
+	}
+	return &LimitedClient{client: client, slots: make(chan struct{}, maxConcurrent)}
+}
+
+// acquire blocks until a slot is free and returns a function releasing it, or returns the error of
+// the context if it is done first.
+func (c *LimitedClient) acquire(ctx context.Context) (func(), error) {
+	select {
+	case c.slots <- struct{}{}:
+		return func() { <-c.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *LimitedClient) GetDigest(ctx context.Context, registry string, tag string, opts ClientOptions) (string, error) {
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	return c.client.GetDigest(ctx, registry, tag, opts)
+}
+
+func (c *LimitedClient) GetFiles(ctx context.Context, registry string, tag string, opts ClientOptions, pullOptions PullOptions) (Filemap, error) {
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return Filemap{}, err
+	}
+	defer release()
+	return c.client.GetFiles(ctx, registry, tag, opts, pullOptions)
+}
+
+func (c *LimitedClient) GetReferrerFiles(ctx context.Context, registry string, subject digest.Digest, artifactTypes []string,
+	maxSize int64, opts ClientOptions) (map[string][]byte, error) {
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return c.client.GetReferrerFiles(ctx, registry, subject, artifactTypes, maxSize, opts)
+}
+
+func (c *LimitedClient) VerifySignature(ctx context.Context, registry string, subject digest.Digest, publicKeyPEM []byte, opts ClientOptions) error {
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return c.client.VerifySignature(ctx, registry, subject, publicKeyPEM, opts)
+}
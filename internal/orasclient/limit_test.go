@@ -0,0 +1,73 @@
+package orasclient
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingClient records the highest number of concurrent GetDigest calls.
+type countingClient struct {
+	OrasClient
+	running atomic.Int32
+	peak    atomic.Int32
+}
+
+func (c *countingClient) GetDigest(context.Context, string, string, ClientOptions) (string, error) {
+	running := c.running.Add(1)
+	defer c.running.Add(-1)
+	for {
+		peak := c.peak.Load()
+		if running <= peak || c.peak.CompareAndSwap(peak, running) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return "sha256:test", nil
+}
+
+func TestLimitedClient(t *testing.T) {
+	counting := &countingClient{}
+	client := NewLimitedClient(counting, 2)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.GetDigest(context.Background(), "registry.example.com", "artifact:v1", ClientOptions{}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak := counting.peak.Load(); peak != 2 {
+		t.Errorf("expected at most 2 concurrent calls, got %d", peak)
+	}
+}
+
+func TestNewLimitedClientUnlimited(t *testing.T) {
+	counting := &countingClient{}
+	if client := NewLimitedClient(counting, 0); client != counting {
+		t.Errorf("expected the client to be returned unchanged, got %T", client)
+	}
+}
+
+func TestLimitedClientCancel(t *testing.T) {
+	client := NewLimitedClient(&countingClient{}, 1).(*LimitedClient)
+	release, err := client.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	// A call waiting for a slot gives up when its context is cancelled, e.g. on shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := client.GetDigest(ctx, "registry.example.com", "artifact:v1", ClientOptions{}); err != context.DeadlineExceeded {
+		t.Errorf("expected the context error, got %v", err)
+	}
+}
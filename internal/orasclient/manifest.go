@@ -0,0 +1,161 @@
+package orasclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+)
+
+// DefaultAllowedMediaTypes are the layer media types accepted by default. They cover files pushed
+// with `oras push` and common plain-text or binary configuration artifacts, but not container image
+// layers such as application/vnd.oci.image.layer.v1.tar+gzip.
+var DefaultAllowedMediaTypes = []string{
+	"application/vnd.oci.image.layer.v1.tar",
+	"application/octet-stream",
+	"application/json",
+	"application/yaml",
+	"application/x-yaml",
+	"application/toml",
+	"application/xml",
+	"application/x-pem-file",
+	"application/*+json",
+	"application/*+yaml",
+	"text/*",
+}
+
+// ErrDisallowedMediaType is returned when an artifact contains a layer whose media type is not allowed.
+var ErrDisallowedMediaType = errors.New("artifact contains a disallowed media type")
+
+// ErrUnsupportedManifest is returned when a reference points to a container image or a nested image
+// index instead of an artifact.
+var ErrUnsupportedManifest = errors.New("unsupported manifest")
+
+// Media types of Docker manifests, which are only used for container images.
+const (
+	dockerManifestMediaType     = "application/vnd.docker.distribution.manifest.v2+json"
+	dockerManifestListMediaType = "application/vnd.docker.distribution.manifest.list.v2+json"
+	dockerImageConfigMediaType  = "application/vnd.docker.container.image.v1+json"
+)
+
+// CheckArtifactManifest verifies that the manifest describes an artifact whose layers are files, i.e.
+// an OCI image manifest (application/vnd.oci.image.manifest.v1+json) whose config is not a container
+// image config. Container images, Docker manifests and image indexes (e.g. multi-platform images)
+// don't lay out as files and are rejected with ErrUnsupportedManifest (wrapped).
+func CheckArtifactManifest(manifestDescriptor ocispec.Descriptor, manifest ocispec.Manifest) error {
+	switch manifestDescriptor.MediaType {
+	case ocispec.MediaTypeImageManifest:
+	case ocispec.MediaTypeImageIndex, dockerManifestListMediaType:
+		return fmt.Errorf("%w: %s is an image index, reference a single artifact instead",
+			ErrUnsupportedManifest, manifestDescriptor.MediaType)
+	case dockerManifestMediaType:
+		return fmt.Errorf("%w: %s is a container image, not an artifact",
+			ErrUnsupportedManifest, manifestDescriptor.MediaType)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedManifest, manifestDescriptor.MediaType)
+	}
+
+	if manifest.Config.MediaType == ocispec.MediaTypeImageConfig || manifest.Config.MediaType == dockerImageConfigMediaType {
+		return fmt.Errorf("%w: the config media type %s belongs to a container image, not an artifact",
+			ErrUnsupportedManifest, manifest.Config.MediaType)
+	}
+	return nil
+}
+
+// fetchManifest fetches the manifest the reference points to and parses it if it's an image manifest.
+// For other manifests (e.g. an index) the returned manifest is empty. The raw content is returned as well.
+func fetchManifest(ctx context.Context, target oras.ReadOnlyTarget, reference string) (ocispec.Descriptor, ocispec.Manifest, []byte, error) {
+	manifestDescriptor, manifestContent, err := oras.FetchBytes(ctx, target, reference, oras.DefaultFetchBytesOptions)
+	if err != nil {
+		return manifestDescriptor, ocispec.Manifest{}, nil, fmt.Errorf("failed to fetch manifest of %s: %w", reference, err)
+	}
+	manifest, err := parseManifest(manifestDescriptor, manifestContent)
+	return manifestDescriptor, manifest, manifestContent, err
+}
+
+// addConfig adds the config blob of the manifest to the files under ConfigKey. The empty config
+// (application/vnd.oci.empty.v1+json) holds no data and is skipped. A file with the key ConfigKey
+// fails with ErrConflictingFiles (wrapped).
+func addConfig(ctx context.Context, target oras.ReadOnlyTarget, manifest ocispec.Manifest, files map[string][]byte) error {
+	if manifest.Config.MediaType == ocispec.MediaTypeEmptyJSON {
+		return nil
+	}
+	if _, exists := files[ConfigKey]; exists {
+		return fmt.Errorf("%w: %s is a file of the artifact and the key of its config", ErrConflictingFiles, ConfigKey)
+	}
+	config, err := content.FetchAll(ctx, target, manifest.Config)
+	if err != nil {
+		return err
+	}
+	files[ConfigKey] = config
+	return nil
+}
+
+// parseManifest parses the content of an image manifest. For other manifests the returned manifest is empty.
+func parseManifest(manifestDescriptor ocispec.Descriptor, manifestContent []byte) (ocispec.Manifest, error) {
+	var manifest ocispec.Manifest
+	if manifestDescriptor.MediaType != ocispec.MediaTypeImageManifest {
+		return manifest, nil
+	}
+	if err := json.Unmarshal(manifestContent, &manifest); err != nil {
+		return manifest, fmt.Errorf("failed to parse manifest %s: %w", manifestDescriptor.Digest, err)
+	}
+	return manifest, nil
+}
+
+// checkManifest verifies the manifest is an artifact whose layers are allowed by the pull options and
+// returns the size of the manifest and the layers that will be downloaded.
+func checkManifest(manifestDescriptor ocispec.Descriptor, manifest ocispec.Manifest, pullOptions PullOptions) (int64, error) {
+	if err := CheckArtifactManifest(manifestDescriptor, manifest); err != nil {
+		return 0, err
+	}
+	// Only the selected layers are downloaded, the others don't count towards the limits
+	var err error
+	manifest.Layers, err = selectLayers(manifest, pullOptions.LayerTitles, pullOptions.MediaTypes)
+	if err != nil {
+		return 0, err
+	}
+	if len(pullOptions.AllowedMediaTypes) > 0 {
+		if err := CheckMediaTypes(manifest, pullOptions.AllowedMediaTypes); err != nil {
+			return 0, err
+		}
+	}
+	return artifactSize(manifestDescriptor, manifest), nil
+}
+
+// artifactSize returns the total size of the manifest, its config and its layers as
+// announced by the registry.
+func artifactSize(manifestDescriptor ocispec.Descriptor, manifest ocispec.Manifest) int64 {
+	size := manifestDescriptor.Size + manifest.Config.Size
+	for _, layer := range manifest.Layers {
+		size += layer.Size
+	}
+	return size
+}
+
+// CheckMediaTypes verifies that every layer of the manifest has a media type matching one of the
+// allowed path.Match patterns (e.g. "text/*"). It returns ErrDisallowedMediaType (wrapped) naming
+// the first offending media type.
+func CheckMediaTypes(manifest ocispec.Manifest, allowed []string) error {
+	for _, layer := range manifest.Layers {
+		if !mediaTypeAllowed(layer.MediaType, allowed) {
+			return fmt.Errorf("%w: %s", ErrDisallowedMediaType, layer.MediaType)
+		}
+	}
+	return nil
+}
+
+// mediaTypeAllowed reports whether the media type matches one of the allowed patterns.
+func mediaTypeAllowed(mediaType string, allowed []string) bool {
+	for _, pattern := range allowed {
+		if matched, err := path.Match(pattern, mediaType); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
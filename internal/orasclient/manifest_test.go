@@ -0,0 +1,118 @@
+package orasclient
+
+import (
+	"errors"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestCheckMediaTypes(t *testing.T) {
+	tests := []struct {
+		name       string
+		mediaTypes []string
+		allowed    []string
+		wantErr    bool
+	}{
+		{
+			name:       "oras push default layer is allowed",
+			mediaTypes: []string{"application/vnd.oci.image.layer.v1.tar"},
+			allowed:    DefaultAllowedMediaTypes,
+		},
+		{
+			name:       "wildcard patterns match",
+			mediaTypes: []string{"text/plain", "application/vnd.example.config+yaml", "application/vnd.example+json"},
+			allowed:    DefaultAllowedMediaTypes,
+		},
+		{
+			name:       "container image layer is disallowed by default",
+			mediaTypes: []string{"application/json", "application/vnd.oci.image.layer.v1.tar+gzip"},
+			allowed:    DefaultAllowedMediaTypes,
+			wantErr:    true,
+		},
+		{
+			name:       "docker image layer is disallowed by default",
+			mediaTypes: []string{"application/vnd.docker.image.rootfs.diff.tar.gzip"},
+			allowed:    DefaultAllowedMediaTypes,
+			wantErr:    true,
+		},
+		{
+			name:       "custom allowlist",
+			mediaTypes: []string{"application/vnd.example.config+yaml"},
+			allowed:    []string{"application/vnd.example.config+yaml"},
+		},
+		{
+			name:       "custom allowlist rejects others",
+			mediaTypes: []string{"text/plain"},
+			allowed:    []string{"application/vnd.example.config+yaml"},
+			wantErr:    true,
+		},
+		{
+			name:    "manifest without layers",
+			allowed: DefaultAllowedMediaTypes,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest := ocispec.Manifest{}
+			for _, mediaType := range tt.mediaTypes {
+				manifest.Layers = append(manifest.Layers, ocispec.Descriptor{MediaType: mediaType})
+			}
+
+			err := CheckMediaTypes(manifest, tt.allowed)
+			if tt.wantErr && !errors.Is(err, ErrDisallowedMediaType) {
+				t.Errorf("CheckMediaTypes() error = %v, want ErrDisallowedMediaType", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("CheckMediaTypes() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckArtifactManifest(t *testing.T) {
+	tests := []struct {
+		name               string
+		manifestMediaType  string
+		configMediaType    string
+		wantUnsupportedErr bool
+	}{
+		{
+			name:              "oras artifact is supported",
+			manifestMediaType: ocispec.MediaTypeImageManifest,
+			configMediaType:   ocispec.MediaTypeEmptyJSON,
+		},
+		{
+			name:              "custom config media type is supported",
+			manifestMediaType: ocispec.MediaTypeImageManifest,
+			configMediaType:   "application/vnd.example.config.v1+json",
+		},
+		{
+			name:               "OCI container image is rejected",
+			manifestMediaType:  ocispec.MediaTypeImageManifest,
+			configMediaType:    ocispec.MediaTypeImageConfig,
+			wantUnsupportedErr: true,
+		},
+		{
+			name:               "Docker container image is rejected",
+			manifestMediaType:  "application/vnd.docker.distribution.manifest.v2+json",
+			wantUnsupportedErr: true,
+		},
+		{
+			name:               "image index is rejected",
+			manifestMediaType:  ocispec.MediaTypeImageIndex,
+			wantUnsupportedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest := ocispec.Manifest{Config: ocispec.Descriptor{MediaType: tt.configMediaType}}
+			err := CheckArtifactManifest(ocispec.Descriptor{MediaType: tt.manifestMediaType}, manifest)
+			if gotErr := errors.Is(err, ErrUnsupportedManifest); gotErr != tt.wantUnsupportedErr {
+				t.Errorf("CheckArtifactManifest() error = %v, want ErrUnsupportedManifest: %v", err, tt.wantUnsupportedErr)
+			}
+		})
+	}
+}
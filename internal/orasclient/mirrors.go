@@ -0,0 +1,32 @@
+package orasclient
+
+import (
+	"errors"
+	"fmt"
+
+	"oras.land/oras-go/v2/registry"
+)
+
+// MirrorRepository returns the repository (e.g. "ghcr.io/org/configs") on the mirror, a registry host with
+// an optional port (e.g. "mirror.example.com:5000"): "mirror.example.com:5000/org/configs". Errors wrap
+// ErrInvalidReference.
+func MirrorRepository(repository, mirror string) (string, error) {
+	ref, err := registry.ParseReference(repository)
+	if err != nil {
+		return "", fmt.Errorf("%w: repository %q: %w", ErrInvalidReference, repository, err)
+	}
+	ref.Registry = mirror
+	if mirror == "" {
+		return "", fmt.Errorf("%w: empty mirror", ErrInvalidReference)
+	} else if err := ref.ValidateRegistry(); err != nil {
+		return "", fmt.Errorf("%w: mirror %q: %w", ErrInvalidReference, mirror, err)
+	}
+	return ref.Registry + "/" + ref.Repository, nil
+}
+
+// IsUnavailableError reports whether the error means the registry is unavailable rather than rejecting
+// the request, so a mirror may serve it: a transient error (ErrTransient), a throttled request
+// (ErrThrottled) or an open circuit breaker (ErrCircuitOpen).
+func IsUnavailableError(err error) bool {
+	return errors.Is(err, ErrTransient) || errors.Is(err, ErrThrottled) || errors.Is(err, ErrCircuitOpen)
+}
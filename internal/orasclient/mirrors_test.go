@@ -0,0 +1,58 @@
+package orasclient
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMirrorRepository(t *testing.T) {
+	tests := []struct {
+		name       string
+		repository string
+		mirror     string
+		want       string
+		wantErr    bool
+	}{
+		{name: "host", repository: "ghcr.io/org/configs", mirror: "mirror.example.com",
+			want: "mirror.example.com/org/configs"},
+		{name: "host with port", repository: "localhost:5000/configs", mirror: "mirror.example.com:5000",
+			want: "mirror.example.com:5000/configs"},
+		{name: "path in the mirror", repository: "ghcr.io/org/configs", mirror: "mirror.example.com/ghcr", wantErr: true},
+		{name: "empty mirror", repository: "ghcr.io/org/configs", mirror: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MirrorRepository(tt.repository, tt.mirror)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidReference) {
+					t.Errorf("expected ErrInvalidReference, got %v", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("MirrorRepository() = %s, %v, want %s", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsUnavailableError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: fmt.Errorf("%w: connection refused", ErrTransient), want: true},
+		{err: &ThrottledError{Host: "ghcr.io", RetryAfter: time.Minute}, want: true},
+		{err: &CircuitOpenError{Host: "ghcr.io", RetryAfter: time.Minute}, want: true},
+		{err: fmt.Errorf("%w: unauthorized", ErrAuth), want: false},
+		{err: fmt.Errorf("%w: manifest unknown", ErrNotFound), want: false},
+		{err: nil, want: false},
+	}
+	for _, tt := range tests {
+		if got := IsUnavailableError(tt.err); got != tt.want {
+			t.Errorf("IsUnavailableError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package orasclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-logr/logr"
+	"golang.org/x/net/http/httpproxy"
+	"oras.land/oras-go/v2/registry/remote/retry"
+)
+
+// ClientOptions configures the connection that CreateClient sets up to a registry.
+type ClientOptions struct {
+	// Credentials are Docker credentials in JSON format; empty means anonymous access
+	Credentials []byte
+	// Username and Password authenticate against the registry instead of Credentials; an empty
+	// Username means Credentials are used
+	Username string
+	Password string
+	// CredentialProvider obtains the credentials from a cloud provider instead of Credentials or Username;
+	// nil means they aren't used
+	CredentialProvider CredentialProvider
+	// Retry configures how failed registry requests are retried; the zero value uses the ORAS defaults
+	Retry RetryPolicy
+	// ProxyURL is the proxy used to reach the registry unless its host is in NO_PROXY; empty honors
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	ProxyURL string
+	// ClientCertificate is presented to registries that require mutual TLS; nil sends no client certificate
+	ClientCertificate *tls.Certificate
+	// CACertificates are PEM encoded CA certificates trusted in addition to the system roots
+	CACertificates []byte
+	// ClockSkewTolerance is how long to wait for a rejected, not yet valid bearer token before retrying;
+	// zero uses DefaultClockSkewTolerance
+	ClockSkewTolerance time.Duration
+	// Logger receives diagnostics such as PossibleClockSkew warnings; the zero value discards them
+	Logger logr.Logger
+	// UserAgent is sent to the registry with every request; empty uses DefaultUserAgent
+	UserAgent string
+	// Scopes are requested with every bearer token in addition to the scopes ORAS negotiates, e.g.
+	// repository:org/base:pull; empty requests the negotiated scopes only
+	Scopes []string
+	// NoCache resolves the digest at the registry even if a DigestCache holds it
+	NoCache bool
+}
+
+// Version is the version of the operator, set at build time with
+// -ldflags "-X github.com/mariusbertram/oci-resource-sync-operator/internal/orasclient.Version=<version>".
+var Version = "dev"
+
+// DefaultUserAgent returns the User-Agent sent to registries unless ClientOptions.UserAgent is set.
+func DefaultUserAgent() string {
+	return "oci-resource-sync-operator/" + Version
+}
+
+// userAgent returns the User-Agent to send to the registry.
+func (opts ClientOptions) userAgent() string {
+	if opts.UserAgent != "" {
+		return opts.UserAgent
+	}
+	return DefaultUserAgent()
+}
+
+// RetryPolicy configures the retries of failed registry requests (5xx, 408 and dial timeouts). Requests
+// rejected with 429 aren't retried but fail with a *ThrottledError.
+// The delay between attempts grows exponentially from BaseDelay up to MaxDelay.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt
+	MaxRetries int
+	// BaseDelay is the delay before the first retry
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between two attempts
+	MaxDelay time.Duration
+}
+
+// newHTTPClient returns an HTTP client that connects through the configured proxy with the
+// configured TLS settings and retries failed requests according to the retry policy, except for
+// throttled requests (see throttleTransport).
+func newHTTPClient(opts ClientOptions) (*http.Client, error) {
+	// http.DefaultTransport honors HTTP_PROXY, HTTPS_PROXY and NO_PROXY (http.ProxyFromEnvironment)
+	base := http.DefaultTransport
+	if opts.ProxyURL != "" || opts.ClientCertificate != nil || len(opts.CACertificates) > 0 {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if opts.ProxyURL != "" {
+			proxy, err := proxyFunc(opts.ProxyURL, httpproxy.FromEnvironment().NoProxy)
+			if err != nil {
+				return nil, err
+			}
+			transport.Proxy = proxy
+		}
+		tlsConfig, err := newTLSConfig(opts)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+		base = transport
+	}
+
+	// Report 429 responses with the Retry-After of the registry instead of retrying them
+	base = &throttleTransport{base: base, now: time.Now}
+
+	policy := opts.Retry
+	transport := retry.NewTransport(base)
+	if policy != (RetryPolicy{}) {
+		transport.Policy = func() retry.Policy {
+			return &retry.GenericPolicy{
+				Retryable: retry.DefaultPredicate,
+				Backoff:   retry.ExponentialBackoff(policy.BaseDelay, 2, 0.1),
+				MinWait:   policy.BaseDelay,
+				MaxWait:   policy.MaxDelay,
+				MaxRetry:  policy.MaxRetries,
+			}
+		}
+	}
+
+	tolerance := opts.ClockSkewTolerance
+	if tolerance == 0 {
+		tolerance = DefaultClockSkewTolerance
+	}
+	return &http.Client{Transport: &clockSkewTransport{
+		base:      transport,
+		logger:    opts.Logger,
+		tolerance: tolerance,
+		now:       time.Now,
+	}}, nil
+}
+
+// proxyFunc returns the http.Transport.Proxy sending the requests through the proxy, except for the
+// requests to localhost, loopback addresses and the hosts matching noProxy, a NO_PROXY value (e.g. "registry.internal,.corp").
+func proxyFunc(proxy, noProxy string) (func(*http.Request) (*url.URL, error), error) {
+	if _, err := url.Parse(proxy); err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %w", proxy, err)
+	}
+	proxyForURL := (&httpproxy.Config{HTTPProxy: proxy, HTTPSProxy: proxy, NoProxy: noProxy}).ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyForURL(req.URL)
+	}, nil
+}
+
+// newTLSConfig returns the TLS configuration presenting the client certificate and trusting the
+// CA certificates of the options.
+func newTLSConfig(opts ClientOptions) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if opts.ClientCertificate != nil {
+		tlsConfig.Certificates = []tls.Certificate{*opts.ClientCertificate}
+	}
+	if len(opts.CACertificates) > 0 {
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM(opts.CACertificates) {
+			return nil, errors.New("no valid PEM encoded CA certificate found")
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,150 @@
+package orasclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestCertificate creates a certificate signed by the parent (or self-signed if parent is nil).
+func newTestCertificate(t *testing.T, commonName string, isCA bool, parent *x509.Certificate,
+	parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, tls.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPair, err := tls.X509KeyPair(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certificate, key, keyPair
+}
+
+func TestNewHTTPClientMutualTLS(t *testing.T) {
+	caCertificate, caKey, _ := newTestCertificate(t, "test-ca", true, nil, nil)
+	_, _, clientCertificate := newTestCertificate(t, "test-client", false, caCertificate, caKey)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(caCertificate)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	noRetry := RetryPolicy{MaxRetries: 0, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	t.Run("client certificate is accepted", func(t *testing.T) {
+		client, err := newHTTPClient(ClientOptions{
+			Retry:             noRetry,
+			ClientCertificate: &clientCertificate,
+			CACertificates:    serverCA,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("request with client certificate failed: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("unexpected status %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("missing client certificate is rejected", func(t *testing.T) {
+		client, err := newHTTPClient(ClientOptions{
+			Retry:          noRetry,
+			CACertificates: serverCA,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Get(server.URL)
+		if err == nil {
+			resp.Body.Close()
+			t.Fatal("request without client certificate succeeded")
+		}
+	})
+
+	t.Run("invalid CA certificates are reported", func(t *testing.T) {
+		if _, err := newHTTPClient(ClientOptions{CACertificates: []byte("not a certificate")}); err == nil {
+			t.Error("expected an error for invalid CA certificates")
+		}
+	})
+}
+
+func TestCreateClientUserAgent(t *testing.T) {
+	userAgents := make(chan string, 10)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents <- r.UserAgent()
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	registry := strings.TrimPrefix(server.URL, "https://") + "/configs"
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	noRetry := RetryPolicy{MaxRetries: 0, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	tests := []struct {
+		name      string
+		userAgent string
+		want      string
+	}{
+		{name: "default", want: DefaultUserAgent()},
+		{name: "override", userAgent: "example-sync/1.0", want: "example-sync/1.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _ = GetDigest(context.Background(), registry, "v1", ClientOptions{CACertificates: serverCA, Retry: noRetry, UserAgent: tt.userAgent})
+			if got := <-userAgents; got != tt.want {
+				t.Errorf("got User-Agent %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,17 +5,22 @@ package orasclient
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/go-logr/logr"
 	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"io/fs"
+	"net/http"
 	"oras.land/oras-go/v2"
 	"oras.land/oras-go/v2/content/file"
 	"oras.land/oras-go/v2/registry"
 	"oras.land/oras-go/v2/registry/remote"
 	"oras.land/oras-go/v2/registry/remote/auth"
 	"oras.land/oras-go/v2/registry/remote/credentials"
-	"oras.land/oras-go/v2/registry/remote/retry"
 	"os"
-	"path/filepath"
 )
 
 // Filemap represents the contents of an OCI artifact.
@@ -26,172 +31,375 @@ type Filemap struct {
 	Digest digest.Digest
 	// Files is a map of filename to file content
 	Files map[string][]byte
+	// Paths maps each key of Files to the original path of the file in the artifact;
+	// only set when PullOptions.PathSeparator is used
+	Paths map[string]string
+	// Modes maps each key of Files to the permission bits of the file in the artifact, so consumers
+	// writing the files to disk can restore them
+	Modes map[string]fs.FileMode
+	// Annotations are the annotations of the manifest, or of the image index, the tag points to
+	Annotations map[string]string
 }
 
 // CreateClient creates and configures a connection to an OCI registry repository.
 //
 // Parameters:
 //   - registry: The address of the OCI registry (e.g., "docker.io/myorg/myrepo")
-//   - creds: Docker credentials in JSON format for authentication, or empty for anonymous access
+//   - opts: The credentials, retry policy and proxy to use for the connection
 //
 // Returns:
 //   - A configured registry.Repository object that can be used to interact with the registry
+//   - An error if the registry address, the credentials or the proxy are invalid
 //
-// The function sets up authentication if credentials are provided, otherwise it configures
-// for anonymous access. It uses retry mechanisms and authentication caching for better performance.
-func CreateClient(registry string, creds []byte) registry.Repository {
+// The function sets up authentication if a credential provider, a username or Docker credentials are
+// provided, otherwise it configures for anonymous access. It uses retry mechanisms and authentication
+// caching for better performance.
+func CreateClient(registry string, opts ClientOptions) (registry.Repository, error) {
 	repo, err := remote.NewRepository(registry)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("%w: registry %q: %w", ErrInvalidReference, registry, err)
 	}
 
-	if len(creds) > 0 {
+	httpClient, err := newHTTPClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Identify the operator to the registry, some registries filter on the User-Agent
+	client := &auth.Client{
+		Client: httpClient,
+		Cache:  auth.NewCache(),
+		Header: http.Header{"User-Agent": {opts.userAgent()}},
+	}
+	if opts.CredentialProvider != nil {
+		// Obtain short-lived credentials from the cloud provider, e.g. an ECR authorization token
+		client.Credential = func(ctx context.Context, hostport string) (auth.Credential, error) {
+			return opts.CredentialProvider.Credential(ctx, hostport)
+		}
+	} else if opts.Username != "" {
+		// Authenticate with the username and password, e.g. from a basic-auth Secret
+		client.Credential = auth.StaticCredential(repo.Reference.Registry, auth.Credential{
+			Username: opts.Username,
+			Password: opts.Password,
+		})
+	} else if len(opts.Credentials) > 0 {
 		// prepare authentication using Docker credentials
-		credStore, err := credentials.NewMemoryStoreFromDockerConfig(creds)
+		credStore, err := credentials.NewMemoryStoreFromDockerConfig(opts.Credentials)
 		if err != nil {
-			panic(err)
-		}
-		// Note: The below code can be omitted if authentication is not required
-		repo.Client = &auth.Client{
-			Client:     retry.DefaultClient,
-			Cache:      auth.NewCache(),
-			Credential: credentials.Credential(credStore),
-		}
-	} else {
-		// Configure for anonymous access
-		repo.Client = &auth.Client{
-			Client: retry.DefaultClient,
-			Cache:  auth.NewCache(),
+			return nil, fmt.Errorf("invalid docker credentials: %w", err)
 		}
+		client.Credential = credentials.Credential(credStore)
 	}
-	return repo
+	// Without credentials the client accesses the registry anonymously
+	repo.Client = client
+	if len(opts.Scopes) > 0 {
+		repo.Client = &scopedClient{Client: client, scopes: opts.Scopes}
+	}
+	return repo, nil
 }
 
 // GetDigest retrieves the content digest (a unique identifier) of an artifact from an OCI registry.
 //
 // Parameters:
+//   - ctx: Cancels the requests to the registry, e.g. when the operator shuts down
 //   - registry: The address of the OCI registry (e.g., "docker.io/myorg/myrepo")
-//   - tag: The tag or reference of the artifact to fetch
-//   - creds: Docker credentials in JSON format for authentication, or empty for anonymous access
+//   - tag: The tag or reference of the artifact to fetch; the tag of a tag pinned to a digest
+//     ("v1@sha256:...") has to resolve to that digest
+//   - opts: The credentials and retry policy to use for the connection
 //
 // Returns:
 //   - A string representation of the artifact's digest (e.g., "sha256:1234abcd...")
+//   - An error if the registry cannot be reached or the artifact does not exist, classified as
+//     ErrAuth, ErrNotFound or ErrTransient (wrapped) where possible, or ErrPinnedTagMismatch
+//     (wrapped) if the tag of a pinned tag points to another digest
 //
 // This function is useful for determining if an artifact has changed by comparing its digest
 // with a previously stored value. The digest uniquely identifies the content of the artifact.
-func GetDigest(registry string, tag string, creds []byte) string {
-	// Create a client to connect to the registry
-	repo := CreateClient(registry, creds)
+// The lookup is traced as the span "fetch".
+func GetDigest(ctx context.Context, registry string, tag string, opts ClientOptions) (string, error) {
+	ctx, span := tracer.Start(ctx, "fetch", trace.WithAttributes(
+		attribute.String("registry", registry), attribute.String("tag", tag)))
+	digest, err := getDigest(ctx, registry, tag, opts)
+	span.SetAttributes(attribute.String("digest", digest))
+	endSpan(span, err)
+	return digest, err
+}
+
+// getDigest resolves the digest of the artifact, see GetDigest.
+func getDigest(ctx context.Context, registry string, tag string, opts ClientOptions) (string, error) {
+	ref, err := ParseArtifactReference(registry, tag)
+	if err != nil {
+		return "", err
+	}
 
-	// Create a context for the operation
-	ctx := context.Background()
+	// Create a client to connect to the registry
+	repo, err := CreateClient(registry, opts)
+	if err != nil {
+		return "", err
+	}
+	if err := verifyPinnedTag(ctx, repo, ref, tag); err != nil {
+		return "", err
+	}
 
 	// Fetch just the manifest descriptor without downloading the entire artifact
-	manifestDescriptor, _, err := oras.Fetch(ctx, repo, tag, oras.DefaultFetchOptions)
+	manifestDescriptor, _, err := oras.Fetch(ctx, repo, ref.Reference, oras.DefaultFetchOptions)
 	if err != nil {
-		panic(err)
+		return "", classifyError(fmt.Errorf("failed to fetch manifest of %s:%s: %w", registry, tag, err))
 	}
 
 	// Return the string representation of the digest
-	return manifestDescriptor.Digest.String()
+	return manifestDescriptor.Digest.String(), nil
 }
 
+// PullOptions contains optional settings for downloading an artifact with GetFiles.
+type PullOptions struct {
+	// MaxArtifactSize is the maximum number of bytes an artifact may have; 0 means unlimited.
+	MaxArtifactSize int64
+	// AllowedMediaTypes are the layer media types (path.Match patterns) an artifact may contain;
+	// empty means every media type is allowed.
+	AllowedMediaTypes []string
+	// PathSeparator replaces the path separator in the keys of files in subdirectories of the
+	// artifact; empty means only the files at the top level of the artifact are read.
+	PathSeparator string
+	// StripPrefix is a directory (e.g. "dist") that is removed from the paths of the files below it
+	// before they are keyed; files outside of it keep their path. Files that end up with the same
+	// key are rejected.
+	StripPrefix string
+	// ExtractArchives replaces tar and tar+gzip files by the files they contain. Without MaxArtifactSize
+	// the files may have 1 MiB in total once extracted.
+	ExtractArchives bool
+	// Decompress decompresses gzip and zstd compressed files; files that aren't compressed are
+	// stored unchanged. Without MaxArtifactSize the files may have 1 MiB in total once decompressed.
+	Decompress bool
+	// DecompressFiles limits Decompress to the files with these keys; empty means all files.
+	DecompressFiles []string
+	// LayerTitles limits the download to the layers with these org.opencontainers.image.title
+	// annotations; empty means all layers.
+	LayerTitles []string
+	// MediaTypes limits the download to the layers whose media type matches one of these path.Match
+	// patterns, after LayerTitles; empty means all layers.
+	MediaTypes []string
+	// Platform (os/architecture[/variant], e.g. linux/amd64) selects the manifests of an image index
+	// that are downloaded; empty means the files of all manifests of the index are merged. It's
+	// ignored for references to a single manifest.
+	Platform string
+	// MaxFileCount is the maximum number of files of the artifact, counted after extracting archives;
+	// 0 means unlimited
+	MaxFileCount int
+	// IncludeConfig adds the config blob of the artifact manifest to the files under ConfigKey, unless
+	// it is the empty config.
+	IncludeConfig bool
+	// Gate is checked against the annotations of the manifest before the layers are downloaded, so
+	// artifacts below a minimum version or created before a point in time are never pulled.
+	Gate Gate
+	// MemoryThreshold is the announced size in bytes up to which the layers of a manifest are fetched
+	// straight into memory instead of being downloaded to a temporary directory and read back from
+	// there; 0 always uses the temporary directory. It doesn't change the files, so it isn't part of the
+	// cache keys of the pull options.
+	MemoryThreshold int64 `json:"-"`
+}
+
+// ConfigKey is the key of the config blob in the files of an artifact pulled with
+// PullOptions.IncludeConfig.
+const ConfigKey = ".oci-config"
+
+// ErrArtifactTooLarge is returned when an artifact exceeds PullOptions.MaxArtifactSize.
+var ErrArtifactTooLarge = errors.New("artifact exceeds the maximum size")
+
+// ErrTooManyFiles is returned when an artifact has more files than PullOptions.MaxFileCount.
+var ErrTooManyFiles = errors.New("artifact exceeds the maximum number of files")
+
 // GetFiles downloads an artifact from an OCI registry and returns its contents as a Filemap.
 //
 // Parameters:
+//   - ctx: Cancels the requests to the registry, e.g. when the operator shuts down
 //   - registry: The address of the OCI registry (e.g., "docker.io/myorg/myrepo")
 //   - tag: The tag or reference of the artifact to fetch
-//   - creds: Docker credentials in JSON format for authentication, or empty for anonymous access
+//   - opts: The credentials and retry policy to use for the connection
+//   - pullOptions: Optional settings for the download, such as the maximum artifact size
 //
 // Returns:
 //   - A Filemap containing the artifact's digest and a map of its files
+//   - An error if the artifact cannot be downloaded or read (classified like the errors of
+//     GetDigest), ErrUnsupportedManifest (wrapped) if the reference is a container image or a
+//     nested image index, ErrPlatformNotFound (wrapped) if an image index has no manifest for
+//     pullOptions.Platform, ErrConflictingFiles (wrapped) if the manifests of an image index contain
+//     different files with the same name or paths end up with the same key, ErrArtifactTooLarge (wrapped) if it exceeds
+//     pullOptions.MaxArtifactSize, or ErrDisallowedMediaType (wrapped) if a layer has a media type that is not in
+//     pullOptions.AllowedMediaTypes, or ErrLayerNotFound (wrapped) if a title of
+//     pullOptions.LayerTitles matches no layer, or ErrNoMatchingMediaType (wrapped) if no layer of a
+//     manifest matches pullOptions.MediaTypes, or ErrPinnedTagMismatch (wrapped) like GetDigest, or
+//     ErrArtifactGated or ErrInvalidGate (wrapped) if the annotations don't meet pullOptions.Gate
 //
 // This function performs several steps:
-// 1. Creates a temporary directory to store the downloaded files
-// 2. Sets up a file store using the ORAS library
-// 3. Downloads the artifact from the registry to the temporary directory
-// 4. Reads all files from the temporary directory into memory
-// 5. Returns a Filemap with the artifact's digest and file contents
+// 1. Resolves the manifests of the artifact, the manifests of an image index are selected by pullOptions.Platform,
+// and checks their annotations against pullOptions.Gate
+// 2. Checks the kind, size and layer media types announced by the manifests against the limits
+// 3. Downloads every manifest with downloadManifest, or fetches it into memory with fetchManifest below
+// pullOptions.MemoryThreshold, and merges their files
+// 4. Returns a Filemap with the digest of the reference (the index for an image index) and the file contents
 //
-// The temporary directory is automatically cleaned up when the function returns.
-func GetFiles(registy string, tag string, creds []byte) Filemap {
-	// 1. Create a temporary directory to store the downloaded files
-	tmpdir, err := os.MkdirTemp("/tmp", "oras")
+// The download is traced as the span "pull" with the child spans "fetch" (resolving the manifests),
+// "copy" (downloading the layers of a manifest) and "read-files" (reading the downloaded files).
+func GetFiles(ctx context.Context, registry string, tag string, opts ClientOptions, pullOptions PullOptions) (Filemap, error) {
+	ctx, span := tracer.Start(ctx, "pull", trace.WithAttributes(
+		attribute.String("registry", registry), attribute.String("tag", tag)))
+	content, err := getFiles(ctx, registry, tag, opts, pullOptions)
+	span.SetAttributes(attribute.String("digest", content.Digest.String()),
+		attribute.Int("files", len(content.Files)), attribute.Int64("bytes", filesSize(content.Files)))
+	endSpan(span, err)
+	return content, err
+}
+
+// getFiles downloads the artifact, see GetFiles.
+func getFiles(ctx context.Context, registry string, tag string, opts ClientOptions, pullOptions PullOptions) (Filemap, error) {
+	ref, err := ParseArtifactReference(registry, tag)
 	if err != nil {
-		panic(err)
+		return Filemap{}, err
 	}
-	// Ensure the temporary directory is removed when the function returns
-	defer os.RemoveAll(tmpdir)
 
-	// 2. Create a file store using the ORAS library
-	fs, err := file.New(tmpdir)
+	repo, err := CreateClient(registry, opts)
 	if err != nil {
-		panic(err)
+		return Filemap{}, err
+	}
+	if err := verifyPinnedTag(ctx, repo, ref, tag); err != nil {
+		return Filemap{}, err
 	}
-	defer fs.Close()
 
-	// 3. Create a context and connect to the remote repository
-	ctx := context.Background()
-	repo := CreateClient(registy, creds)
+	// The download progress is logged with the logger of the options, see copyProgress
+	return pullFiles(logr.NewContext(ctx, opts.Logger), repo, ref.Reference, pullOptions)
+}
 
-	// 4. Download the artifact from the registry to the file store
-	manifestDescriptor, err := oras.Copy(ctx, repo, tag, fs, tag, oras.DefaultCopyOptions)
+// pullFiles downloads the artifact the reference points to from the target, see GetFiles.
+func pullFiles(ctx context.Context, target oras.ReadOnlyTarget, reference string, pullOptions PullOptions) (Filemap, error) {
+	// 1. Resolve the manifests, an image index may contain a manifest per platform
+	fetchCtx, span := tracer.Start(ctx, "fetch", trace.WithAttributes(attribute.String("reference", reference)))
+	rootDescriptor, annotations, manifests, err := resolveManifests(fetchCtx, target, reference, pullOptions.Platform)
+	endSpan(span, err)
 	if err != nil {
-		panic(err)
+		return Filemap{}, classifyError(err)
+	}
+	if err := pullOptions.Gate.Check(annotations); err != nil {
+		return Filemap{}, err
 	}
 
-	// 5. Read all files from the temporary directory into memory
-	filesMap, err := GetFilesContentBinary(tmpdir)
+	// 2. Check the kind, size and media types announced by the manifests before downloading anything
+	var size int64
+	manifestSizes := make([]int64, len(manifests))
+	for i, manifest := range manifests {
+		manifestSize, err := checkManifest(manifest.descriptor, manifest.manifest, pullOptions)
+		if err != nil {
+			return Filemap{}, err
+		}
+		manifestSizes[i] = manifestSize
+		size += manifestSize
+	}
+	if pullOptions.MaxArtifactSize > 0 && size > pullOptions.MaxArtifactSize {
+		return Filemap{}, fmt.Errorf("%w: %d bytes announced, %d allowed", ErrArtifactTooLarge, size, pullOptions.MaxArtifactSize)
+	}
 
-	// 6. Return a Filemap with the artifact's digest and file contents
+	// 3. Download the manifests and merge their files
+	files := make(map[string][]byte)
+	modes := make(map[string]fs.FileMode)
+	var paths map[string]string
+	if pullOptions.PathSeparator != "" {
+		paths = make(map[string]string)
+	}
+	for i, manifest := range manifests {
+		var manifestFiles *fileCollector
+		if inMemory(manifest.manifest, manifestSizes[i], pullOptions) {
+			manifestFiles, err = fetchManifest(ctx, target, manifest.manifest, manifest.descriptor, manifestSizes[i], pullOptions)
+		} else {
+			manifestFiles, err = downloadManifest(ctx, target, manifest.descriptor, manifestSizes[i], pullOptions)
+		}
+		if err != nil {
+			return Filemap{}, classifyError(fmt.Errorf("failed to copy %s: %w", reference, err))
+		}
+		if pullOptions.IncludeConfig {
+			if err := addConfig(ctx, target, manifest.manifest, manifestFiles.files); err != nil {
+				return Filemap{}, classifyError(fmt.Errorf("failed to fetch the config of %s: %w", reference, err))
+			}
+		}
+		if err := mergeFiles(files, paths, modes, manifestFiles); err != nil {
+			return Filemap{}, err
+		}
+		// Every manifest is limited on its own, the merged files of an image index could exceed the limit
+		if pullOptions.MaxFileCount > 0 && len(files) > pullOptions.MaxFileCount {
+			return Filemap{}, fmt.Errorf("%w: more than %d files", ErrTooManyFiles, pullOptions.MaxFileCount)
+		}
+	}
+
+	// 4. Return a Filemap with the artifact's digest, file contents and annotations
 	return Filemap{
-		Digest: manifestDescriptor.Digest,
-		Files:  filesMap,
+		Digest:      rootDescriptor.Digest,
+		Files:       files,
+		Paths:       paths,
+		Modes:       modes,
+		Annotations: annotations,
+	}, nil
+}
+
+// downloadManifest downloads the layers of an artifact manifest (only the layers of
+// pullOptions.LayerTitles and pullOptions.MediaTypes if set) to a temporary directory and collects the files like
+// collectFiles. The temporary directory is removed when the function returns. The progress of the download,
+// which announces size bytes, is logged and counted with a copyProgress.
+func downloadManifest(ctx context.Context, target oras.ReadOnlyTarget, manifestDescriptor ocispec.Descriptor,
+	size int64, pullOptions PullOptions) (*fileCollector, error) {
+	tmpdir, err := os.MkdirTemp("/tmp", "oras")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpdir)
+
+	store, err := file.New(tmpdir)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	// Skip the layers that aren't selected
+	copyOptions := oras.DefaultCopyGraphOptions
+	if len(pullOptions.LayerTitles) > 0 || len(pullOptions.MediaTypes) > 0 {
+		copyOptions.FindSuccessors = findSelectedSuccessors(pullOptions.LayerTitles, pullOptions.MediaTypes)
 	}
+	progress := newCopyProgress(ctx, size)
+	copyOptions.PreCopy = progress.preCopy
+	copyOptions.PostCopy = progress.postCopy
+	copyCtx, span := tracer.Start(ctx, "copy", trace.WithAttributes(
+		attribute.String("digest", manifestDescriptor.Digest.String())))
+	err = oras.CopyGraph(copyCtx, target, store, manifestDescriptor, copyOptions)
+	endSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+
+	_, span = tracer.Start(ctx, "read-files")
+	collector, err := collectFiles(tmpdir, pullOptions)
+	if err == nil {
+		span.SetAttributes(attribute.Int("files", len(collector.files)), attribute.Int64("bytes", filesSize(collector.files)))
+	}
+	endSpan(span, err)
+	return collector, err
 }
 
 // GetFilesContentBinary reads all files from a directory and returns their contents as a map.
 //
 // Parameters:
 //   - dirPath: The path to the directory containing the files to read
+//   - maxSize: The maximum number of bytes to read in total; 0 means unlimited
 //
 // Returns:
 //   - A map where keys are filenames and values are the file contents as byte slices
-//   - An error if any file operations fail
+//   - An error if any file operations fail, or ErrArtifactTooLarge (wrapped) if the files
+//     exceed maxSize
 //
 // This function:
 // 1. Lists all entries in the specified directory
 // 2. Skips any subdirectories
-// 3. Reads each file's content into memory
-// 4. Creates a map with filenames as keys and file contents as values
-//
-// Note: Error messages are in German. They indicate directory reading errors or file reading errors.
-func GetFilesContentBinary(dirPath string) (map[string][]byte, error) {
-	// Initialize an empty map to store the file contents
-	files := make(map[string][]byte)
-
-	// Read all entries in the directory
-	entries, err := os.ReadDir(dirPath)
-	if err != nil {
-		return nil, fmt.Errorf("fehler beim Lesen des Verzeichnisses: %v", err)
-	}
-
-	// Process each entry in the directory
-	for _, entry := range entries {
-		// Skip subdirectories
-		if entry.IsDir() {
-			continue
-		}
-
-		// Read the file content
-		content, err := os.ReadFile(filepath.Join(dirPath, entry.Name()))
-		if err != nil {
-			return nil, fmt.Errorf("fehler beim Lesen der Datei %s: %v", entry.Name(), err)
-		}
-
-		// Add the file content to the map with the filename as the key
-		files[entry.Name()] = content
-	}
-
-	return files, nil
+// 3. Checks the size of each file against maxSize before reading it
+// 4. Reads the files with a bounded pool of workers (see readConcurrently)
+// 5. Creates a map with filenames as keys and file contents as values
+func GetFilesContentBinary(dirPath string, maxSize int64) (map[string][]byte, error) {
+	files, _, err := readFiles(dirPath, PullOptions{MaxArtifactSize: maxSize})
+	return files, err
 }
@@ -0,0 +1,50 @@
+package orasclient
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/go-logr/logr"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PulledBytes counts the bytes of the blobs downloaded from the registries, so the progress of long
+// downloads shows up before they complete. It has to be registered by the caller.
+var PulledBytes = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "ocisecret_registry_pulled_bytes_total",
+	Help: "Bytes of the blobs downloaded from the registries.",
+})
+
+// copyProgress logs the progress of the download of a manifest per blob at debug level (V(1)) and adds
+// the downloaded blobs to PulledBytes. Its handlers are safe for the concurrent copies of oras.CopyGraph.
+type copyProgress struct {
+	logger logr.Logger
+	// total is the announced size of the manifest, its config and the selected layers
+	total int64
+	// copied is the size of the blobs downloaded so far
+	copied atomic.Int64
+}
+
+// newCopyProgress returns the copyProgress of a manifest announcing total bytes. It logs to the logger of
+// the context, see getFiles.
+func newCopyProgress(ctx context.Context, total int64) *copyProgress {
+	return &copyProgress{logger: logr.FromContextOrDiscard(ctx).V(1), total: total}
+}
+
+// preCopy logs the start of the download of a blob, it is an oras.CopyGraphOptions.PreCopy handler.
+func (p *copyProgress) preCopy(_ context.Context, desc ocispec.Descriptor) error {
+	p.logger.Info("Downloading blob", "digest", desc.Digest, "mediaType", desc.MediaType,
+		"title", desc.Annotations[ocispec.AnnotationTitle], "size", desc.Size)
+	return nil
+}
+
+// postCopy counts a downloaded blob and logs the progress of the manifest, it is an
+// oras.CopyGraphOptions.PostCopy handler.
+func (p *copyProgress) postCopy(_ context.Context, desc ocispec.Descriptor) error {
+	copied := p.copied.Add(desc.Size)
+	PulledBytes.Add(float64(desc.Size))
+	p.logger.Info("Downloaded blob", "digest", desc.Digest, "title", desc.Annotations[ocispec.AnnotationTitle],
+		"copied", copied, "total", p.total)
+	return nil
+}
@@ -0,0 +1,45 @@
+package orasclient
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestPullFilesProgress(t *testing.T) {
+	store := pushTestArtifact(t, map[string]string{"app.yaml": "app", "db.yaml": "database"})
+
+	for _, memoryThreshold := range []int64{0, DefaultMemoryThreshold} {
+		// The blobs are copied concurrently
+		var mu sync.Mutex
+		var lines []string
+		logger := funcr.New(func(prefix, args string) {
+			mu.Lock()
+			defer mu.Unlock()
+			lines = append(lines, args)
+		}, funcr.Options{Verbosity: 1})
+		ctx := logr.NewContext(context.Background(), logger)
+
+		pulled := testutil.ToFloat64(PulledBytes)
+		pullOptions := PullOptions{LayerTitles: []string{"db.yaml"}, MemoryThreshold: memoryThreshold}
+		if _, err := pullFiles(ctx, store, "v1", pullOptions); err != nil {
+			t.Fatal(err)
+		}
+		// The manifest and the config are copied to the file store as well
+		if got := testutil.ToFloat64(PulledBytes) - pulled; got < float64(len("database")) {
+			t.Errorf("memoryThreshold %d: expected at least the layer to be counted, got %v bytes", memoryThreshold, got)
+		}
+		log := strings.Join(lines, "\n")
+		if !strings.Contains(log, `"msg"="Downloaded blob" "digest"`) || !strings.Contains(log, `"title"="db.yaml"`) {
+			t.Errorf("memoryThreshold %d: expected the progress of db.yaml to be logged, got %s", memoryThreshold, log)
+		}
+		if strings.Contains(log, `"title"="app.yaml"`) {
+			t.Errorf("memoryThreshold %d: expected the unselected app.yaml not to be downloaded, got %s", memoryThreshold, log)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package orasclient
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// testProxy is an HTTP proxy tunneling the CONNECT requests of every host to a single target, served by an
+// httptest server. It records the requested hosts.
+type testProxy struct {
+	server *httptest.Server
+	target string
+
+	mu    sync.Mutex
+	hosts []string
+}
+
+// newTestProxy starts a testProxy tunneling to the target address that is stopped at the end of the test.
+func newTestProxy(t *testing.T, target string) *testProxy {
+	t.Helper()
+	p := &testProxy{target: target}
+	p.server = httptest.NewServer(http.HandlerFunc(p.serveHTTP))
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+// requestedHosts returns the hosts requested since the last call.
+func (p *testProxy) requestedHosts() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	hosts := p.hosts
+	p.hosts = nil
+	return hosts
+}
+
+func (p *testProxy) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodConnect {
+		http.Error(w, "only CONNECT is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	p.mu.Lock()
+	p.hosts = append(p.hosts, req.Host)
+	p.mu.Unlock()
+
+	upstream, err := net.Dial("tcp", p.target)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	conn, _, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	if _, err := io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n"); err != nil {
+		upstream.Close()
+		conn.Close()
+		return
+	}
+	go func() {
+		defer upstream.Close()
+		io.Copy(upstream, conn)
+	}()
+	go func() {
+		defer conn.Close()
+		io.Copy(conn, upstream)
+	}()
+}
+
+// routeHost connects the clients created during the test to the target address instead of the host (host:port).
+func routeHost(t *testing.T, host, target string) {
+	t.Helper()
+	defaultTransport := http.DefaultTransport
+	transport := defaultTransport.(*http.Transport).Clone()
+	dialer := &net.Dialer{}
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if addr == host {
+			addr = target
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+	http.DefaultTransport = transport
+	t.Cleanup(func() { http.DefaultTransport = defaultTransport })
+}
+
+func TestRegistryProxy(t *testing.T) {
+	ctx := context.Background()
+	registry := newTestRegistry(t)
+	manifestDescriptor := registry.push(t, "configs", "v1", "text/plain", map[string]string{"app.yaml": "app"})
+	proxy := newTestProxy(t, registry.host())
+	// Requests to loopback addresses never use a proxy, the certificate of the registry is valid for example.com
+	routeHost(t, "example.com:443", registry.host())
+	opts := registry.clientOptions()
+	opts.ProxyURL = proxy.server.URL
+
+	tests := []struct {
+		name        string
+		noProxy     string
+		wantProxied bool
+	}{
+		{name: "proxied", wantProxied: true},
+		{name: "other host in NO_PROXY", noProxy: "registry.internal", wantProxied: true},
+		{name: "host in NO_PROXY", noProxy: "registry.internal,example.com", wantProxied: false},
+		{name: "domain in NO_PROXY", noProxy: ".com", wantProxied: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_PROXY", tt.noProxy)
+			t.Setenv("no_proxy", tt.noProxy)
+			got, err := GetDigest(ctx, "example.com/configs", "v1", opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != manifestDescriptor.Digest.String() {
+				t.Errorf("GetDigest() = %s, want %s", got, manifestDescriptor.Digest)
+			}
+			hosts := proxy.requestedHosts()
+			if proxied := slices.Contains(hosts, "example.com:443"); proxied != tt.wantProxied {
+				t.Errorf("expected proxied=%v, the proxy got requests for %v", tt.wantProxied, hosts)
+			}
+		})
+	}
+
+	if _, err := GetDigest(ctx, "example.com/configs", "v1", ClientOptions{ProxyURL: "://invalid"}); err == nil {
+		t.Error("expected an error for an invalid proxy URL")
+	}
+}
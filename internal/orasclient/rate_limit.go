@@ -0,0 +1,116 @@
+package orasclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"golang.org/x/time/rate"
+)
+
+// ErrRateLimited is matched by the *RateLimitError returned by a RateLimitedClient.
+var ErrRateLimited = errors.New("registry rate limit reached")
+
+// RateLimitError is returned by a RateLimitedClient instead of accessing a registry host whose
+// request budget is used up. The call should be retried after RetryAfter.
+type RateLimitError struct {
+	// Host is the registry host, e.g. "docker.io"
+	Host string
+	// RetryAfter is the time until the next request to the host is allowed
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s for %s, retry in %s", ErrRateLimited, e.Host, e.RetryAfter.Round(time.Millisecond))
+}
+
+// Is makes errors.Is(err, ErrRateLimited) match the RateLimitError.
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// RateLimitedClient is an ArtifactClient that limits the operations per registry host with a token
+// bucket, so that polling many OCISecrets stays within the pull quotas of registries like Docker Hub.
+// Operations exceeding the limit aren't delayed but fail with a *RateLimitError, so the caller can
+// retry later without blocking.
+type RateLimitedClient struct {
+	client ArtifactClient
+	limit  rate.Limit
+	burst  int
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+var _ ArtifactClient = &RateLimitedClient{}
+
+// NewRateLimitedClient returns a RateLimitedClient allowing perSecond operations of the client per
+// registry host on average and bursts of up to burst operations. A perSecond of zero or less doesn't
+// limit the client, it is returned unchanged.
+func NewRateLimitedClient(client ArtifactClient, perSecond float64, burst int) ArtifactClient {
+	if perSecond <= 0 {
+		return client
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimitedClient{client: client, limit: rate.Limit(perSecond), burst: burst, limiters: map[string]*rate.Limiter{}}
+}
+
+// registryHost returns the host of a registry reference such as "ghcr.io/org/configs".
+func registryHost(registry string) string {
+	host, _, _ := strings.Cut(registry, "/")
+	return host
+}
+
+// take takes a token of the registry host or returns a *RateLimitError if none is available.
+func (c *RateLimitedClient) take(registry string) error {
+	host := registryHost(registry)
+	c.mu.Lock()
+	limiter, ok := c.limiters[host]
+	if !ok {
+		limiter = rate.NewLimiter(c.limit, c.burst)
+		c.limiters[host] = limiter
+	}
+	c.mu.Unlock()
+
+	reservation := limiter.Reserve()
+	if delay := reservation.Delay(); delay > 0 {
+		reservation.Cancel()
+		return &RateLimitError{Host: host, RetryAfter: delay}
+	}
+	return nil
+}
+
+func (c *RateLimitedClient) GetDigest(ctx context.Context, registry string, tag string, opts ClientOptions) (string, error) {
+	if err := c.take(registry); err != nil {
+		return "", err
+	}
+	return c.client.GetDigest(ctx, registry, tag, opts)
+}
+
+func (c *RateLimitedClient) GetFiles(ctx context.Context, registry string, tag string, opts ClientOptions, pullOptions PullOptions) (Filemap, error) {
+	if err := c.take(registry); err != nil {
+		return Filemap{}, err
+	}
+	return c.client.GetFiles(ctx, registry, tag, opts, pullOptions)
+}
+
+func (c *RateLimitedClient) GetReferrerFiles(ctx context.Context, registry string, subject digest.Digest, artifactTypes []string,
+	maxSize int64, opts ClientOptions) (map[string][]byte, error) {
+	if err := c.take(registry); err != nil {
+		return nil, err
+	}
+	return c.client.GetReferrerFiles(ctx, registry, subject, artifactTypes, maxSize, opts)
+}
+
+func (c *RateLimitedClient) VerifySignature(ctx context.Context, registry string, subject digest.Digest, publicKeyPEM []byte, opts ClientOptions) error {
+	if err := c.take(registry); err != nil {
+		return err
+	}
+	return c.client.VerifySignature(ctx, registry, subject, publicKeyPEM, opts)
+}
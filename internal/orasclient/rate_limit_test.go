@@ -0,0 +1,42 @@
+package orasclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRateLimitedClient(t *testing.T) {
+	upstream := &digestClient{digest: "sha256:test"}
+	client := NewRateLimitedClient(upstream, 0.001, 2)
+
+	for range 2 {
+		if _, err := client.GetDigest(context.Background(), "docker.io/library/configs", "v1", ClientOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := client.GetDigest(context.Background(), "docker.io/other/configs", "v1", ClientOptions{})
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) || !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected a RateLimitError, got %v", err)
+	}
+	if rateLimitErr.Host != "docker.io" || rateLimitErr.RetryAfter <= 0 {
+		t.Errorf("unexpected RateLimitError %+v", rateLimitErr)
+	}
+
+	// Other hosts have their own budget
+	if _, err := client.GetDigest(context.Background(), "ghcr.io/org/configs", "v1", ClientOptions{}); err != nil {
+		t.Errorf("expected ghcr.io not to be limited, got %v", err)
+	}
+	if upstream.calls != 3 {
+		t.Errorf("expected 3 registry lookups, got %d", upstream.calls)
+	}
+}
+
+func TestNewRateLimitedClientUnlimited(t *testing.T) {
+	upstream := &digestClient{}
+	if client := NewRateLimitedClient(upstream, 0, 1); client != upstream {
+		t.Errorf("expected the client to be returned unchanged, got %T", client)
+	}
+}
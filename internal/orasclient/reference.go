@@ -0,0 +1,90 @@
+package orasclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry"
+)
+
+// ErrInvalidReference is returned when the repository or the tag of an artifact can't be parsed.
+var ErrInvalidReference = errors.New("invalid artifact reference")
+
+// ErrPinnedTagMismatch is returned when the tag of a tag pinned to a digest ("v1@sha256:...") doesn't
+// resolve to that digest, e.g. because the tag was moved to a new artifact.
+var ErrPinnedTagMismatch = errors.New("the tag doesn't point to the pinned digest")
+
+// ParseArtifactReference validates the repository (e.g. "ghcr.io/org/configs" or
+// "localhost:5000/team/apps/configs") and the tag or digest of an artifact and returns the parsed
+// reference. The tag may be a digest ("sha256:...") or a tag pinned to a digest ("v1@sha256:..."),
+// both resolve to the digest. Errors wrap ErrInvalidReference.
+func ParseArtifactReference(repository, tag string) (registry.Reference, error) {
+	repositoryRef, err := registry.ParseReference(repository)
+	if err != nil {
+		return registry.Reference{}, fmt.Errorf("%w: repository %q: %w", ErrInvalidReference, repository, err)
+	}
+	if repositoryRef.Reference != "" {
+		return registry.Reference{}, fmt.Errorf("%w: repository %q contains a tag or digest, set it as the tag instead",
+			ErrInvalidReference, repository)
+	}
+	if tag == "" {
+		return registry.Reference{}, fmt.Errorf("%w: no tag or digest for %s", ErrInvalidReference, repository)
+	}
+
+	separator := ":"
+	if strings.Contains(tag, ":") && !strings.Contains(tag, "@") {
+		// A bare digest such as sha256:...
+		separator = "@"
+	}
+	ref, err := registry.ParseReference(repository + separator + tag)
+	if err != nil {
+		return registry.Reference{}, fmt.Errorf("%w: tag %q: %w", ErrInvalidReference, tag, err)
+	}
+	return ref, nil
+}
+
+// pinnedTag returns the tag of a tag pinned to a digest ("v1" of "v1@sha256:..."), or "" if the tag
+// isn't pinned.
+func pinnedTag(tag string) string {
+	if pinned, _, ok := strings.Cut(tag, "@"); ok {
+		return pinned
+	}
+	return ""
+}
+
+// verifyPinnedTag checks that the tag of a tag pinned to a digest still resolves to the digest of the
+// parsed reference, so a moved tag isn't silently ignored in favour of the digest. Tags that aren't
+// pinned are accepted. It returns ErrPinnedTagMismatch (wrapped) if the digests differ.
+func verifyPinnedTag(ctx context.Context, resolver content.Resolver, ref registry.Reference, tag string) error {
+	pinned := pinnedTag(tag)
+	if pinned == "" {
+		return nil
+	}
+	descriptor, err := resolver.Resolve(ctx, pinned)
+	if err != nil {
+		return classifyError(fmt.Errorf("failed to resolve the pinned tag %s: %w", pinned, err))
+	}
+	if descriptor.Digest.String() != ref.Reference {
+		return fmt.Errorf("%w: %s points to %s, pinned to %s", ErrPinnedTagMismatch, pinned, descriptor.Digest, ref.Reference)
+	}
+	return nil
+}
+
+// SplitArtifactRef splits a full artifact reference (e.g. "ghcr.io/org/configs:v1",
+// "ghcr.io/org/configs@sha256:..." or "ghcr.io/org/configs:v1@sha256:...") into the repository and the tag
+// or digest accepted by ParseArtifactReference. Errors wrap ErrInvalidReference.
+func SplitArtifactRef(artifactRef string) (repository, tag string, err error) {
+	ref, err := registry.ParseReference(artifactRef)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %q: %w", ErrInvalidReference, artifactRef, err)
+	}
+	if ref.Reference == "" {
+		return "", "", fmt.Errorf("%w: no tag or digest in %q", ErrInvalidReference, artifactRef)
+	}
+	// The reference is kept as written after the repository, so a tag pinned to a digest stays pinned
+	repository = ref.Registry + "/" + ref.Repository
+	return repository, artifactRef[len(repository)+1:], nil
+}
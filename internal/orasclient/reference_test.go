@@ -0,0 +1,131 @@
+package orasclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParseArtifactReference(t *testing.T) {
+	const digest = "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	tests := []struct {
+		name       string
+		repository string
+		tag        string
+		wantHost   string
+		wantRepo   string
+		wantRef    string
+		wantErr    bool
+	}{
+		{name: "registry with port", repository: "localhost:5000/configs", tag: "v1",
+			wantHost: "localhost:5000", wantRepo: "configs", wantRef: "v1"},
+		{name: "nested namespaces", repository: "registry.example.com:8443/team/apps/configs", tag: "v1",
+			wantHost: "registry.example.com:8443", wantRepo: "team/apps/configs", wantRef: "v1"},
+		{name: "digest", repository: "ghcr.io/org/configs", tag: digest,
+			wantHost: "ghcr.io", wantRepo: "org/configs", wantRef: digest},
+		{name: "tag and digest", repository: "localhost:5000/configs", tag: "v1@" + digest,
+			wantHost: "localhost:5000", wantRepo: "configs", wantRef: digest},
+		{name: "tag in the repository", repository: "ghcr.io/org/configs:v1", tag: "v2", wantErr: true},
+		{name: "no tag", repository: "ghcr.io/org/configs", wantErr: true},
+		{name: "invalid digest", repository: "ghcr.io/org/configs", tag: "sha256:abc", wantErr: true},
+		{name: "invalid repository", repository: "ghcr.io/Org/configs", tag: "v1", wantErr: true},
+		{name: "invalid tag", repository: "ghcr.io/org/configs", tag: "-v1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, err := ParseArtifactReference(tt.repository, tt.tag)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidReference) {
+					t.Errorf("expected ErrInvalidReference, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ref.Registry != tt.wantHost || ref.Repository != tt.wantRepo || ref.Reference != tt.wantRef {
+				t.Errorf("got %s %s %s, want %s %s %s", ref.Registry, ref.Repository, ref.Reference,
+					tt.wantHost, tt.wantRepo, tt.wantRef)
+			}
+		})
+	}
+}
+
+func TestVerifyPinnedTag(t *testing.T) {
+	ctx := context.Background()
+	store := pushTestArtifact(t, map[string]string{"app.yaml": "app"})
+	descriptor, err := store.Resolve(ctx, "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const otherDigest = "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+	tests := []struct {
+		name    string
+		tag     string
+		wantErr error
+	}{
+		{name: "pinned to the digest", tag: "v1@" + descriptor.Digest.String()},
+		{name: "digest only", tag: descriptor.Digest.String()},
+		{name: "tag only", tag: "v1"},
+		{name: "moved tag", tag: "v1@" + otherDigest, wantErr: ErrPinnedTagMismatch},
+		{name: "missing tag", tag: "v2@" + descriptor.Digest.String(), wantErr: ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, err := ParseArtifactReference("localhost:5000/configs", tt.tag)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = verifyPinnedTag(ctx, store, ref, tt.tag)
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			} else if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSplitArtifactRef(t *testing.T) {
+	const digest = "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	tests := []struct {
+		name           string
+		artifactRef    string
+		wantRepository string
+		wantTag        string
+		wantErr        bool
+	}{
+		{name: "tag", artifactRef: "ghcr.io/org/configs:v1", wantRepository: "ghcr.io/org/configs", wantTag: "v1"},
+		{name: "registry with port", artifactRef: "localhost:5000/team/configs:v1",
+			wantRepository: "localhost:5000/team/configs", wantTag: "v1"},
+		{name: "digest", artifactRef: "ghcr.io/org/configs@" + digest, wantRepository: "ghcr.io/org/configs", wantTag: digest},
+		{name: "tag and digest", artifactRef: "ghcr.io/org/configs:v1@" + digest,
+			wantRepository: "ghcr.io/org/configs", wantTag: "v1@" + digest},
+		{name: "no tag", artifactRef: "ghcr.io/org/configs", wantErr: true},
+		{name: "no registry", artifactRef: "configs:v1", wantErr: true},
+		{name: "invalid repository", artifactRef: "ghcr.io/Org/configs:v1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository, tag, err := SplitArtifactRef(tt.artifactRef)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidReference) {
+					t.Errorf("expected ErrInvalidReference, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if repository != tt.wantRepository || tag != tt.wantTag {
+				t.Errorf("got %s %s, want %s %s", repository, tag, tt.wantRepository, tt.wantTag)
+			}
+			// The parts are accepted by ParseArtifactReference
+			if _, err := ParseArtifactReference(repository, tag); err != nil {
+				t.Errorf("ParseArtifactReference(%s, %s) = %v", repository, tag, err)
+			}
+		})
+	}
+}
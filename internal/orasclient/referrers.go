@@ -0,0 +1,114 @@
+package orasclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+// ListReferrers lists the artifacts (e.g. signatures or SBOMs) that refer to a subject manifest
+// through the OCI referrers API.
+//
+// Parameters:
+//   - ctx: Cancels the requests to the registry, e.g. when the operator shuts down
+//   - registry: The address of the OCI registry (e.g., "docker.io/myorg/myrepo")
+//   - subject: The digest of the manifest the referrers point to
+//   - artifactType: Only referrers of this artifact type are returned; empty returns all referrers
+//   - opts: The credentials and retry policy to use for the connection
+//
+// Returns:
+//   - The descriptors of the referrer manifests
+//   - An error if the subject cannot be resolved or the referrers cannot be listed
+func ListReferrers(ctx context.Context, registry string, subject digest.Digest, artifactType string, opts ClientOptions) ([]ocispec.Descriptor, error) {
+	repo, err := CreateClient(registry, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve the subject to get its full descriptor (media type and size are needed by the referrers API)
+	subjectDescriptor, err := repo.Resolve(ctx, subject.String())
+	if err != nil {
+		return nil, classifyError(fmt.Errorf("failed to resolve subject %s: %w", subject, err))
+	}
+
+	var referrers []ocispec.Descriptor
+	err = repo.Referrers(ctx, subjectDescriptor, artifactType, func(page []ocispec.Descriptor) error {
+		referrers = append(referrers, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, classifyError(fmt.Errorf("failed to list referrers of %s: %w", subject, err))
+	}
+
+	return referrers, nil
+}
+
+// GetReferrerFiles downloads the referrers of a subject manifest whose artifact type is in artifactTypes
+// and returns them as a map of predictable keys to content.
+//
+// For every matching referrer the manifest itself is stored under
+// "referrer.<algorithm>-<hex>.manifest.json" and each of its layers under
+// "referrer.<algorithm>-<hex>.<layer index>", where <algorithm>-<hex> is the referrer's manifest digest.
+//
+// The referrer manifests and layers may have maxSize bytes in total; 0 means unlimited. Their announced
+// sizes are checked before they are fetched, so an oversized referrer fails with ErrArtifactTooLarge
+// (wrapped) without being read into memory.
+func GetReferrerFiles(ctx context.Context, registry string, subject digest.Digest, artifactTypes []string, maxSize int64,
+	opts ClientOptions) (map[string][]byte, error) {
+	repo, err := CreateClient(registry, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	var size int64
+	// reserve counts the blob against maxSize before it is fetched
+	reserve := func(desc ocispec.Descriptor) error {
+		size += desc.Size
+		if maxSize > 0 && size > maxSize {
+			return fmt.Errorf("%w: the referrers of %s have more than %d bytes", ErrArtifactTooLarge, subject, maxSize)
+		}
+		return nil
+	}
+	for _, artifactType := range artifactTypes {
+		referrers, err := ListReferrers(ctx, registry, subject, artifactType, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, referrer := range referrers {
+			if err := reserve(referrer); err != nil {
+				return nil, err
+			}
+			manifestContent, err := content.FetchAll(ctx, repo, referrer)
+			if err != nil {
+				return nil, classifyError(fmt.Errorf("failed to fetch referrer %s: %w", referrer.Digest, err))
+			}
+
+			var manifest ocispec.Manifest
+			if err := json.Unmarshal(manifestContent, &manifest); err != nil {
+				return nil, fmt.Errorf("failed to parse referrer manifest %s: %w", referrer.Digest, err)
+			}
+
+			keyPrefix := fmt.Sprintf("referrer.%s-%s", referrer.Digest.Algorithm(), referrer.Digest.Encoded())
+			files[keyPrefix+".manifest.json"] = manifestContent
+
+			for i, layer := range manifest.Layers {
+				if err := reserve(layer); err != nil {
+					return nil, err
+				}
+				blob, err := content.FetchAll(ctx, repo, layer)
+				if err != nil {
+					return nil, classifyError(fmt.Errorf("failed to fetch layer %s of referrer %s: %w", layer.Digest, referrer.Digest, err))
+				}
+				files[fmt.Sprintf("%s.%d", keyPrefix, i)] = blob
+			}
+		}
+	}
+
+	return files, nil
+}
@@ -0,0 +1,106 @@
+package orasclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// sbomArtifactType is the artifact type of the SBOM referrers of the tests.
+const sbomArtifactType = "application/vnd.example.sbom"
+
+func TestRegistryGetReferrerFiles(t *testing.T) {
+	ctx := context.Background()
+	registry := newTestRegistry(t)
+	subject := registry.push(t, "configs", "v1", "text/plain", map[string]string{"app.yaml": "app"})
+	sbom := registry.pushReferrer(t, "configs", subject, sbomArtifactType,
+		testLayer{mediaType: "application/spdx+json", content: []byte(`{"spdxVersion":"SPDX-2.3"}`)})
+	signature := registry.pushReferrer(t, "configs", subject, CosignSignatureArtifactType,
+		testLayer{mediaType: "application/vnd.dev.cosign.simplesigning.v1+json", content: []byte(`{}`)})
+	repository := registry.host() + "/configs"
+
+	keyPrefix := func(referrer string) string {
+		return fmt.Sprintf("referrer.sha256-%s", referrer)
+	}
+	tests := []struct {
+		name          string
+		artifactTypes []string
+		want          []string
+	}{
+		{name: "SBOMs", artifactTypes: []string{sbomArtifactType},
+			want: []string{keyPrefix(sbom.Digest.Encoded()) + ".0", keyPrefix(sbom.Digest.Encoded()) + ".manifest.json"}},
+		{name: "signatures", artifactTypes: []string{CosignSignatureArtifactType},
+			want: []string{keyPrefix(signature.Digest.Encoded()) + ".0", keyPrefix(signature.Digest.Encoded()) + ".manifest.json"}},
+		{name: "unknown artifact type", artifactTypes: []string{"application/vnd.example.unknown"}, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := GetReferrerFiles(ctx, repository, subject.Digest, tt.artifactTypes, 0, registry.clientOptions())
+			if err != nil {
+				t.Fatal(err)
+			}
+			keys := []string{}
+			for key := range files {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			if !reflect.DeepEqual(keys, tt.want) {
+				t.Errorf("GetReferrerFiles() keys = %v, want %v", keys, tt.want)
+			}
+		})
+	}
+
+	files, err := GetReferrerFiles(ctx, repository, subject.Digest, []string{sbomArtifactType}, 0, registry.clientOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(files[keyPrefix(sbom.Digest.Encoded())+".0"]); got != `{"spdxVersion":"SPDX-2.3"}` {
+		t.Errorf("expected the SBOM layer, got %q", got)
+	}
+}
+
+func TestRegistryGetReferrerFilesSizeLimit(t *testing.T) {
+	ctx := context.Background()
+	registry := newTestRegistry(t)
+	subject := registry.push(t, "configs", "v1", "text/plain", map[string]string{"app.yaml": "app"})
+	layer := []byte(`{"spdxVersion":"SPDX-2.3"}`)
+	sbom := registry.pushReferrer(t, "configs", subject, sbomArtifactType,
+		testLayer{mediaType: "application/spdx+json", content: layer})
+	repository := registry.host() + "/configs"
+	size := sbom.Size + int64(len(layer))
+
+	tests := []struct {
+		name    string
+		maxSize int64
+		wantErr error
+	}{
+		{name: "unlimited", maxSize: 0},
+		{name: "at the limit", maxSize: size},
+		{name: "layer beyond the limit", maxSize: size - 1, wantErr: ErrArtifactTooLarge},
+		{name: "manifest beyond the limit", maxSize: sbom.Size - 1, wantErr: ErrArtifactTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := GetReferrerFiles(ctx, repository, subject.Digest, []string{sbomArtifactType}, tt.maxSize,
+				registry.clientOptions())
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+				if files != nil {
+					t.Errorf("expected no files, got %v", files)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != 2 {
+				t.Errorf("expected the manifest and the layer of the SBOM, got %v", files)
+			}
+		})
+	}
+}
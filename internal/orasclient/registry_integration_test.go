@@ -0,0 +1,122 @@
+package orasclient
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRegistryGetDigest(t *testing.T) {
+	ctx := context.Background()
+	registry := newTestRegistry(t)
+	manifestDescriptor := registry.push(t, "team/configs", "v1", "text/plain", map[string]string{"app.yaml": "app"})
+	repository := registry.host() + "/team/configs"
+
+	tests := []struct {
+		name    string
+		tag     string
+		wantErr error
+	}{
+		{name: "tag", tag: "v1"},
+		{name: "digest", tag: manifestDescriptor.Digest.String()},
+		{name: "pinned tag", tag: "v1@" + manifestDescriptor.Digest.String()},
+		{name: "missing tag", tag: "v2", wantErr: ErrNotFound},
+		{name: "moved pinned tag", tag: "v1@sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+			wantErr: ErrPinnedTagMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetDigest(ctx, repository, tt.tag, registry.clientOptions())
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != manifestDescriptor.Digest.String() {
+				t.Errorf("GetDigest() = %s, want %s", got, manifestDescriptor.Digest)
+			}
+		})
+	}
+}
+
+func TestRegistryGetFiles(t *testing.T) {
+	ctx := context.Background()
+	registry := newTestRegistry(t)
+	manifestDescriptor := registry.push(t, "configs", "v1", "text/plain", map[string]string{
+		"app.yaml":     "app",
+		"db.yaml":      "db",
+		"conf/tls.crt": "cert",
+	})
+	repository := registry.host() + "/configs"
+
+	tests := []struct {
+		name        string
+		pullOptions PullOptions
+		want        map[string][]byte
+		wantErr     error
+	}{
+		{name: "top level files", pullOptions: PullOptions{},
+			want: map[string][]byte{"app.yaml": []byte("app"), "db.yaml": []byte("db")}},
+		{name: "in memory", pullOptions: PullOptions{MemoryThreshold: DefaultMemoryThreshold},
+			want: map[string][]byte{"app.yaml": []byte("app"), "db.yaml": []byte("db")}},
+		{name: "path separator", pullOptions: PullOptions{PathSeparator: "__"},
+			want: map[string][]byte{"app.yaml": []byte("app"), "db.yaml": []byte("db"), "conf__tls.crt": []byte("cert")}},
+		{name: "layer titles", pullOptions: PullOptions{LayerTitles: []string{"db.yaml"}},
+			want: map[string][]byte{"db.yaml": []byte("db")}},
+		{name: "media types", pullOptions: PullOptions{MediaTypes: []string{"text/*"}},
+			want: map[string][]byte{"app.yaml": []byte("app"), "db.yaml": []byte("db")}},
+		{name: "no matching media type", pullOptions: PullOptions{MediaTypes: []string{"application/*"}},
+			wantErr: ErrNoMatchingMediaType},
+		{name: "disallowed media type", pullOptions: PullOptions{AllowedMediaTypes: []string{"application/json"}},
+			wantErr: ErrDisallowedMediaType},
+		{name: "too large", pullOptions: PullOptions{MaxArtifactSize: 100}, wantErr: ErrArtifactTooLarge},
+		{name: "missing layer", pullOptions: PullOptions{LayerTitles: []string{"missing.yaml"}}, wantErr: ErrLayerNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filemap, err := GetFiles(ctx, repository, "v1", registry.clientOptions(), tt.pullOptions)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if filemap.Digest != manifestDescriptor.Digest {
+				t.Errorf("expected the digest %s, got %s", manifestDescriptor.Digest, filemap.Digest)
+			}
+			if !reflect.DeepEqual(filemap.Files, tt.want) {
+				t.Errorf("GetFiles() = %v, want %v", filemap.Files, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistryBasicAuth(t *testing.T) {
+	ctx := context.Background()
+	registry := newTestRegistry(t)
+	registry.username, registry.password = "user", "secret"
+	registry.push(t, "configs", "v1", "text/plain", map[string]string{"app.yaml": "app"})
+	repository := registry.host() + "/configs"
+
+	filemap, err := GetFiles(ctx, repository, "v1", registry.clientOptions(), PullOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(filemap.Files["app.yaml"]) != "app" {
+		t.Errorf("expected app.yaml, got %v", filemap.Files)
+	}
+
+	opts := registry.clientOptions()
+	opts.Password = "wrong"
+	if _, err := GetDigest(ctx, repository, "v1", opts); !errors.Is(err, ErrAuth) {
+		t.Errorf("expected ErrAuth with a wrong password, got %v", err)
+	}
+}
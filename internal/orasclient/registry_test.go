@@ -0,0 +1,295 @@
+package orasclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+)
+
+// testRegistry is an in-process OCI registry implementing the pull, push and referrers endpoints of the
+// distribution spec that ORAS uses, served over TLS by an httptest server. It keeps the repositories in memory.
+type testRegistry struct {
+	server *httptest.Server
+	// username and password are required as basic auth if username is set
+	username, password string
+
+	mu           sync.Mutex
+	repositories map[string]*testRepository
+	uploads      int
+}
+
+// testRepository is a repository of a testRegistry.
+type testRepository struct {
+	blobs map[digest.Digest][]byte
+	// manifests are the media types of the manifests, their content is in blobs
+	manifests map[digest.Digest]string
+	tags      map[string]digest.Digest
+	// referrers are the descriptors of the manifests referring to a subject manifest
+	referrers map[digest.Digest][]ocispec.Descriptor
+}
+
+// newTestRegistry starts a testRegistry that is stopped at the end of the test.
+func newTestRegistry(t testing.TB) *testRegistry {
+	t.Helper()
+	r := &testRegistry{repositories: make(map[string]*testRepository)}
+	r.server = httptest.NewTLSServer(http.HandlerFunc(r.serveHTTP))
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+// host is the host and port of the registry.
+func (r *testRegistry) host() string {
+	return strings.TrimPrefix(r.server.URL, "https://")
+}
+
+// clientOptions are the ClientOptions trusting the certificate of the registry and sending its credentials.
+func (r *testRegistry) clientOptions() ClientOptions {
+	return ClientOptions{
+		CACertificates: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: r.server.Certificate().Raw}),
+		Username:       r.username,
+		Password:       r.password,
+	}
+}
+
+// push pushes the files as titled layers of the media type of an artifact to the repository (e.g.
+// "configs") through the registry API with ORAS, tags it and returns the descriptor of its manifest.
+func (r *testRegistry) push(t testing.TB, repository, tag, mediaType string, files map[string]string) ocispec.Descriptor {
+	t.Helper()
+	ctx := context.Background()
+	repo, err := CreateClient(r.host()+"/"+repository, r.clientOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var layers []ocispec.Descriptor
+	for name, fileContent := range files {
+		layer := content.NewDescriptorFromBytes(mediaType, []byte(fileContent))
+		layer.Annotations = map[string]string{ocispec.AnnotationTitle: name}
+		if err := repo.Push(ctx, layer, strings.NewReader(fileContent)); err != nil {
+			t.Fatal(err)
+		}
+		layers = append(layers, layer)
+	}
+	manifestDescriptor, err := oras.PackManifest(ctx, repo, oras.PackManifestVersion1_1, "application/vnd.example",
+		oras.PackManifestOptions{Layers: layers})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Tag(ctx, manifestDescriptor, tag); err != nil {
+		t.Fatal(err)
+	}
+	return manifestDescriptor
+}
+
+// testLayer is a layer of a referrer pushed with pushReferrer.
+type testLayer struct {
+	mediaType   string
+	content     []byte
+	annotations map[string]string
+}
+
+// pushReferrer pushes the layers as a manifest of the artifact type referring to the subject to the
+// repository and returns the descriptor of the manifest.
+func (r *testRegistry) pushReferrer(t testing.TB, repository string, subject ocispec.Descriptor, artifactType string,
+	layers ...testLayer) ocispec.Descriptor {
+	t.Helper()
+	ctx := context.Background()
+	repo, err := CreateClient(r.host()+"/"+repository, r.clientOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var descriptors []ocispec.Descriptor
+	for _, layer := range layers {
+		descriptor := content.NewDescriptorFromBytes(layer.mediaType, layer.content)
+		descriptor.Annotations = layer.annotations
+		if err := repo.Push(ctx, descriptor, bytes.NewReader(layer.content)); err != nil {
+			t.Fatal(err)
+		}
+		descriptors = append(descriptors, descriptor)
+	}
+	manifestDescriptor, err := oras.PackManifest(ctx, repo, oras.PackManifestVersion1_1, artifactType,
+		oras.PackManifestOptions{Subject: &subject, Layers: descriptors})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return manifestDescriptor
+}
+
+// repository returns the repository with the name, creating it if needed. r.mu must be held.
+func (r *testRegistry) repository(name string) *testRepository {
+	repo, ok := r.repositories[name]
+	if !ok {
+		repo = &testRepository{
+			blobs:     make(map[digest.Digest][]byte),
+			manifests: make(map[digest.Digest]string),
+			tags:      make(map[string]digest.Digest),
+			referrers: make(map[digest.Digest][]ocispec.Descriptor),
+		}
+		r.repositories[name] = repo
+	}
+	return repo
+}
+
+func (r *testRegistry) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	if r.username != "" {
+		if username, password, ok := req.BasicAuth(); !ok || username != r.username || password != r.password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			writeRegistryError(w, http.StatusUnauthorized, "UNAUTHORIZED")
+			return
+		}
+	}
+	if req.URL.Path == "/v2/" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	path := strings.TrimPrefix(req.URL.Path, "/v2/")
+	for _, endpoint := range []string{"/manifests/", "/blobs/uploads/", "/blobs/", "/referrers/"} {
+		if i := strings.LastIndex(path, endpoint); i > 0 {
+			repo, reference := r.repository(path[:i]), path[i+len(endpoint):]
+			switch endpoint {
+			case "/manifests/":
+				r.serveManifest(w, req, repo, reference)
+			case "/blobs/uploads/":
+				r.serveUpload(w, req, repo, path[:i])
+			case "/referrers/":
+				serveReferrers(w, req, repo, reference)
+			default:
+				serveBlob(w, req, repo, reference)
+			}
+			return
+		}
+	}
+	writeRegistryError(w, http.StatusNotFound, "NAME_UNKNOWN")
+}
+
+// serveManifest serves the manifest with the tag or digest, or stores a pushed one.
+func (r *testRegistry) serveManifest(w http.ResponseWriter, req *http.Request, repo *testRepository, reference string) {
+	if req.Method == http.MethodPut {
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			writeRegistryError(w, http.StatusBadRequest, "MANIFEST_INVALID")
+			return
+		}
+		manifestDigest := digest.FromBytes(data)
+		repo.blobs[manifestDigest] = data
+		repo.manifests[manifestDigest] = req.Header.Get("Content-Type")
+		if _, err := digest.Parse(reference); err != nil {
+			repo.tags[reference] = manifestDigest
+		}
+		// Manifests with a subject are listed by the referrers endpoint of their subject
+		var manifest ocispec.Manifest
+		if err := json.Unmarshal(data, &manifest); err == nil && manifest.Subject != nil {
+			artifactType := manifest.ArtifactType
+			if artifactType == "" {
+				artifactType = manifest.Config.MediaType
+			}
+			repo.referrers[manifest.Subject.Digest] = append(repo.referrers[manifest.Subject.Digest], ocispec.Descriptor{
+				MediaType:    req.Header.Get("Content-Type"),
+				Digest:       manifestDigest,
+				Size:         int64(len(data)),
+				ArtifactType: artifactType,
+				Annotations:  manifest.Annotations,
+			})
+			w.Header().Set("OCI-Subject", manifest.Subject.Digest.String())
+		}
+		w.Header().Set("Docker-Content-Digest", manifestDigest.String())
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+
+	manifestDigest, ok := repo.tags[reference]
+	if !ok {
+		manifestDigest = digest.Digest(reference)
+	}
+	mediaType, ok := repo.manifests[manifestDigest]
+	if !ok {
+		writeRegistryError(w, http.StatusNotFound, "MANIFEST_UNKNOWN")
+		return
+	}
+	data := repo.blobs[manifestDigest]
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Docker-Content-Digest", manifestDigest.String())
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	if req.Method == http.MethodGet {
+		w.Write(data)
+	}
+}
+
+// serveUpload starts the upload of a blob and stores it when the upload is completed with its digest.
+func (r *testRegistry) serveUpload(w http.ResponseWriter, req *http.Request, repo *testRepository, name string) {
+	switch req.Method {
+	case http.MethodPost:
+		r.uploads++
+		w.Header().Set("Location", fmt.Sprintf("/v2/%s/blobs/uploads/%d", name, r.uploads))
+		w.WriteHeader(http.StatusAccepted)
+	case http.MethodPut:
+		data, err := io.ReadAll(req.Body)
+		blobDigest, parseErr := digest.Parse(req.URL.Query().Get("digest"))
+		if err != nil || parseErr != nil || blobDigest != digest.FromBytes(data) {
+			writeRegistryError(w, http.StatusBadRequest, "DIGEST_INVALID")
+			return
+		}
+		repo.blobs[blobDigest] = data
+		w.Header().Set("Docker-Content-Digest", blobDigest.String())
+		w.WriteHeader(http.StatusCreated)
+	default:
+		writeRegistryError(w, http.StatusMethodNotAllowed, "UNSUPPORTED")
+	}
+}
+
+// serveBlob serves the blob with the digest.
+func serveBlob(w http.ResponseWriter, req *http.Request, repo *testRepository, reference string) {
+	data, ok := repo.blobs[digest.Digest(reference)]
+	if !ok {
+		writeRegistryError(w, http.StatusNotFound, "BLOB_UNKNOWN")
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Docker-Content-Digest", reference)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	if req.Method == http.MethodGet {
+		io.Copy(w, bytes.NewReader(data))
+	}
+}
+
+// serveReferrers serves the image index listing the manifests referring to the subject digest, only the ones
+// of the artifactType query parameter if it is set.
+func serveReferrers(w http.ResponseWriter, req *http.Request, repo *testRepository, subject string) {
+	index := ocispec.Index{Versioned: specs.Versioned{SchemaVersion: 2}, MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{}}
+	artifactType := req.URL.Query().Get("artifactType")
+	for _, referrer := range repo.referrers[digest.Digest(subject)] {
+		if artifactType == "" || referrer.ArtifactType == artifactType {
+			index.Manifests = append(index.Manifests, referrer)
+		}
+	}
+	if artifactType != "" {
+		w.Header().Set("OCI-Filters-Applied", "artifactType")
+	}
+	w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+	json.NewEncoder(w).Encode(index)
+}
+
+// writeRegistryError writes an error response of the distribution spec.
+func writeRegistryError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{"errors": []map[string]string{{"code": code, "message": code}}})
+}
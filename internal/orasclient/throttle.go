@@ -0,0 +1,64 @@
+package orasclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrThrottled is matched by the *ThrottledError returned when a registry responds with 429 Too Many Requests.
+var ErrThrottled = errors.New("registry throttled the request")
+
+// DefaultThrottleDelay is the RetryAfter of a *ThrottledError when the registry sends no usable
+// Retry-After header.
+const DefaultThrottleDelay = time.Minute
+
+// ThrottledError is returned when a registry rejects a request with 429 Too Many Requests, e.g. because
+// the pull quota of Docker Hub is used up. The request isn't retried right away, the call should be
+// retried after RetryAfter.
+type ThrottledError struct {
+	// Host is the registry host, e.g. "docker.io"
+	Host string
+	// RetryAfter is the delay requested by the Retry-After header of the registry
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("%s for %s, retry in %s", ErrThrottled, e.Host, e.RetryAfter.Round(time.Millisecond))
+}
+
+// Is makes errors.Is(err, ErrThrottled) match the ThrottledError.
+func (e *ThrottledError) Is(target error) bool {
+	return target == ErrThrottled
+}
+
+// throttleTransport turns 429 Too Many Requests responses into a *ThrottledError carrying the
+// Retry-After of the registry. It sits below the retry transport, which doesn't retry errors, so a
+// throttled request isn't hammered with retries that ignore the Retry-After.
+type throttleTransport struct {
+	base http.RoundTripper
+	now  func() time.Time
+}
+
+func (t *throttleTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+		return resp, err
+	}
+	resp.Body.Close()
+	return nil, &ThrottledError{Host: req.URL.Host, RetryAfter: retryAfter(resp.Header.Get("Retry-After"), t.now())}
+}
+
+// retryAfter parses the value of a Retry-After header, either seconds or an HTTP date. Missing, invalid
+// and past values yield DefaultThrottleDelay.
+func retryAfter(value string, now time.Time) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return DefaultThrottleDelay
+}
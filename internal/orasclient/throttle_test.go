@@ -0,0 +1,64 @@
+package orasclient
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "seconds", value: "120", want: 2 * time.Minute},
+		{name: "date", value: now.Add(30 * time.Second).Format(http.TimeFormat), want: 30 * time.Second},
+		{name: "missing", value: "", want: DefaultThrottleDelay},
+		{name: "past date", value: now.Add(-time.Minute).Format(http.TimeFormat), want: DefaultThrottleDelay},
+		{name: "invalid", value: "soon", want: DefaultThrottleDelay},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryAfter(tt.value, now); got != tt.want {
+				t.Errorf("retryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetDigestThrottled(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Retry-After", "42")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	registry := strings.TrimPrefix(server.URL, "https://") + "/configs"
+	opts := ClientOptions{
+		CACertificates: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}),
+		Retry:          RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+	}
+
+	_, err := GetDigest(context.Background(), registry, "v1", opts)
+	var throttledErr *ThrottledError
+	if !errors.As(err, &throttledErr) {
+		t.Fatalf("expected a ThrottledError, got %v", err)
+	}
+	if throttledErr.RetryAfter != 42*time.Second || throttledErr.Host != strings.TrimPrefix(server.URL, "https://") {
+		t.Errorf("unexpected ThrottledError %+v", throttledErr)
+	}
+	// The throttled request isn't retried against the Retry-After of the registry
+	if got := requests.Load(); got != 1 {
+		t.Errorf("expected a single request, got %d", got)
+	}
+}
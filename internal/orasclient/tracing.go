@@ -0,0 +1,29 @@
+package orasclient
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the spans of the registry operations. It uses the global TracerProvider, so the spans
+// are only exported if the program installed one with otel.SetTracerProvider.
+var tracer = otel.Tracer("github.com/mariusbertram/oci-resource-sync-operator/internal/orasclient")
+
+// endSpan records the error of the operation, if any, on the span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// filesSize returns the total size of the content of the files.
+func filesSize(files map[string][]byte) int64 {
+	var size int64
+	for _, content := range files {
+		size += int64(len(content))
+	}
+	return size
+}
@@ -0,0 +1,58 @@
+package orasclient
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+func TestPullFilesSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	ctx := context.Background()
+	store := memory.New()
+	layer := content.NewDescriptorFromBytes("text/plain", []byte("app"))
+	layer.Annotations = map[string]string{ocispec.AnnotationTitle: "app.yaml"}
+	if err := store.Push(ctx, layer, bytes.NewReader([]byte("app"))); err != nil {
+		t.Fatal(err)
+	}
+	manifestDescriptor, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, "application/vnd.example",
+		oras.PackManifestOptions{Layers: []ocispec.Descriptor{layer}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Tag(ctx, manifestDescriptor, "v1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := pullFiles(ctx, store, "v1", PullOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, span := range recorder.Ended() {
+		names = append(names, span.Name())
+		if span.Name() == "read-files" {
+			for _, attr := range span.Attributes() {
+				if attr.Key == "bytes" && attr.Value != attribute.Int64Value(3) {
+					t.Errorf("expected 3 bytes to be read, got %v", attr.Value.Emit())
+				}
+			}
+		}
+	}
+	if len(names) != 3 || names[0] != "fetch" || names[1] != "copy" || names[2] != "read-files" {
+		t.Errorf("expected the spans fetch, copy and read-files, got %v", names)
+	}
+}
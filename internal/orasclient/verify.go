@@ -0,0 +1,168 @@
+package orasclient
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+const (
+	// CosignSignatureArtifactType is the artifact type cosign uses for signatures
+	// that are attached to an artifact through the OCI referrers API.
+	CosignSignatureArtifactType = "application/vnd.dev.cosign.artifact.sig.v1+json"
+
+	// cosignSignatureAnnotation is the layer annotation holding the base64 encoded signature of the layer payload.
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+
+	// maxSignatureSize is the maximum size of a signature manifest and of a signature payload. Both are a few
+	// hundred bytes in practice, larger ones are skipped without fetching them.
+	maxSignatureSize int64 = 64 * 1024
+)
+
+// ErrSignatureInvalid is returned by VerifySignature when no referrer carries a valid signature
+// of the subject for the given public key.
+var ErrSignatureInvalid = errors.New("no valid signature found")
+
+// simpleSigningPayload is the part of the cosign "simple signing" payload that binds a signature
+// to a manifest digest.
+type simpleSigningPayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+// VerifySignature checks that the subject manifest is signed by the given public key.
+//
+// Parameters:
+//   - ctx: Cancels the requests to the registry, e.g. when the operator shuts down
+//   - registry: The address of the OCI registry (e.g., "docker.io/myorg/myrepo")
+//   - subject: The digest of the manifest that must be signed
+//   - publicKeyPEM: A PEM encoded ECDSA, RSA or Ed25519 public key
+//   - opts: The credentials and retry policy to use for the connection
+//
+// Returns:
+//   - nil if at least one cosign signature attached via the referrers API is valid for the key
+//     and signs exactly the subject digest. Signature manifests and payloads larger than
+//     maxSignatureSize are skipped.
+//   - ErrSignatureInvalid (wrapped) if no such signature exists, or another error if the
+//     key cannot be parsed or the registry cannot be queried
+func VerifySignature(ctx context.Context, registry string, subject digest.Digest, publicKeyPEM []byte, opts ClientOptions) error {
+	publicKey, err := parsePublicKey(publicKeyPEM)
+	if err != nil {
+		return err
+	}
+
+	referrers, err := ListReferrers(ctx, registry, subject, CosignSignatureArtifactType, opts)
+	if err != nil {
+		return err
+	}
+
+	repo, err := CreateClient(registry, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, referrer := range referrers {
+		if referrer.Size > maxSignatureSize {
+			continue
+		}
+		manifestContent, err := fetchSignatureBlob(ctx, repo, referrer)
+		if err != nil {
+			return classifyError(fmt.Errorf("failed to fetch signature %s: %w", referrer.Digest, err))
+		}
+
+		var manifest ocispec.Manifest
+		if err := json.Unmarshal(manifestContent, &manifest); err != nil {
+			return fmt.Errorf("failed to parse signature manifest %s: %w", referrer.Digest, err)
+		}
+
+		for _, layer := range manifest.Layers {
+			encodedSignature, ok := layer.Annotations[cosignSignatureAnnotation]
+			if !ok {
+				continue
+			}
+			signature, err := base64.StdEncoding.DecodeString(encodedSignature)
+			if err != nil {
+				continue
+			}
+
+			if layer.Size > maxSignatureSize {
+				continue
+			}
+			payload, err := fetchSignatureBlob(ctx, repo, layer)
+			if err != nil {
+				return classifyError(fmt.Errorf("failed to fetch signature payload %s: %w", layer.Digest, err))
+			}
+
+			if verifyPayload(publicKey, payload, signature) && payloadSignsDigest(payload, subject) {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("%w for %s", ErrSignatureInvalid, subject)
+}
+
+// fetchSignatureBlob fetches a signature manifest or payload of at most maxSignatureSize bytes and verifies
+// its size and digest.
+func fetchSignatureBlob(ctx context.Context, fetcher content.Fetcher, desc ocispec.Descriptor) ([]byte, error) {
+	reader, err := fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return content.ReadAll(io.LimitReader(reader, maxSignatureSize+1), desc)
+}
+
+// parsePublicKey decodes a PEM encoded PKIX public key.
+func parsePublicKey(publicKeyPEM []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(publicKeyPEM)
+	if block == nil {
+		return nil, errors.New("failed to decode PEM public key")
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	return publicKey, nil
+}
+
+// verifyPayload reports whether signature is a valid signature of payload for the public key.
+func verifyPayload(publicKey crypto.PublicKey, payload []byte, signature []byte) bool {
+	hash := sha256.Sum256(payload)
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(key, hash[:], signature)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], signature) == nil
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, payload, signature)
+	default:
+		return false
+	}
+}
+
+// payloadSignsDigest reports whether the simple signing payload refers to the subject digest.
+func payloadSignsDigest(payload []byte, subject digest.Digest) bool {
+	var parsed simpleSigningPayload
+	if err := json.Unmarshal(payload, &parsed); err != nil {
+		return false
+	}
+	return parsed.Critical.Image.DockerManifestDigest == subject.String()
+}
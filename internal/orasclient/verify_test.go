@@ -0,0 +1,181 @@
+package orasclient
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// cosignPayloadMediaType is the media type of the simple signing payload layers of cosign.
+const cosignPayloadMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+
+// testSigner signs payloads and returns the PEM encoded public key for VerifySignature.
+type testSigner struct {
+	publicKeyPEM []byte
+	sign         func(payload []byte) []byte
+}
+
+func newECDSASigner(t *testing.T) testSigner {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testSigner{
+		publicKeyPEM: encodePublicKey(t, key.Public()),
+		sign: func(payload []byte) []byte {
+			hash := sha256.Sum256(payload)
+			signature, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			return signature
+		},
+	}
+}
+
+func newEd25519Signer(t *testing.T) testSigner {
+	t.Helper()
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testSigner{
+		publicKeyPEM: encodePublicKey(t, publicKey),
+		sign: func(payload []byte) []byte {
+			return ed25519.Sign(privateKey, payload)
+		},
+	}
+}
+
+// encodePublicKey encodes the public key as a PEM encoded PKIX public key.
+func encodePublicKey(t *testing.T, publicKey crypto.PublicKey) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+// testSigningPayload returns a cosign simple signing payload for the manifest digest.
+func testSigningPayload(manifestDigest digest.Digest) []byte {
+	return []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"registry.example.com/configs"},`+
+		`"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`, manifestDigest))
+}
+
+// signatureLayer returns the layer of a cosign signature of the payload.
+func signatureLayer(payload, signature []byte) testLayer {
+	return testLayer{
+		mediaType:   cosignPayloadMediaType,
+		content:     payload,
+		annotations: map[string]string{cosignSignatureAnnotation: base64.StdEncoding.EncodeToString(signature)},
+	}
+}
+
+func TestRegistryVerifySignature(t *testing.T) {
+	ctx := context.Background()
+	registry := newTestRegistry(t)
+	ecdsaSigner := newECDSASigner(t)
+	ed25519Signer := newEd25519Signer(t)
+	otherSigner := newECDSASigner(t)
+
+	tests := []struct {
+		name string
+		// sign attaches the signatures to the subject, nil attaches none
+		sign    func(t *testing.T, repository string, subject ocispec.Descriptor)
+		key     []byte
+		wantErr error
+	}{
+		{name: "valid ECDSA signature", key: ecdsaSigner.publicKeyPEM,
+			sign: func(t *testing.T, repository string, subject ocispec.Descriptor) {
+				payload := testSigningPayload(subject.Digest)
+				registry.pushReferrer(t, repository, subject, CosignSignatureArtifactType,
+					signatureLayer(payload, ecdsaSigner.sign(payload)))
+			}},
+		{name: "valid Ed25519 signature", key: ed25519Signer.publicKeyPEM,
+			sign: func(t *testing.T, repository string, subject ocispec.Descriptor) {
+				payload := testSigningPayload(subject.Digest)
+				registry.pushReferrer(t, repository, subject, CosignSignatureArtifactType,
+					signatureLayer(payload, ed25519Signer.sign(payload)))
+			}},
+		{name: "wrong key", key: otherSigner.publicKeyPEM, wantErr: ErrSignatureInvalid,
+			sign: func(t *testing.T, repository string, subject ocispec.Descriptor) {
+				payload := testSigningPayload(subject.Digest)
+				registry.pushReferrer(t, repository, subject, CosignSignatureArtifactType,
+					signatureLayer(payload, ecdsaSigner.sign(payload)))
+			}},
+		{name: "tampered payload", key: ecdsaSigner.publicKeyPEM, wantErr: ErrSignatureInvalid,
+			sign: func(t *testing.T, repository string, subject ocispec.Descriptor) {
+				payload := testSigningPayload(subject.Digest)
+				signature := ecdsaSigner.sign(payload)
+				tampered := append(append([]byte(nil), payload...), ' ')
+				registry.pushReferrer(t, repository, subject, CosignSignatureArtifactType, signatureLayer(tampered, signature))
+			}},
+		{name: "signature of another digest", key: ecdsaSigner.publicKeyPEM, wantErr: ErrSignatureInvalid,
+			sign: func(t *testing.T, repository string, subject ocispec.Descriptor) {
+				payload := testSigningPayload(digest.FromString("other artifact"))
+				registry.pushReferrer(t, repository, subject, CosignSignatureArtifactType,
+					signatureLayer(payload, ecdsaSigner.sign(payload)))
+			}},
+		{name: "no referrers", key: ecdsaSigner.publicKeyPEM, wantErr: ErrSignatureInvalid},
+		{name: "layer without signature annotation", key: ecdsaSigner.publicKeyPEM, wantErr: ErrSignatureInvalid,
+			sign: func(t *testing.T, repository string, subject ocispec.Descriptor) {
+				registry.pushReferrer(t, repository, subject, CosignSignatureArtifactType,
+					testLayer{mediaType: cosignPayloadMediaType, content: testSigningPayload(subject.Digest)})
+			}},
+		{name: "oversized payload", key: ecdsaSigner.publicKeyPEM, wantErr: ErrSignatureInvalid,
+			sign: func(t *testing.T, repository string, subject ocispec.Descriptor) {
+				// Trailing whitespace keeps the payload valid JSON
+				payload := append(testSigningPayload(subject.Digest), bytes.Repeat([]byte(" "), int(maxSignatureSize))...)
+				registry.pushReferrer(t, repository, subject, CosignSignatureArtifactType,
+					signatureLayer(payload, ecdsaSigner.sign(payload)))
+			}},
+		{name: "oversized signature manifest", key: ecdsaSigner.publicKeyPEM, wantErr: ErrSignatureInvalid,
+			sign: func(t *testing.T, repository string, subject ocispec.Descriptor) {
+				payload := testSigningPayload(subject.Digest)
+				layer := signatureLayer(payload, ecdsaSigner.sign(payload))
+				layer.annotations["padding"] = strings.Repeat("x", int(maxSignatureSize))
+				registry.pushReferrer(t, repository, subject, CosignSignatureArtifactType, layer)
+			}},
+		{name: "signed referrer of another artifact type", key: ecdsaSigner.publicKeyPEM, wantErr: ErrSignatureInvalid,
+			sign: func(t *testing.T, repository string, subject ocispec.Descriptor) {
+				payload := testSigningPayload(subject.Digest)
+				registry.pushReferrer(t, repository, subject, "application/vnd.example.sbom",
+					signatureLayer(payload, ecdsaSigner.sign(payload)))
+			}},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Every case signs its own artifact, so the signatures of the other cases aren't listed
+			repository := fmt.Sprintf("signed-%d", i)
+			subject := registry.push(t, repository, "v1", "text/plain", map[string]string{"app.yaml": "app"})
+			if tt.sign != nil {
+				tt.sign(t, repository, subject)
+			}
+
+			err := VerifySignature(ctx, registry.host()+"/"+repository, subject.Digest, tt.key, registry.clientOptions())
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("VerifySignature() = %v, want nil", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifySignature() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,31 +1,159 @@
 package utils
 
-// FilterMapInPlace filters a map in-place by keeping only the keys that are present in the allowedKeys slice.
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// FilterMapInPlace filters a map in-place by keeping only the keys that match one of the allowedKeys.
 // This function modifies the original map directly without creating a new one.
 //
 // Parameters:
 //   - m: The map to be filtered. It contains string keys and byte slice values.
 //   - allowedKeys: A slice of strings representing the keys that should be kept in the map.
+//     Entries may be shell-style glob patterns (path.Match syntax, e.g. "*.yaml" or "config-?.json").
 //
 // How it works:
-// 1. Creates a set (implemented as a map with empty struct values) from the allowedKeys for efficient lookups
-// 2. Iterates through all keys in the original map
-// 3. Deletes any key that is not present in the allowedSet
+// 1. Iterates through all keys in the original map
+// 2. Deletes any key that matches none of the allowedKeys, see MatchesAny
+// 3. Returns the deleted keys in sorted order, so callers can report what the filter removed
 //
 // This is useful for restricting a map to only contain specific keys, such as when
 // filtering files or configuration data to include only what's needed.
-func FilterMapInPlace(m map[string][]byte, allowedKeys []string) {
+func FilterMapInPlace(m map[string][]byte, allowedKeys []string) []string {
+	var removed []string
 
-	// Create a set from allowedKeys for O(1) lookups
-	allowedSet := make(map[string]struct{})
-	for _, key := range allowedKeys {
-		allowedSet[key] = struct{}{}
+	// Remove any key from the map that matches none of the allowed keys
+	for key := range m {
+		if !MatchesAny(key, allowedKeys) {
+			delete(m, key)
+			removed = append(removed, key)
+		}
 	}
+	sort.Strings(removed)
+	return removed
+}
 
-	// Remove any key from the map that is not in the allowed set
+// FilterMapExcludeInPlace filters a map in-place by removing the keys that match one of the excludedKeys.
+// It is the counterpart of FilterMapInPlace for cases where it is easier to name the keys to drop.
+//
+// Parameters:
+//   - m: The map to be filtered. It contains string keys and byte slice values.
+//   - excludedKeys: A slice of strings representing the keys that should be removed from the map.
+//     Entries may be glob patterns like in FilterMapInPlace.
+//
+// Keys that are not present in the map are ignored. The removed keys are returned in sorted order.
+func FilterMapExcludeInPlace(m map[string][]byte, excludedKeys []string) []string {
+	var removed []string
 	for key := range m {
-		if _, ok := allowedSet[key]; !ok {
+		if MatchesAny(key, excludedKeys) {
 			delete(m, key)
+			removed = append(removed, key)
+		}
+	}
+	sort.Strings(removed)
+	return removed
+}
+
+// UnmatchedPatterns returns the patterns, in their order, that match none of the keys of the map
+// (see MatchesAny), e.g. to report requested files that are missing.
+func UnmatchedPatterns(m map[string][]byte, patterns []string) []string {
+	var unmatched []string
+	for _, pattern := range patterns {
+		matched := false
+		for key := range m {
+			if MatchesAny(key, []string{pattern}) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			unmatched = append(unmatched, pattern)
+		}
+	}
+	return unmatched
+}
+
+// MatchesAny reports whether the key is equal to one of the patterns or matches it as a
+// path.Match glob pattern. Comparing literally first keeps keys that contain glob syntax
+// (e.g. "[1].yaml") selectable by their name; malformed patterns only match literally.
+func MatchesAny(key string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if key == pattern {
+			return true
+		}
+		if matched, err := path.Match(pattern, key); err == nil && matched {
+			return true
 		}
 	}
+	return false
+}
+
+// MapDiff lists the keys that were added, changed or removed between two versions of a map, e.g. the
+// files of two Filemaps or the files of a Filemap and the data of a Secret.
+type MapDiff struct {
+	Added   []string
+	Changed []string
+	Removed []string
+}
+
+// DiffMaps compares the old and new map. The key lists are sorted.
+func DiffMaps(oldMap, newMap map[string][]byte) MapDiff {
+	diff := MapDiff{}
+	for key, value := range newMap {
+		if oldValue, ok := oldMap[key]; !ok {
+			diff.Added = append(diff.Added, key)
+		} else if !bytes.Equal(oldValue, value) {
+			diff.Changed = append(diff.Changed, key)
+		}
+	}
+	for key := range oldMap {
+		if _, ok := newMap[key]; !ok {
+			diff.Removed = append(diff.Removed, key)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Changed)
+	sort.Strings(diff.Removed)
+	return diff
+}
+
+// Keys returns all added, changed and removed keys in sorted order.
+func (d MapDiff) Keys() []string {
+	keys := append(append(append([]string{}, d.Added...), d.Changed...), d.Removed...)
+	sort.Strings(keys)
+	return keys
+}
+
+// Empty reports whether no key was added, changed or removed.
+func (d MapDiff) Empty() bool {
+	return len(d.Added)+len(d.Changed)+len(d.Removed) == 0
+}
+
+// String summarizes the diff for events and logs, e.g. "updated 2 keys, removed 1".
+func (d MapDiff) String() string {
+	var parts []string
+	for _, change := range []struct {
+		verb string
+		keys []string
+	}{{"added", d.Added}, {"updated", d.Changed}, {"removed", d.Removed}} {
+		if len(change.keys) == 0 {
+			continue
+		}
+		part := fmt.Sprintf("%s %d", change.verb, len(change.keys))
+		if len(parts) == 0 {
+			part += " key"
+			if len(change.keys) > 1 {
+				part += "s"
+			}
+		}
+		parts = append(parts, part)
+	}
+	if len(parts) == 0 {
+		return "no keys changed"
+	}
+	return strings.Join(parts, ", ")
 }
@@ -0,0 +1,214 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterMapInPlace(t *testing.T) {
+	tests := []struct {
+		name        string
+		m           map[string][]byte
+		allowedKeys []string
+		want        map[string][]byte
+		wantRemoved []string
+	}{
+		{
+			name:        "nil map",
+			allowedKeys: []string{"a"},
+		},
+		{
+			name:        "empty map stays empty",
+			m:           map[string][]byte{},
+			allowedKeys: []string{"a"},
+			want:        map[string][]byte{},
+		},
+		{
+			name:        "empty allowed keys remove everything",
+			m:           map[string][]byte{"a": []byte("a")},
+			want:        map[string][]byte{},
+			wantRemoved: []string{"a"},
+		},
+		{
+			name:        "only allowed keys are kept",
+			m:           map[string][]byte{"a": []byte("a"), "b": []byte("b")},
+			allowedKeys: []string{"a", "missing"},
+			want:        map[string][]byte{"a": []byte("a")},
+			wantRemoved: []string{"b"},
+		},
+		{
+			name:        "glob patterns keep every matching key",
+			m:           map[string][]byte{"app.yaml": []byte("app"), "db.yaml": []byte("db"), "config-1.json": []byte("1"), "config-10.json": []byte("10")},
+			allowedKeys: []string{"*.yaml", "config-?.json"},
+			want:        map[string][]byte{"app.yaml": []byte("app"), "db.yaml": []byte("db"), "config-1.json": []byte("1")},
+			wantRemoved: []string{"config-10.json"},
+		},
+		{
+			name:        "literal key with glob syntax matches itself",
+			m:           map[string][]byte{"[1].yaml": []byte("1"), "1.yaml": []byte("one")},
+			allowedKeys: []string{"[1].yaml"},
+			want:        map[string][]byte{"[1].yaml": []byte("1"), "1.yaml": []byte("one")},
+		},
+		{
+			name:        "malformed pattern only matches literally",
+			m:           map[string][]byte{"[.yaml": []byte("a"), "b.yaml": []byte("b")},
+			allowedKeys: []string{"[.yaml"},
+			want:        map[string][]byte{"[.yaml": []byte("a")},
+			wantRemoved: []string{"b.yaml"},
+		},
+		{
+			name:        "patterns that match nothing remove everything",
+			m:           map[string][]byte{"app.yaml": []byte("app")},
+			allowedKeys: []string{"*.json"},
+			want:        map[string][]byte{},
+			wantRemoved: []string{"app.yaml"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			removed := FilterMapInPlace(tt.m, tt.allowedKeys)
+			if !reflect.DeepEqual(tt.m, tt.want) {
+				t.Errorf("got %v, want %v", tt.m, tt.want)
+			}
+			if !reflect.DeepEqual(removed, tt.wantRemoved) {
+				t.Errorf("removed %v, want %v", removed, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func TestFilterMapExcludeInPlace(t *testing.T) {
+	tests := []struct {
+		name         string
+		m            map[string][]byte
+		allowedKeys  []string
+		excludedKeys []string
+		want         map[string][]byte
+	}{
+		{
+			name:         "nil map",
+			excludedKeys: []string{"a"},
+		},
+		{
+			name:         "empty map stays empty",
+			m:            map[string][]byte{},
+			excludedKeys: []string{"a"},
+			want:         map[string][]byte{},
+		},
+		{
+			name: "empty excluded keys keep everything",
+			m:    map[string][]byte{"a": []byte("a")},
+			want: map[string][]byte{"a": []byte("a")},
+		},
+		{
+			name:         "excluded keys are removed",
+			m:            map[string][]byte{"a": []byte("a"), "b": []byte("b")},
+			excludedKeys: []string{"b", "missing"},
+			want:         map[string][]byte{"a": []byte("a")},
+		},
+		{
+			name:         "glob patterns remove every matching key",
+			m:            map[string][]byte{"app.yaml": []byte("app"), "app.json": []byte("json")},
+			excludedKeys: []string{"*.yaml"},
+			want:         map[string][]byte{"app.json": []byte("json")},
+		},
+		{
+			name:         "exclude wins over include for overlapping keys",
+			m:            map[string][]byte{"a": []byte("a"), "b": []byte("b"), "c": []byte("c")},
+			allowedKeys:  []string{"a", "b"},
+			excludedKeys: []string{"b", "c"},
+			want:         map[string][]byte{"a": []byte("a")},
+		},
+		{
+			name:         "excluding every included key leaves an empty map",
+			m:            map[string][]byte{"a": []byte("a"), "b": []byte("b")},
+			allowedKeys:  []string{"a"},
+			excludedKeys: []string{"a"},
+			want:         map[string][]byte{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Include first, then exclude, like the controller
+			if len(tt.allowedKeys) > 0 {
+				FilterMapInPlace(tt.m, tt.allowedKeys)
+			}
+			removed := FilterMapExcludeInPlace(tt.m, tt.excludedKeys)
+			if !reflect.DeepEqual(tt.m, tt.want) {
+				t.Errorf("got %v, want %v", tt.m, tt.want)
+			}
+			for _, key := range removed {
+				if !MatchesAny(key, tt.excludedKeys) {
+					t.Errorf("removed %s, which matches no excluded key", key)
+				}
+			}
+		})
+	}
+}
+
+func TestUnmatchedPatterns(t *testing.T) {
+	m := map[string][]byte{"app.yaml": []byte("app"), "[1].yaml": []byte("1")}
+	tests := []struct {
+		name     string
+		m        map[string][]byte
+		patterns []string
+		want     []string
+	}{
+		{name: "no patterns", m: m},
+		{name: "all patterns match", m: m, patterns: []string{"app.yaml", "*.yaml", "[1].yaml"}},
+		{name: "missing files are returned in order", m: m, patterns: []string{"db.yaml", "app.yaml", "*.json"},
+			want: []string{"db.yaml", "*.json"}},
+		{name: "empty map", m: map[string][]byte{}, patterns: []string{"app.yaml"}, want: []string{"app.yaml"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UnmatchedPatterns(tt.m, tt.patterns); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffMaps(t *testing.T) {
+	oldMap := map[string][]byte{"same": []byte("1"), "changed": []byte("a"), "removed": []byte("x")}
+	newMap := map[string][]byte{"same": []byte("1"), "changed": []byte("b"), "added": []byte("y"), "new": []byte("z")}
+
+	diff := DiffMaps(oldMap, newMap)
+	want := MapDiff{Added: []string{"added", "new"}, Changed: []string{"changed"}, Removed: []string{"removed"}}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("DiffMaps() = %+v, want %+v", diff, want)
+	}
+	if keys := diff.Keys(); !reflect.DeepEqual(keys, []string{"added", "changed", "new", "removed"}) {
+		t.Errorf("Keys() = %v", keys)
+	}
+	if diff.Empty() {
+		t.Error("expected a non-empty diff")
+	}
+	if unchanged := DiffMaps(newMap, newMap); !unchanged.Empty() || len(unchanged.Keys()) != 0 {
+		t.Errorf("expected no differences, got %+v", unchanged)
+	}
+	if removedAll := DiffMaps(oldMap, nil); !reflect.DeepEqual(removedAll.Removed, []string{"changed", "removed", "same"}) {
+		t.Errorf("expected all keys to be removed, got %+v", removedAll)
+	}
+}
+
+func TestMapDiffString(t *testing.T) {
+	tests := []struct {
+		diff MapDiff
+		want string
+	}{
+		{diff: MapDiff{}, want: "no keys changed"},
+		{diff: MapDiff{Added: []string{"a"}}, want: "added 1 key"},
+		{diff: MapDiff{Changed: []string{"a", "b"}, Removed: []string{"c"}}, want: "updated 2 keys, removed 1"},
+		{diff: MapDiff{Added: []string{"a"}, Changed: []string{"b"}, Removed: []string{"c", "d"}},
+			want: "added 1 key, updated 1, removed 2"},
+	}
+	for _, tt := range tests {
+		if got := tt.diff.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1aplha1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+)
+
+// defaultingPath is the path the OCISecretDefaulter is served at.
+const defaultingPath = "/mutate-oci-sync-brtrm-de-v1aplha1-ocisecret"
+
+// SetupOCISecretWebhookWithManager registers the OCISecretDefaulter with the webhook server of the manager.
+func SetupOCISecretWebhookWithManager(mgr ctrl.Manager, defaultNamespace string) error {
+	mgr.GetWebhookServer().Register(defaultingPath, &webhook.Admission{
+		Handler: &OCISecretDefaulter{DefaultNamespace: defaultNamespace},
+	})
+	return nil
+}
+
+// +kubebuilder:webhook:path=/mutate-oci-sync-brtrm-de-v1aplha1-ocisecret,mutating=true,failurePolicy=fail,sideEffects=None,groups=oci-sync.brtrm.de,resources=ocisecrets,verbs=create;update,versions=v1aplha1,name=mocisecret-v1aplha1.kb.io,admissionReviewVersions=v1
+
+// OCISecretDefaulter normalizes the casing of the spec fields of OCISecrets and defaults their Secret
+// namespaces. The spec mixes capitalized (ArtefactRegistry, Sync.Files) and lower camel case
+// (orasArtefact) field names, so keys like artefactRegistry or sync are easily written and would
+// otherwise be dropped by the API server without a trace. It works on the raw object, because the typed
+// OCISecret can't hold the mis-cased keys; the CRD preserves unknown fields of the spec and of Sync for
+// it. Keys that match no field, even ignoring case, are dropped with a warning.
+type OCISecretDefaulter struct {
+	// DefaultNamespace is the namespace of the Secret references without one of cluster-scoped
+	// OCISecrets, like the --default-namespace of the controller; empty leaves them unset.
+	DefaultNamespace string
+}
+
+// Handle implements admission.Handler.
+func (d *OCISecretDefaulter) Handle(_ context.Context, req admission.Request) admission.Response {
+	var object map[string]any
+	if err := json.Unmarshal(req.Object.Raw, &object); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	var warnings []string
+	if spec, ok := object["spec"].(map[string]any); ok {
+		warnings = normalizeKeys(spec, reflect.TypeOf(ocisyncv1aplha1.OCISecretSpec{}), "spec")
+	}
+
+	normalized, err := json.Marshal(object)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	ocisecret := &ocisyncv1aplha1.OCISecret{}
+	if err := json.Unmarshal(normalized, ocisecret); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	defaultNamespaces(ocisecret, d.DefaultNamespace)
+
+	defaulted, err := json.Marshal(ocisecret)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, defaulted).WithWarnings(warnings...)
+}
+
+// normalizeKeys renames the keys of the JSON object that match the JSON name of a field of the struct
+// type only when ignoring case to that name, recursing into nested objects and lists of objects. Keys
+// matching no field are removed, as are mis-cased keys of fields that are also set correctly. It
+// returns a warning for every renamed or removed key, prefixed with the path of the object.
+func normalizeKeys(object map[string]any, structType reflect.Type, path string) []string {
+	fields := make(map[string]reflect.Type, structType.NumField())
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = field.Type
+		}
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var warnings []string
+	for _, key := range keys {
+		if _, ok := fields[key]; ok {
+			continue
+		}
+		value := object[key]
+		delete(object, key)
+		name := ""
+		for fieldName := range fields {
+			if strings.EqualFold(fieldName, key) {
+				name = fieldName
+			}
+		}
+		switch _, set := object[name]; {
+		case name == "":
+			warnings = append(warnings, fmt.Sprintf("unknown field %s.%s was dropped", path, key))
+		case set:
+			warnings = append(warnings, fmt.Sprintf("%s.%s was dropped, %s.%s is set as well", path, key, path, name))
+		default:
+			object[name] = value
+			warnings = append(warnings, fmt.Sprintf("%s.%s was renamed to %s.%s", path, key, path, name))
+		}
+	}
+
+	for name, fieldType := range fields {
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		switch value := object[name].(type) {
+		case map[string]any:
+			if fieldType.Kind() == reflect.Struct {
+				warnings = append(warnings, normalizeKeys(value, fieldType, path+"."+name)...)
+			}
+		case []any:
+			elemType := fieldType
+			if elemType.Kind() == reflect.Slice {
+				elemType = elemType.Elem()
+			}
+			if elemType.Kind() != reflect.Struct {
+				continue
+			}
+			for i, item := range value {
+				if itemObject, ok := item.(map[string]any); ok {
+					warnings = append(warnings, normalizeKeys(itemObject, elemType, fmt.Sprintf("%s.%s[%d]", path, name, i))...)
+				}
+			}
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
+
+// defaultNamespaces fills in the empty namespaces of the Secrets referenced by the OCISecret, so the
+// stored OCISecret shows where they are read from and written to. A namespaced OCISecret defaults them
+// to its own namespace. A cluster-scoped OCISecret defaults the credential Secrets to the namespace of
+// its TargetSecret and the other references to defaultNamespace, if set. The controller applies the
+// same defaults to OCISecrets created without the webhook.
+func defaultNamespaces(ocisecret *ocisyncv1aplha1.OCISecret, defaultNamespace string) {
+	spec := &ocisecret.Spec
+	fallback := ocisecret.Namespace
+	if fallback == "" {
+		fallback = defaultNamespace
+	}
+	setDefault := func(namespace *string, value string) {
+		if *namespace == "" {
+			*namespace = value
+		}
+	}
+
+	setDefault(&spec.TargetSecret.Namespace, fallback)
+	for i := range spec.AdditionalTargets {
+		setDefault(&spec.AdditionalTargets[i].Namespace, fallback)
+	}
+	credentialNamespace := fallback
+	if ocisecret.Namespace == "" {
+		credentialNamespace = spec.TargetSecret.Namespace
+	}
+	if spec.ArtefactPullSecret.Name != "" {
+		setDefault(&spec.ArtefactPullSecret.Namespace, credentialNamespace)
+	}
+	if spec.BasicAuthSecretRef != nil {
+		setDefault(&spec.BasicAuthSecretRef.Namespace, credentialNamespace)
+	}
+	if spec.ClientCertSecretRef != nil {
+		setDefault(&spec.ClientCertSecretRef.Namespace, credentialNamespace)
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1aplha1
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	ocisyncv1aplha1 "github.com/mariusbertram/oci-resource-sync-operator/api/v1aplha1"
+)
+
+func TestNormalizeKeys(t *testing.T) {
+	spec := map[string]any{
+		"artefactRegistry": "registry.example.com/configs",
+		"orasArtefact":     "v1",
+		"TargetSecret":     map[string]any{"Name": "config"},
+		"sync": map[string]any{
+			"files":      []any{"app.yaml"},
+			"transforms": []any{map[string]any{"Type": "decode"}},
+		},
+		"suspend": false,
+		"Suspend": true,
+		"typo":    "value",
+	}
+	warnings := normalizeKeys(spec, reflect.TypeOf(ocisyncv1aplha1.OCISecretSpec{}), "spec")
+
+	want := map[string]any{
+		"ArtefactRegistry": "registry.example.com/configs",
+		"orasArtefact":     "v1",
+		"targetSecret":     map[string]any{"name": "config"},
+		"Sync": map[string]any{
+			"Files":      []any{"app.yaml"},
+			"transforms": []any{map[string]any{"type": "decode"}},
+		},
+		"suspend": false,
+	}
+	if !reflect.DeepEqual(spec, want) {
+		t.Errorf("normalizeKeys() = %v, want %v", spec, want)
+	}
+	wantWarnings := []string{
+		"spec.Suspend was dropped, spec.suspend is set as well",
+		"spec.Sync.files was renamed to spec.Sync.Files",
+		"spec.Sync.transforms[0].Type was renamed to spec.Sync.transforms[0].type",
+		"spec.TargetSecret was renamed to spec.targetSecret",
+		"spec.artefactRegistry was renamed to spec.ArtefactRegistry",
+		"spec.sync was renamed to spec.Sync",
+		"spec.targetSecret.Name was renamed to spec.targetSecret.name",
+		"unknown field spec.typo was dropped",
+	}
+	if !reflect.DeepEqual(warnings, wantWarnings) {
+		t.Errorf("normalizeKeys() warnings = %q, want %q", warnings, wantWarnings)
+	}
+}
+
+func TestDefaultNamespaces(t *testing.T) {
+	tests := []struct {
+		name             string
+		namespace        string
+		defaultNamespace string
+		targetNamespace  string
+		wantTarget       string
+		wantCredentials  string
+	}{
+		{name: "namespaced", namespace: "team", wantTarget: "team", wantCredentials: "team"},
+		{name: "cluster-scoped", defaultNamespace: "configs", wantTarget: "configs", wantCredentials: "configs"},
+		{name: "cluster-scoped with target namespace", defaultNamespace: "configs", targetNamespace: "team",
+			wantTarget: "team", wantCredentials: "team"},
+		{name: "cluster-scoped without default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ocisecret := &ocisyncv1aplha1.OCISecret{}
+			ocisecret.Namespace = tt.namespace
+			ocisecret.Spec.TargetSecret.Namespace = tt.targetNamespace
+			ocisecret.Spec.ArtefactPullSecret.Name = "pull-secret"
+			defaultNamespaces(ocisecret, tt.defaultNamespace)
+			if got := ocisecret.Spec.TargetSecret.Namespace; got != tt.wantTarget {
+				t.Errorf("target namespace = %q, want %q", got, tt.wantTarget)
+			}
+			if got := ocisecret.Spec.ArtefactPullSecret.Namespace; got != tt.wantCredentials {
+				t.Errorf("pull secret namespace = %q, want %q", got, tt.wantCredentials)
+			}
+		})
+	}
+}
+
+func TestOCISecretDefaulterHandle(t *testing.T) {
+	raw := []byte(`{"apiVersion":"oci-sync.brtrm.de/v1aplha1","kind":"OCISecret",` +
+		`"metadata":{"name":"config","namespace":"team"},` +
+		`"spec":{"artefactRegistry":"registry.example.com/configs","orasArtefact":"v1","targetSecret":{"name":"config"}}}`)
+	response := (&OCISecretDefaulter{}).Handle(context.Background(), admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: admissionv1.Create,
+			Namespace: "team",
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	})
+	if !response.Allowed {
+		t.Fatalf("expected the OCISecret to be allowed, got %+v", response.Result)
+	}
+	if len(response.Warnings) != 1 {
+		t.Errorf("expected a warning about the renamed field, got %q", response.Warnings)
+	}
+
+	patches := make(map[string]any, len(response.Patches))
+	for _, patch := range response.Patches {
+		patches[patch.Operation+" "+patch.Path] = patch.Value
+	}
+	for operation, value := range map[string]any{
+		"remove /spec/artefactRegistry":    nil,
+		"add /spec/ArtefactRegistry":       "registry.example.com/configs",
+		"add /spec/targetSecret/namespace": "team",
+	} {
+		if got, ok := patches[operation]; !ok || got != value {
+			t.Errorf("expected the patch %s %v, got %v", operation, value, response.Patches)
+		}
+	}
+}